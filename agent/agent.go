@@ -0,0 +1,338 @@
+// Package agent contains a server that keeps the keys of a profile
+// in memory and serves encrypt and decrypt requests over a Unix socket,
+// and a client for the server.
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/KarlGW/secman/internal/security"
+)
+
+var (
+	// ErrLocked is returned when the agent is locked.
+	ErrLocked = errors.New("agent is locked")
+	// ErrNotRunning is returned when no agent can be reached.
+	ErrNotRunning = errors.New("agent is not running")
+	// ErrUnsupported is returned when the agent is not supported on
+	// the current platform.
+	ErrUnsupported = errors.New("agent is not supported on this platform")
+	// ErrPeerNotAllowed is returned when the peer of a connection
+	// is not allowed to use the agent.
+	ErrPeerNotAllowed = errors.New("peer is not allowed")
+	// ErrInvalidRequest is returned when the agent receives an invalid request.
+	ErrInvalidRequest = errors.New("invalid request")
+)
+
+const (
+	// SocketEnv is the environment variable containing the path to
+	// the socket of a running agent.
+	SocketEnv = "SECMAN_AUTH_SOCK"
+	// DefaultTimeout is the default idle timeout of the agent.
+	DefaultTimeout = 15 * time.Minute
+)
+
+// connTimeout is the time a connection to the agent may take to be
+// served, so that idle connections do not keep the agent from stopping.
+var connTimeout = 10 * time.Second
+
+// KeyType represents which of the keys of a profile to use.
+type KeyType string
+
+const (
+	// KeyStorage is the key for the storage (collection file).
+	KeyStorage KeyType = "storage"
+	// KeySecret is the key for the secrets.
+	KeySecret KeyType = "secret"
+)
+
+// operation is an operation requested from the agent.
+type operation string
+
+const (
+	opEncrypt operation = "encrypt"
+	opDecrypt operation = "decrypt"
+	opStatus  operation = "status"
+	opStop    operation = "stop"
+)
+
+// request is a request to the agent.
+type request struct {
+	Op   operation `json:"op"`
+	Key  KeyType   `json:"key,omitempty"`
+	Data []byte    `json:"data,omitempty"`
+}
+
+// response is a response from the agent.
+type response struct {
+	Data   []byte `json:"data,omitempty"`
+	Status Status `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Status contains the status of an agent.
+type Status struct {
+	ProfileID string    `json:"profileId,omitempty"`
+	PID       int       `json:"pid,omitempty"`
+	Expires   time.Time `json:"expires,omitempty"`
+}
+
+// Server is an agent that holds the keys of a profile and serves
+// requests over a Unix socket.
+type Server struct {
+	profileID string
+	keys      map[KeyType][]byte
+	timeout   time.Duration
+	timer     *time.Timer
+	expires   time.Time
+	listener  net.Listener
+	mu        sync.Mutex
+	locked    bool
+	done      chan struct{}
+}
+
+// ServerOptions contains options for a Server.
+type ServerOptions struct {
+	Timeout time.Duration
+}
+
+// ServerOption is a function that sets ServerOptions.
+type ServerOption func(o *ServerOptions)
+
+// NewServer creates and returns a new Server. The keys are copied into
+// memory that is locked from being swapped to disk where the platform
+// supports it.
+func NewServer(profileID string, storageKey, key security.Key, options ...ServerOption) (*Server, error) {
+	if len(profileID) == 0 {
+		return nil, errors.New("a profile ID must be provided")
+	}
+	if len(storageKey.Value) != security.KeyLength || len(key.Value) != security.KeyLength {
+		return nil, security.ErrInvalidKeyLength
+	}
+
+	opts := ServerOptions{
+		Timeout: DefaultTimeout,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	keys := make(map[KeyType][]byte, 2)
+	for t, k := range map[KeyType][]byte{KeyStorage: storageKey.Value, KeySecret: key.Value} {
+		b := make([]byte, len(k))
+		if err := lockMemory(b); err != nil {
+			return nil, fmt.Errorf("lock memory: %w", err)
+		}
+		copy(b, k)
+		keys[t] = b
+	}
+
+	return &Server{
+		profileID: profileID,
+		keys:      keys,
+		timeout:   opts.Timeout,
+		done:      make(chan struct{}),
+	}, nil
+}
+
+// Listen creates the socket at the provided path and serves requests until
+// the agent is stopped or locked after being idle for the configured timeout.
+func (s *Server) Listen(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if err := removeStaleSocket(path); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve requests on the provided listener until the agent is stopped
+// or locked.
+func (s *Server) Serve(listener net.Listener) error {
+	s.mu.Lock()
+	s.listener = listener
+	s.resetTimer()
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-s.done:
+				wg.Wait()
+				return nil
+			default:
+				s.Lock()
+				return err
+			}
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+// Lock the agent. The keys are wiped from memory and the agent
+// stops serving requests.
+func (s *Server) Lock() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.locked {
+		return
+	}
+	s.locked = true
+	for t, k := range s.keys {
+		clear(k)
+		_ = unlockMemory(k)
+		delete(s.keys, t)
+	}
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	close(s.done)
+	if s.listener != nil {
+		s.listener.Close()
+	}
+}
+
+// handle a connection to the agent.
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(connTimeout)); err != nil {
+		return
+	}
+	if err := checkPeer(conn); err != nil {
+		writeResponse(conn, response{Error: err.Error()})
+		return
+	}
+
+	var req request
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+		writeResponse(conn, response{Error: ErrInvalidRequest.Error()})
+		return
+	}
+
+	switch req.Op {
+	case opStop:
+		writeResponse(conn, response{})
+		s.Lock()
+		return
+	case opStatus:
+		writeResponse(conn, response{Status: s.status()})
+		return
+	case opEncrypt, opDecrypt:
+		writeResponse(conn, s.crypt(req))
+		return
+	}
+	writeResponse(conn, response{Error: ErrInvalidRequest.Error()})
+}
+
+// crypt performs encrypt and decrypt requests.
+func (s *Server) crypt(req request) response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.locked {
+		return response{Error: ErrLocked.Error()}
+	}
+	key, ok := s.keys[req.Key]
+	if !ok {
+		return response{Error: ErrInvalidRequest.Error()}
+	}
+	s.resetTimer()
+
+	var b []byte
+	var err error
+	if req.Op == opEncrypt {
+		b, err = security.Encrypt(req.Data, key)
+	} else {
+		b, err = security.Decrypt(req.Data, key)
+	}
+	if err != nil {
+		return response{Error: err.Error()}
+	}
+	return response{Data: b}
+}
+
+// status returns the status of the agent.
+func (s *Server) status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Status{
+		ProfileID: s.profileID,
+		PID:       os.Getpid(),
+		Expires:   s.expires,
+	}
+}
+
+// resetTimer resets the idle timer. Must be called with
+// the lock held.
+func (s *Server) resetTimer() {
+	if s.timeout <= 0 {
+		return
+	}
+	s.expires = time.Now().Add(s.timeout)
+	if s.timer == nil {
+		s.timer = time.AfterFunc(s.timeout, s.Lock)
+		return
+	}
+	s.timer.Reset(s.timeout)
+}
+
+// writeResponse writes a response to the connection.
+func writeResponse(conn net.Conn, resp response) {
+	_ = json.NewEncoder(conn).Encode(resp)
+}
+
+// removeStaleSocket removes the socket at the provided path if no agent
+// is listening on it.
+func removeStaleSocket(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+	if _, err := NewClient(path).Status(); err == nil {
+		return errors.New("an agent is already listening on " + path)
+	}
+	return os.Remove(path)
+}
+
+// DefaultSocketPath returns the default path of the agent socket.
+func DefaultSocketPath() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if len(dir) == 0 {
+		dir = filepath.Join(os.TempDir(), "secman-"+strconv.Itoa(os.Getuid()))
+	} else {
+		dir = filepath.Join(dir, "secman")
+	}
+	return filepath.Join(dir, "agent.sock")
+}
+
+// WithTimeout sets the idle timeout of the agent. A timeout of 0
+// disables it.
+func WithTimeout(d time.Duration) ServerOption {
+	return func(o *ServerOptions) {
+		o.Timeout = d
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package agent
+
+// lockMemory is not supported on the current platform.
+func lockMemory(b []byte) error {
+	return nil
+}
+
+// unlockMemory is not supported on the current platform.
+func unlockMemory(b []byte) error {
+	return nil
+}
@@ -0,0 +1,183 @@
+//go:build linux || darwin
+
+package agent
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/KarlGW/secman/internal/security"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestServer(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			key  KeyType
+			data []byte
+		}
+		want    []byte
+		wantErr error
+	}{
+		{
+			name: "encrypt and decrypt with secret key",
+			input: struct {
+				key  KeyType
+				data []byte
+			}{
+				key:  KeySecret,
+				data: []byte(`data`),
+			},
+			want: []byte(`data`),
+		},
+		{
+			name: "encrypt and decrypt with storage key",
+			input: struct {
+				key  KeyType
+				data []byte
+			}{
+				key:  KeyStorage,
+				data: []byte(`data`),
+			},
+			want: []byte(`data`),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, _ := setupServer(t, 0)
+
+			encrypted, gotErr := client.Encrypt(test.input.key, test.input.data)
+			if gotErr != nil {
+				t.Fatalf("unexpected error in test: %v\n", gotErr)
+			}
+			got, gotErr := client.Decrypt(test.input.key, encrypted)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Encrypt()/Decrypt() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Encrypt()/Decrypt() = unexpected error (-want +got)\n%s\n", diff)
+			}
+
+			plain, _ := security.Decrypt(encrypted, _testKeys[test.input.key].Value)
+			if diff := cmp.Diff(test.want, plain); diff != "" {
+				t.Errorf("Encrypt() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestServer_Lock(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   func(client Client) error
+		timeout time.Duration
+		wantErr error
+	}{
+		{
+			name: "stop",
+			input: func(client Client) error {
+				return client.Stop()
+			},
+			wantErr: ErrNotRunning,
+		},
+		{
+			name: "stop with idle connection",
+			input: func(client Client) error {
+				// A connection that never sends a request.
+				if _, err := net.Dial("unix", client.path); err != nil {
+					return err
+				}
+				return client.Stop()
+			},
+			wantErr: ErrNotRunning,
+		},
+		{
+			name: "idle timeout",
+			input: func(client Client) error {
+				time.Sleep(100 * time.Millisecond)
+				return nil
+			},
+			timeout: 50 * time.Millisecond,
+			wantErr: ErrNotRunning,
+		},
+	}
+
+	originalConnTimeout := connTimeout
+	connTimeout = 100 * time.Millisecond
+	t.Cleanup(func() {
+		connTimeout = originalConnTimeout
+	})
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, done := setupServer(t, test.timeout)
+
+			if err := test.input(client); err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatalf("agent did not stop")
+			}
+
+			_, gotErr := client.Encrypt(KeySecret, []byte(`data`))
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Lock() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func setupServer(t *testing.T, timeout time.Duration) (Client, chan error) {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "secman")
+	if err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+	path := filepath.Join(dir, "agent.sock")
+
+	server, err := NewServer("AAAA", _testKeys[KeyStorage], _testKeys[KeySecret], WithTimeout(timeout))
+	if err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Listen(path)
+	}()
+	t.Cleanup(func() {
+		server.Lock()
+		_ = os.RemoveAll(dir)
+	})
+
+	client := NewClient(path)
+	for i := 0; i < 50; i++ {
+		if _, err := client.Status(); err == nil {
+			return client, done
+		} else if !errors.Is(err, ErrNotRunning) {
+			t.Fatalf("unexpected error in test: %v\n", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("agent did not start")
+	return client, done
+}
+
+var (
+	_testStorageKey, _ = security.NewKey()
+	_testSecretKey, _  = security.NewKeyFromPassword([]byte("test"))
+	_testKeys          = map[KeyType]security.Key{
+		KeyStorage: _testStorageKey,
+		KeySecret:  _testSecretKey,
+	}
+)
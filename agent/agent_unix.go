@@ -0,0 +1,16 @@
+//go:build unix
+
+package agent
+
+import "golang.org/x/sys/unix"
+
+// lockMemory locks the memory of the provided slice to prevent
+// it from being swapped to disk.
+func lockMemory(b []byte) error {
+	return unix.Mlock(b)
+}
+
+// unlockMemory unlocks the memory of the provided slice.
+func unlockMemory(b []byte) error {
+	return unix.Munlock(b)
+}
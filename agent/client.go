@@ -0,0 +1,107 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// Client is a client for an agent.
+type Client struct {
+	path    string
+	timeout time.Duration
+}
+
+// NewClient creates and returns a new Client for the agent
+// listening on the socket at the provided path.
+func NewClient(path string) Client {
+	return Client{
+		path:    path,
+		timeout: 10 * time.Second,
+	}
+}
+
+// Status returns the status of the agent.
+func (c Client) Status() (Status, error) {
+	resp, err := c.do(request{Op: opStatus})
+	if err != nil {
+		return Status{}, err
+	}
+	return resp.Status, nil
+}
+
+// Stop the agent.
+func (c Client) Stop() error {
+	_, err := c.do(request{Op: opStop})
+	return err
+}
+
+// Encrypt data with the provided key held by the agent.
+func (c Client) Encrypt(key KeyType, b []byte) ([]byte, error) {
+	resp, err := c.do(request{Op: opEncrypt, Key: key, Data: b})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// Decrypt data with the provided key held by the agent.
+func (c Client) Decrypt(key KeyType, b []byte) ([]byte, error) {
+	resp, err := c.do(request{Op: opDecrypt, Key: key, Data: b})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// Cipher returns a Cipher that encrypts and decrypts with the
+// provided key held by the agent.
+func (c Client) Cipher(key KeyType) Cipher {
+	return Cipher{client: c, key: key}
+}
+
+// do performs a request against the agent.
+func (c Client) do(req request) (response, error) {
+	conn, err := net.DialTimeout("unix", c.path, c.timeout)
+	if err != nil {
+		return response{}, fmt.Errorf("%w: %w", ErrNotRunning, err)
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return response{}, err
+	}
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return response{}, err
+	}
+	var resp response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return response{}, err
+	}
+	if len(resp.Error) > 0 {
+		if resp.Error == ErrLocked.Error() {
+			return response{}, ErrLocked
+		}
+		return response{}, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+// Cipher encrypts and decrypts data with a key held by
+// an agent.
+type Cipher struct {
+	client Client
+	key    KeyType
+}
+
+// Encrypt data.
+func (c Cipher) Encrypt(b []byte) ([]byte, error) {
+	return c.client.Encrypt(c.key, b)
+}
+
+// Decrypt data.
+func (c Cipher) Decrypt(b []byte) ([]byte, error) {
+	return c.client.Decrypt(c.key, b)
+}
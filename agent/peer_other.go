@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package agent
+
+import "net"
+
+// checkPeer is not supported on the current platform and
+// denies all connections.
+func checkPeer(conn net.Conn) error {
+	return ErrUnsupported
+}
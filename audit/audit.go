@@ -0,0 +1,315 @@
+// Package audit audits the decrypted values and metadata of secrets
+// for weak, reused, breached and old values and missing labels.
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KarlGW/secman/secret"
+)
+
+var (
+	// ErrInvalidSeverity is returned when a severity cannot be parsed.
+	ErrInvalidSeverity = errors.New("invalid severity")
+)
+
+// Severity is the severity of a finding.
+type Severity int
+
+const (
+	// SeverityLow is for findings that should be addressed.
+	SeverityLow Severity = iota + 1
+	// SeverityMedium is for findings that weaken the security of a secret.
+	SeverityMedium
+	// SeverityHigh is for findings that compromise the security of a secret.
+	SeverityHigh
+)
+
+// String returns the string representation of the severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityLow:
+		return "low"
+	case SeverityMedium:
+		return "medium"
+	case SeverityHigh:
+		return "high"
+	}
+	return ""
+}
+
+// MarshalJSON returns the string representation of the severity.
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// ParseSeverity parses the string representation of a severity.
+func ParseSeverity(s string) (Severity, error) {
+	for _, severity := range []Severity{SeverityLow, SeverityMedium, SeverityHigh} {
+		if severity.String() == s {
+			return severity, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %s", ErrInvalidSeverity, s)
+}
+
+// Checks of an audit.
+const (
+	CheckWeak       = "weak"
+	CheckReused     = "reused"
+	CheckNotRotated = "not-rotated"
+	CheckUnlabelled = "unlabelled"
+	CheckBreached   = "breached"
+)
+
+// Entropy thresholds in bits for weak values.
+const (
+	weakHigh   = 40
+	weakMedium = 60
+)
+
+// DefaultMaxAge is the default age after which a value should be rotated.
+const DefaultMaxAge = 365 * 24 * time.Hour
+
+// Finding is a finding of an audit.
+type Finding struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Check    string   `json:"check"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// Options contains options for audits.
+type Options struct {
+	MaxAge   time.Duration
+	BreachDB *BreachDB
+}
+
+// Option is a function that sets options for audits.
+type Option func(o *Options)
+
+// Audit decrypts the secrets and audits them. The values of generic
+// and credential secrets (the password field of structured values) are
+// checked for weakness, and the values of all secrets except notes and
+// files are compared for reuse by keyed hashes. With a breach database
+// the checked values are looked up in it. The findings are sorted
+// by severity and name.
+func Audit(secrets []secret.Secret, options ...Option) ([]Finding, error) {
+	opts := Options{
+		MaxAge: DefaultMaxAge,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	key := make([]byte, sha256.Size)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	reused := make(map[string][]secret.Secret)
+	var hashes []string
+	for _, s := range secrets {
+		value, ok, err := auditValue(s)
+		if err != nil {
+			return nil, err
+		}
+		if ok && len(value) > 0 {
+			if s.Type != secret.TypeSSHKey {
+				if f, ok := checkWeak(s, value); ok {
+					findings = append(findings, f)
+				}
+				if opts.BreachDB != nil {
+					f, ok, err := checkBreached(s, value, *opts.BreachDB)
+					if err != nil {
+						return nil, err
+					}
+					if ok {
+						findings = append(findings, f)
+					}
+				}
+			}
+			h := keyedHash(key, value)
+			if _, ok := reused[h]; !ok {
+				hashes = append(hashes, h)
+			}
+			reused[h] = append(reused[h], s)
+		}
+
+		if f, ok := checkNotRotated(s, opts.MaxAge); ok {
+			findings = append(findings, f)
+		}
+		if len(s.Labels) == 0 {
+			findings = append(findings, Finding{
+				ID:       s.ID,
+				Name:     s.Name,
+				Check:    CheckUnlabelled,
+				Severity: SeverityLow,
+				Message:  "secret has no labels",
+			})
+		}
+	}
+
+	for _, h := range hashes {
+		group := reused[h]
+		if len(group) < 2 {
+			continue
+		}
+		names := make([]string, len(group))
+		for i := range group {
+			names[i] = group[i].Name
+		}
+		for _, s := range group {
+			findings = append(findings, Finding{
+				ID:       s.ID,
+				Name:     s.Name,
+				Check:    CheckReused,
+				Severity: SeverityHigh,
+				Message:  "value is shared by " + strings.Join(names, ", "),
+			})
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Severity != findings[j].Severity {
+			return findings[i].Severity > findings[j].Severity
+		}
+		return findings[i].Name < findings[j].Name
+	})
+	return findings, nil
+}
+
+// Exceeds returns the findings with a severity at or above the threshold.
+func Exceeds(findings []Finding, threshold Severity) []Finding {
+	var exceeding []Finding
+	for _, f := range findings {
+		if f.Severity >= threshold {
+			exceeding = append(exceeding, f)
+		}
+	}
+	return exceeding
+}
+
+// WithMaxAge sets the age after which a value should be rotated.
+func WithMaxAge(d time.Duration) Option {
+	return func(o *Options) {
+		o.MaxAge = d
+	}
+}
+
+// WithBreachDB sets the breach database to check the values against.
+func WithBreachDB(db BreachDB) Option {
+	return func(o *Options) {
+		o.BreachDB = &db
+	}
+}
+
+// Password returns the password field of a structured value, or the
+// value if it is not structured. Returns false if a structured value
+// has no password field.
+func Password(value []byte) (string, bool) {
+	fields := make(map[string]string)
+	if err := json.Unmarshal(value, &fields); err == nil {
+		password, ok := fields["password"]
+		return password, ok
+	}
+	return string(value), true
+}
+
+// auditValue decrypts the secret and returns the value to audit. The
+// password field is used for structured values. Returns false for
+// notes and files.
+func auditValue(s secret.Secret) (string, bool, error) {
+	if s.Type == secret.TypeNote || s.Type == secret.TypeFile {
+		return "", false, nil
+	}
+	decrypted, err := s.Decrypt()
+	if err != nil {
+		return "", false, fmt.Errorf("%s: %w", s.Name, err)
+	}
+	if s.Type == secret.TypeSSHKey {
+		return string(decrypted), true, nil
+	}
+	password, ok := Password(decrypted)
+	return password, ok, nil
+}
+
+// checkWeak checks the entropy of the value.
+func checkWeak(s secret.Secret, value string) (Finding, bool) {
+	bits := Entropy(value)
+	var severity Severity
+	switch {
+	case bits < weakHigh:
+		severity = SeverityHigh
+	case bits < weakMedium:
+		severity = SeverityMedium
+	default:
+		return Finding{}, false
+	}
+	return Finding{
+		ID:       s.ID,
+		Name:     s.Name,
+		Check:    CheckWeak,
+		Severity: severity,
+		Message:  fmt.Sprintf("value has an estimated entropy of %.0f bits", bits),
+	}, true
+}
+
+// checkBreached checks if the value appears in the breach database.
+func checkBreached(s secret.Secret, value string, db BreachDB) (Finding, bool, error) {
+	n, err := db.Count(value)
+	if err != nil || n == 0 {
+		return Finding{}, false, err
+	}
+	return Finding{
+		ID:       s.ID,
+		Name:     s.Name,
+		Check:    CheckBreached,
+		Severity: SeverityHigh,
+		Message:  fmt.Sprintf("value appears %d times in the breach database", n),
+	}, true, nil
+}
+
+// checkNotRotated checks the time since the secret was updated.
+func checkNotRotated(s secret.Secret, maxAge time.Duration) (Finding, bool) {
+	updated := s.Updated
+	if updated.IsZero() {
+		updated = s.Created
+	}
+	if maxAge <= 0 || updated.IsZero() {
+		return Finding{}, false
+	}
+	age := now().Sub(updated)
+	if age <= maxAge {
+		return Finding{}, false
+	}
+	return Finding{
+		ID:       s.ID,
+		Name:     s.Name,
+		Check:    CheckNotRotated,
+		Severity: SeverityMedium,
+		Message:  fmt.Sprintf("value not updated for %d days", int(age.Hours()/24)),
+	}, true
+}
+
+// keyedHash returns the HMAC-SHA256 of the value with the key.
+func keyedHash(key []byte, value string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return string(mac.Sum(nil))
+}
+
+// now returns the current time.
+var now = func() time.Time {
+	return time.Now()
+}
@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/KarlGW/secman/secret"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEntropy(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input string
+		want  float64
+	}{
+		{
+			name:  "empty",
+			input: "",
+			want:  0,
+		},
+		{
+			name:  "repeated and sequential",
+			input: "aaaabcd",
+			want:  4.700439718141092,
+		},
+		{
+			name:  "character classes",
+			input: "aZ3!",
+			want:  4 * 6.569855608330948,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Entropy(test.input)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Entropy() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestAudit(t *testing.T) {
+	now = func() time.Time {
+		return time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	}
+	t.Cleanup(func() {
+		now = time.Now
+	})
+
+	key := bytes.Repeat([]byte{1}, secret.KeyLength)
+	newSecret := func(name, value string, updated time.Time, options ...secret.SecretOption) secret.Secret {
+		t.Helper()
+		s, err := secret.NewSecret(name, value, key, options...)
+		if err != nil {
+			t.Fatalf("NewSecret() = unexpected error: %v\n", err)
+		}
+		s.ID, s.Updated = name, updated
+		return s
+	}
+	recent := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	strong := "Xk9#mQ2$vL7&pR4!wT8z"
+
+	secrets := []secret.Secret{
+		newSecret("weak", "password", recent, secret.WithLabels("a")),
+		newSecret("first", strong, recent, secret.WithLabels("a")),
+		newSecret("second", `{"username":"user","password":"`+strong+`"}`, recent, secret.WithType(secret.TypeCredential), secret.WithLabels("a")),
+		newSecret("old", "Zu7%kd9#Lq2@Wn5!fH3e", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), secret.WithLabels("a")),
+		newSecret("note", "password", recent, secret.WithType(secret.TypeNote)),
+	}
+
+	got, gotErr := Audit(secrets)
+	want := []Finding{
+		{ID: "first", Name: "first", Check: CheckReused, Severity: SeverityHigh, Message: "value is shared by first, second"},
+		{ID: "second", Name: "second", Check: CheckReused, Severity: SeverityHigh, Message: "value is shared by first, second"},
+		{ID: "weak", Name: "weak", Check: CheckWeak, Severity: SeverityHigh, Message: "value has an estimated entropy of 33 bits"},
+		{ID: "old", Name: "old", Check: CheckNotRotated, Severity: SeverityMedium, Message: "value not updated for 517 days"},
+		{ID: "note", Name: "note", Check: CheckUnlabelled, Severity: SeverityLow, Message: "secret has no labels"},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Audit() = unexpected result (-want +got)\n%s\n", diff)
+	}
+
+	if gotErr != nil {
+		t.Errorf("Audit() = unexpected error: %v\n", gotErr)
+	}
+
+	if n := len(Exceeds(got, SeverityMedium)); n != 4 {
+		t.Errorf("Exceeds() = unexpected number of findings, want: 4, got: %d\n", n)
+	}
+}
@@ -0,0 +1,132 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrBreachDB is returned when the breach database cannot be used.
+	ErrBreachDB = errors.New("invalid breach database")
+)
+
+const (
+	// prefixLength is the length of the hash prefix of range files.
+	prefixLength = 5
+	// maxLineLength is the maximum length of a line in a range file.
+	maxLineLength = 128
+)
+
+// BreachDB is a local mirror of the Have I Been Pwned SHA-1 range files.
+// The directory contains a file <PREFIX>.txt for every 5 character
+// prefix of the hashes, with sorted lines of the format SUFFIX:COUNT.
+type BreachDB struct {
+	dir string
+}
+
+// OpenBreachDB opens the breach database in the directory.
+func OpenBreachDB(dir string) (BreachDB, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return BreachDB{}, fmt.Errorf("%w: %w", ErrBreachDB, err)
+	}
+	if !info.IsDir() {
+		return BreachDB{}, fmt.Errorf("%w: %s is not a directory", ErrBreachDB, dir)
+	}
+	return BreachDB{dir: dir}, nil
+}
+
+// Count returns the number of times the value appears in the breach
+// database. The range file of the value is searched without reading
+// all of it.
+func (db BreachDB) Count(value string) (int, error) {
+	sum := sha1.Sum([]byte(value))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:prefixLength], hash[prefixLength:]
+
+	f, err := os.Open(filepath.Join(db.dir, prefix+".txt"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0, fmt.Errorf("%w: range file %s.txt not found", ErrBreachDB, prefix)
+		}
+		return 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return searchRange(f, info.Size(), suffix)
+}
+
+// searchRange performs a binary search for the suffix over the sorted
+// lines of r, and returns its count. Returns 0 if it is not found.
+func searchRange(r io.ReaderAt, size int64, suffix string) (int, error) {
+	lo, hi := int64(0), size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		start, line, err := lineAt(r, size, mid)
+		if err != nil {
+			return 0, err
+		}
+		// No line starts between mid and hi.
+		if start >= hi {
+			hi = mid
+			continue
+		}
+
+		key, count, _ := strings.Cut(line, ":")
+		switch c := strings.Compare(strings.ToUpper(key), suffix); {
+		case c == 0:
+			n, err := strconv.Atoi(strings.TrimSpace(count))
+			if err != nil {
+				return 0, fmt.Errorf("%w: invalid line %q", ErrBreachDB, line)
+			}
+			return n, nil
+		case c < 0:
+			lo = start + int64(len(line)) + 1
+		default:
+			hi = start
+		}
+	}
+	return 0, nil
+}
+
+// lineAt returns the offset and content of the first line that starts
+// at or after the offset. The content excludes the line ending.
+func lineAt(r io.ReaderAt, size, offset int64) (int64, string, error) {
+	start := offset
+	reader := bufio.NewReaderSize(io.NewSectionReader(r, max(offset-1, 0), size), maxLineLength)
+	if offset > 0 {
+		// Skip to the line after the previous line ending.
+		skipped, err := reader.ReadSlice('\n')
+		if errors.Is(err, io.EOF) {
+			return size, "", nil
+		}
+		if err != nil {
+			return 0, "", err
+		}
+		start = offset - 1 + int64(len(skipped))
+	}
+
+	line, err := reader.ReadSlice('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, "", err
+	}
+	if len(line) == 0 {
+		return size, "", nil
+	}
+	// Keep the length of the line with \r so that the next line
+	// can be computed from the length of the content.
+	return start, strings.TrimSuffix(string(line), "\n"), nil
+}
@@ -0,0 +1,83 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestBreachDB_Count(t *testing.T) {
+	dir := t.TempDir()
+	lines := []string{
+		"0018A45C4D1DEF81644B54AB7F969B88D65:1",
+		"1E4C9B93F3F0682250B6CF8331B7EE68FD8:9659365",
+		"FFFFF45C4D1DEF81644B54AB7F969B88D65:3",
+	}
+	if err := os.WriteFile(filepath.Join(dir, "5BAA6.txt"), []byte(strings.Join(lines, "\r\n")+"\r\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	db, err := OpenBreachDB(dir)
+	if err != nil {
+		t.Fatalf("OpenBreachDB() = unexpected error: %v\n", err)
+	}
+
+	var tests = []struct {
+		name    string
+		input   string
+		want    int
+		wantErr error
+	}{
+		{
+			name:  "breached",
+			input: "password",
+			want:  9659365,
+		},
+		{
+			name:    "range file not found",
+			input:   "Xk9#mQ2$vL7&pR4!wT8z",
+			wantErr: ErrBreachDB,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := db.Count(test.input)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Count() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Count() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestSearchRange(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&b, "%035X:%d\r\n", i*2, i+1)
+	}
+	data := b.String()
+	r := strings.NewReader(data)
+
+	for i := 0; i < 2000; i++ {
+		want := 0
+		if i%2 == 0 {
+			want = i/2 + 1
+		}
+		got, err := searchRange(r, int64(len(data)), fmt.Sprintf("%035X", i))
+		if err != nil {
+			t.Fatalf("searchRange() = unexpected error: %v\n", err)
+		}
+		if got != want {
+			t.Errorf("searchRange(%d) = unexpected result, want: %d, got: %d\n", i, want, got)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package audit
+
+import (
+	"math"
+	"unicode"
+)
+
+// Sizes of the character classes.
+const (
+	poolLower  = 26
+	poolUpper  = 26
+	poolDigit  = 10
+	poolSymbol = 33
+	poolOther  = 100
+)
+
+// Entropy estimates the entropy of the value in bits. The estimate is
+// the number of characters times log2 of the size of the character
+// classes in use. Characters that repeat or continue a sequence (like
+// aaa, abc or 321) of the previous character are not counted.
+func Entropy(value string) float64 {
+	var lower, upper, digit, symbol, other bool
+	var effective int
+	prev := rune(-1)
+	for _, r := range value {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII && unicode.IsPrint(r):
+			symbol = true
+		default:
+			other = true
+		}
+		if r != prev && r != prev+1 && r != prev-1 {
+			effective++
+		}
+		prev = r
+	}
+
+	var pool int
+	for _, class := range []struct {
+		used bool
+		size int
+	}{
+		{lower, poolLower},
+		{upper, poolUpper},
+		{digit, poolDigit},
+		{symbol, poolSymbol},
+		{other, poolOther},
+	} {
+		if class.used {
+			pool += class.size
+		}
+	}
+	if pool == 0 {
+		return 0
+	}
+	return float64(effective) * math.Log2(float64(pool))
+}
@@ -1,7 +1,9 @@
 package secman
 
 import (
-	"os"
+	"errors"
+	"path/filepath"
+	"strings"
 
 	"github.com/KarlGW/secman/command"
 	"github.com/KarlGW/secman/output"
@@ -21,21 +23,77 @@ func CLI(args []string) int {
 		Version:              version.Version(),
 		EnableBashCompletion: true,
 		HideHelpCommand:      true,
+		// Exit codes are handled below.
+		ExitErrHandler: func(ctx *cli.Context, err error) {},
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "Output format: table, wide, json, yaml or template=<go-template>",
+				EnvVars: []string{"SECMAN_OUTPUT"},
+			},
+		},
 		Commands: []*cli.Command{
 			command.SecretGenerate(),
 			command.SecretList(),
 			command.SecretGet(),
+			command.SecretSearch(),
 			command.SecretCreate(),
 			command.SecretUpdate(),
 			command.SecretDelete(),
+			command.SecretImport(),
+			command.SecretExport(),
+			command.SecretDecryptExport(),
+			command.Audit(),
+			command.UI(),
+			command.Run(),
+			command.Inject(),
+			command.Env(),
+			command.Watch(),
+			command.GitCredential(),
+			command.DockerCredential(),
+			command.CredentialProcess(),
 			command.Profile(),
+			command.Agent(),
+			command.SSHAgent(),
+			command.SSHAdd(),
+			command.Serve(),
 			command.Completion(),
+			command.ClipboardClear(),
 		},
 	}
 
-	if err := app.Run(os.Args); err != nil {
+	// When invoked as a credential helper the arguments are passed
+	// to the command of the helper.
+	if len(args) > 0 {
+		if cmd, ok := credentialHelpers[executableName(args[0])]; ok {
+			args = append([]string{args[0], cmd}, args[1:]...)
+		}
+	}
+
+	if err := app.Run(args); err != nil {
+		var exitErr cli.ExitCoder
+		if errors.As(err, &exitErr) {
+			if len(exitErr.Error()) > 0 {
+				output.PrintErrorln(err)
+			}
+			return exitErr.ExitCode()
+		}
 		output.PrintErrorln(err)
 		return 1
 	}
 	return 0
 }
+
+// credentialHelpers maps the executable names of credential helpers
+// to the commands implementing them.
+var credentialHelpers = map[string]string{
+	command.DockerCredentialHelper: "docker-credential",
+	command.GitCredentialHelper:    "git-credential",
+}
+
+// executableName returns the name of the executable without
+// directory and extension.
+func executableName(executable string) string {
+	return strings.TrimSuffix(filepath.Base(executable), ".exe")
+}
@@ -0,0 +1,152 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/KarlGW/secman/agent"
+	"github.com/KarlGW/secman/internal/process"
+	"github.com/KarlGW/secman/output"
+	"github.com/urfave/cli/v2"
+)
+
+// Agent is a command for starting an agent that holds the
+// keys of the current profile.
+func Agent() *cli.Command {
+	return &cli.Command{
+		Name:     "agent",
+		Usage:    "Start an agent that holds the keys of the current profile",
+		Category: "Subcommands",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "socket",
+				Aliases: []string{"s"},
+				Usage:   "Path to the socket of the agent",
+				Value:   agent.DefaultSocketPath(),
+			},
+			&cli.DurationFlag{
+				Name:    "timeout",
+				Aliases: []string{"t"},
+				Usage:   "Lock the agent after being idle for the duration. 0 disables the timeout",
+				Value:   agent.DefaultTimeout,
+			},
+			&cli.BoolFlag{
+				Name:  "foreground",
+				Usage: "Run the agent in the foreground",
+			},
+		},
+		Subcommands: []*cli.Command{
+			AgentStop(),
+			AgentStatus(),
+		},
+		Before: func(ctx *cli.Context) error {
+			return configure(ctx)
+		},
+		Action: func(ctx *cli.Context) error {
+			if ctx.Bool("foreground") {
+				return runAgent(ctx)
+			}
+			return startAgent(ctx)
+		},
+	}
+}
+
+// AgentStop is a subcommand for stopping an agent.
+func AgentStop() *cli.Command {
+	return &cli.Command{
+		Name:  "stop",
+		Usage: "Stop the agent",
+		Action: func(ctx *cli.Context) error {
+			return agent.NewClient(agentSocket(ctx)).Stop()
+		},
+	}
+}
+
+// AgentStatus is a subcommand for showing the status of an agent.
+func AgentStatus() *cli.Command {
+	return &cli.Command{
+		Name:  "status",
+		Usage: "Show status of the agent",
+		Action: func(ctx *cli.Context) error {
+			status, err := agent.NewClient(agentSocket(ctx)).Status()
+			if err != nil {
+				return err
+			}
+			output.Println(fmt.Sprintf("profile: %s\npid: %d\nexpires: %s", status.ProfileID, status.PID, status.Expires.Format(time.RFC3339)))
+			return nil
+		},
+	}
+}
+
+// startAgent prompts for the password of the current profile and
+// starts the agent as a detached process. The environment needed
+// to use the agent is printed.
+func startAgent(ctx *cli.Context) error {
+	cfg, err := configuration(ctx)
+	if err != nil {
+		return err
+	}
+	if !cfg.Key().Valid() {
+		return errors.New("a password must be set on the profile")
+	}
+
+	password, err := passwordPrompt()
+	if err != nil {
+		return err
+	}
+	if err := verifyPassword(&cfg, password); err != nil {
+		return err
+	}
+
+	socket := ctx.String("socket")
+	pid, err := process.Spawn(nil, "agent", "--foreground", "--socket", socket, "--timeout", ctx.Duration("timeout").String())
+	if err != nil {
+		return err
+	}
+	if err := waitForAgent(socket, 5*time.Second); err != nil {
+		return err
+	}
+
+	output.Println(fmt.Sprintf("%s=%s; export %s;\necho Agent pid %d;", agent.SocketEnv, socket, agent.SocketEnv, pid))
+	return nil
+}
+
+// runAgent runs the agent in the foreground.
+func runAgent(ctx *cli.Context) error {
+	cfg, err := configuration(ctx)
+	if err != nil {
+		return err
+	}
+
+	server, err := agent.NewServer(cfg.ProfileID, cfg.StorageKey(), cfg.Key(), agent.WithTimeout(ctx.Duration("timeout")))
+	if err != nil {
+		return err
+	}
+	return server.Listen(ctx.String("socket"))
+}
+
+// waitForAgent waits for the agent listening on the socket to respond.
+func waitForAgent(socket string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		_, err := agent.NewClient(socket).Status()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// agentSocket returns the socket of the agent. It is taken from the
+// environment if set, otherwise the provided flag is used.
+func agentSocket(ctx *cli.Context) string {
+	if socket, ok := os.LookupEnv(agent.SocketEnv); ok && !ctx.IsSet("socket") {
+		return socket
+	}
+	return ctx.String("socket")
+}
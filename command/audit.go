@@ -0,0 +1,120 @@
+package command
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/KarlGW/secman/audit"
+	"github.com/KarlGW/secman/output"
+	"github.com/KarlGW/secman/secret"
+	"github.com/urfave/cli/v2"
+)
+
+// Audit is a command for auditing secrets.
+func Audit() *cli.Command {
+	return &cli.Command{
+		Name:     "audit",
+		Category: "Secrets",
+		Usage:    "Audit secrets for weak, reused and old values and missing labels",
+		Description: `Decrypts all secrets and reports:
+  weak         values with a low estimated entropy
+  reused       values shared by several secrets (compared by keyed hashes)
+  not-rotated  values not updated within the rotation days
+  unlabelled   secrets without labels
+  breached     values in the breach database (with --breach-db)
+
+Exits with a non-zero exit code if any finding is at or above the threshold.`,
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:  "rotation-days",
+				Usage: "Days after which a value should be rotated. 0 disables the check",
+				Value: int(audit.DefaultMaxAge.Hours() / 24),
+			},
+			&cli.StringFlag{
+				Name:  "threshold",
+				Usage: "Severity (low, medium or high) at or above which findings fail the audit",
+				Value: audit.SeverityHigh.String(),
+			},
+			breachDBFlag(),
+			outputFlag(),
+		},
+		Before: func(ctx *cli.Context) error {
+			return initHandler(ctx)
+		},
+		Action: func(ctx *cli.Context) error {
+			threshold, err := audit.ParseSeverity(ctx.String("threshold"))
+			if err != nil {
+				return err
+			}
+			handler, err := handler(ctx)
+			if err != nil {
+				return err
+			}
+			secrets, err := handler.ListSecrets()
+			if err != nil {
+				return err
+			}
+
+			options := []audit.Option{audit.WithMaxAge(time.Duration(ctx.Int("rotation-days")) * 24 * time.Hour)}
+			if dir := ctx.String("breach-db"); len(dir) > 0 {
+				db, err := audit.OpenBreachDB(dir)
+				if err != nil {
+					return err
+				}
+				options = append(options, audit.WithBreachDB(db))
+			}
+
+			findings, err := audit.Audit(secrets, options...)
+			if err != nil {
+				return err
+			}
+			if findings == nil {
+				findings = []audit.Finding{}
+			}
+			if err := writeOutput(ctx, output.KindTable, findingsTable(findings)); err != nil {
+				return err
+			}
+
+			if n := len(audit.Exceeds(findings, threshold)); n > 0 {
+				return cli.Exit(fmt.Sprintf("%d findings at or above severity %s", n, threshold), 1)
+			}
+			return nil
+		},
+	}
+}
+
+// breachDBFlag returns the flag for the directory of the breach database.
+func breachDBFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:    "breach-db",
+		Usage:   "Directory of the Have I Been Pwned SHA-1 range files to check values against",
+		EnvVars: []string{"SECMAN_BREACH_DB"},
+	}
+}
+
+// warnBreached prints a warning if the value of a secret of the provided
+// type appears in the breach database. Failures of the check are printed
+// as warnings.
+func warnBreached(ctx *cli.Context, value string, t secret.Type) {
+	dir := ctx.String("breach-db")
+	if len(dir) == 0 || len(value) == 0 || t == secret.TypeNote || t == secret.TypeFile || t == secret.TypeSSHKey {
+		return
+	}
+	password, ok := audit.Password([]byte(value))
+	if !ok {
+		return
+	}
+
+	db, err := audit.OpenBreachDB(dir)
+	var n int
+	if err == nil {
+		n, err = db.Count(password)
+	}
+	if err != nil {
+		output.Prompt(fmt.Sprintf("Warning: breach check failed: %v\n", err))
+		return
+	}
+	if n > 0 {
+		output.Prompt(fmt.Sprintf("Warning: the value appears %d times in the breach database\n", n))
+	}
+}
@@ -0,0 +1,61 @@
+package command
+
+import (
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/KarlGW/secman/internal/clipboard"
+	"github.com/KarlGW/secman/internal/process"
+	"github.com/urfave/cli/v2"
+)
+
+// ClipboardClear is a hidden command for clearing the clipboard after
+// a timeout. It is started as a detached process by the commands that
+// use the clipboard, and only clears the clipboard if it still holds
+// the value of the sum (a random key and HMAC) read from stdin.
+func ClipboardClear() *cli.Command {
+	return &cli.Command{
+		Name:   "clipboard-clear",
+		Hidden: true,
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Value: clipboard.DefaultTimeout,
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			sum, err := io.ReadAll(io.LimitReader(os.Stdin, clipboard.SumLength+1))
+			if err != nil {
+				return err
+			}
+			if len(sum) == 0 {
+				return errors.New("no sum provided")
+			}
+			time.Sleep(ctx.Duration("timeout"))
+			_, err = clipboard.ClearIfMatch(sum)
+			return err
+		},
+	}
+}
+
+// clearClipboardAfter starts a detached process that clears the
+// clipboard after the clipboard timeout of the current profile,
+// if the clipboard still holds the provided value.
+func clearClipboardAfter(ctx *cli.Context, value string) error {
+	cfg, err := configuration(ctx)
+	if err != nil {
+		return err
+	}
+	timeout := cfg.ClipboardTimeout()
+	if timeout == 0 || len(value) == 0 {
+		return nil
+	}
+	sum, err := clipboard.Sum(value)
+	if err != nil {
+		return err
+	}
+	_, err = process.Spawn(sum, "clipboard-clear", "--timeout", timeout.String())
+	return err
+}
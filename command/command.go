@@ -1,11 +1,18 @@
 package command
 
 import (
+	"bufio"
 	"errors"
+	"fmt"
+	"io"
+	"os"
 	"strings"
 	"syscall"
 
+	"github.com/KarlGW/secman/agent"
 	"github.com/KarlGW/secman/config"
+	"github.com/KarlGW/secman/hook"
+	"github.com/KarlGW/secman/internal/security"
 	"github.com/KarlGW/secman/output"
 	"github.com/KarlGW/secman/secret"
 	"github.com/KarlGW/secman/storage"
@@ -38,30 +45,89 @@ func configuration(ctx *cli.Context) (config.Configuration, error) {
 }
 
 // initHandler performs the necessary steps to setup a handler and
-// set it to the provided *cli.Context.
+// set it to the provided *cli.Context together with the configuration.
+// If an agent is set in the environment it is used for the keys.
 func initHandler(ctx *cli.Context) error {
 	cfg, err := config.Configure()
 	if err != nil {
 		return err
 	}
+
+	var handler *secret.Handler
+	if socket, ok := os.LookupEnv(agent.SocketEnv); ok && len(socket) > 0 {
+		handler, err = newAgentHandler(cfg, socket)
+	} else {
+		handler, err = newHandler(cfg)
+	}
+	if err != nil {
+		return err
+	}
+	ctx.App.Metadata["config"] = cfg
+	ctx.App.Metadata["handler"] = handler
+	return nil
+}
+
+// newHandler creates a handler for the current profile of the
+// provided configuration with the keys from the keyring.
+func newHandler(cfg config.Configuration) (*secret.Handler, error) {
 	if len(cfg.StorageKey().Value) != secret.KeyLength {
-		return errors.New("a key must be set for storage")
+		return nil, errors.New("a key must be set for storage")
 	}
 	if len(cfg.Key().Value) != secret.KeyLength {
-		return errors.New("a key must be set")
+		return nil, errors.New("a key must be set")
 	}
 
-	handler, err := secret.NewHandler(
+	return secret.NewHandler(
 		cfg.ProfileID,
 		cfg.StorageKey(),
 		cfg.Key(),
 		storage.NewFileSystem(cfg.StoragePath()),
-		secret.WithLoadCollection(),
+		handlerOptions(cfg, secret.WithLoadCollection())...,
 	)
+}
+
+// newAgentHandler creates a handler for the current profile of the
+// provided configuration with ciphers backed by the agent listening
+// on the provided socket. The agent must hold the keys of the profile.
+func newAgentHandler(cfg config.Configuration, socket string) (*secret.Handler, error) {
+	client := agent.NewClient(socket)
+	status, err := client.Status()
 	if err != nil {
+		return nil, err
+	}
+	if status.ProfileID != cfg.ProfileID {
+		return nil, fmt.Errorf("agent holds keys for profile %s, current profile is %s", status.ProfileID, cfg.ProfileID)
+	}
+
+	return secret.NewHandler(
+		cfg.ProfileID,
+		security.Key{},
+		security.Key{},
+		storage.NewFileSystem(cfg.StoragePath()),
+		handlerOptions(
+			cfg,
+			secret.WithLoadCollection(),
+			secret.WithCiphers(client.Cipher(agent.KeyStorage), client.Cipher(agent.KeySecret)),
+		)...,
+	)
+}
+
+// handlerOptions returns the provided options together with the
+// hooks of the current profile of the provided configuration, if any.
+func handlerOptions(cfg config.Configuration, options ...secret.HandlerOption) []secret.HandlerOption {
+	if hooks := cfg.Hooks(); len(hooks) > 0 {
+		options = append(options, secret.WithHooks(hook.NewRunner(cfg.ProfileID, hooks)))
+	}
+	return options
+}
+
+// warnPostHook prints a failed post-hook as a warning, since the
+// change has been saved. Other errors are returned.
+func warnPostHook(err error) error {
+	if !errors.Is(err, secret.ErrPostHook) {
 		return err
 	}
-	ctx.App.Metadata["handler"] = handler
+	output.Prompt(fmt.Sprintf("Warning: %v\n", err))
 	return nil
 }
 
@@ -86,13 +152,28 @@ func passwordPrompt(messages ...string) ([]byte, error) {
 		}
 		m = b.String()
 	}
-	output.Print(m)
+	output.Prompt(m)
 	p, err := term.ReadPassword(int(syscall.Stdin))
 	if err != nil {
 		return nil, err
 	}
 	if m[0] != '\r' {
-		output.PrintEmptyln()
+		output.Prompt("\n")
 	}
 	return p, nil
 }
+
+// confirmPrompt prompts for confirmation with the provided message.
+// Returns true if the answer is yes.
+func confirmPrompt(message string) (bool, error) {
+	output.Prompt(message + " [y/N]: ")
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,77 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/KarlGW/secman/credential"
+	"github.com/urfave/cli/v2"
+)
+
+// Formats of the credential-process command.
+const (
+	credentialFormatAWS        = "aws"
+	credentialFormatKubernetes = "kubernetes"
+)
+
+// CredentialProcess is a command for printing the credentials of a
+// secret in the format expected by AWS credential_process or by
+// Kubernetes exec credential plugins.
+func CredentialProcess() *cli.Command {
+	return &cli.Command{
+		Name:     "credential-process",
+		Category: "Credential helpers",
+		Usage:    "Print the credentials of a secret for AWS credential_process or as a Kubernetes ExecCredential",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "id",
+				Aliases: []string{"i"},
+				Usage:   "ID of secret with the credentials",
+			},
+			&cli.StringFlag{
+				Name:    "name",
+				Aliases: []string{"n"},
+				Usage:   "Name of secret with the credentials",
+			},
+			&cli.StringFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Usage:   "Format of the credentials: " + credentialFormatAWS + " or " + credentialFormatKubernetes,
+				Value:   credentialFormatAWS,
+			},
+			&cli.StringFlag{
+				Name:  "api-version",
+				Usage: "API version of the Kubernetes ExecCredential",
+				Value: credential.KubernetesV1,
+			},
+		},
+		Before: func(ctx *cli.Context) error {
+			return initHandler(ctx)
+		},
+		Action: func(ctx *cli.Context) error {
+			handler, err := handler(ctx)
+			if err != nil {
+				return err
+			}
+			s, err := getSecret(handler, ctx.String("id"), ctx.String("name"))
+			if err != nil {
+				return err
+			}
+
+			var v any
+			switch ctx.String("format") {
+			case credentialFormatAWS:
+				v, err = credential.NewAWS(s)
+			case credentialFormatKubernetes:
+				v, err = credential.NewExecCredential(s, ctx.String("api-version"))
+			default:
+				return fmt.Errorf("invalid format: %s", ctx.String("format"))
+			}
+			if err != nil {
+				return err
+			}
+			return json.NewEncoder(os.Stdout).Encode(v)
+		},
+	}
+}
@@ -0,0 +1,124 @@
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/KarlGW/secman/credential"
+	"github.com/KarlGW/secman/output"
+	"github.com/urfave/cli/v2"
+)
+
+// DockerCredentialHelper is the name of the executable of the
+// docker credential helper.
+const DockerCredentialHelper = "docker-credential-secman"
+
+// DockerCredential is a command implementing the docker credential
+// helper protocol.
+func DockerCredential() *cli.Command {
+	return &cli.Command{
+		Name:     "docker-credential",
+		Category: "Credential helpers",
+		Usage:    "Docker credential helper. Link or install as " + DockerCredentialHelper + " and set credsStore to secman",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "store",
+				Usage: "Store the credentials on stdin",
+				Action: func(ctx *cli.Context) error {
+					return dockerCredential(ctx, func(handler credential.Handler) error {
+						var d credential.Docker
+						if err := json.NewDecoder(os.Stdin).Decode(&d); err != nil {
+							return err
+						}
+						return credential.DockerStore(handler, d)
+					})
+				},
+			},
+			{
+				Name:  "get",
+				Usage: "Get the credentials for the server URL on stdin",
+				Action: func(ctx *cli.Context) error {
+					return dockerCredential(ctx, func(handler credential.Handler) error {
+						serverURL, err := readServerURL(os.Stdin)
+						if err != nil {
+							return err
+						}
+						d, err := credential.DockerGet(handler, serverURL)
+						if err != nil {
+							return err
+						}
+						return json.NewEncoder(os.Stdout).Encode(d)
+					})
+				},
+			},
+			{
+				Name:  "erase",
+				Usage: "Erase the credentials for the server URL on stdin",
+				Action: func(ctx *cli.Context) error {
+					return dockerCredential(ctx, func(handler credential.Handler) error {
+						serverURL, err := readServerURL(os.Stdin)
+						if err != nil {
+							return err
+						}
+						return credential.DockerErase(handler, serverURL)
+					})
+				},
+			},
+			{
+				Name:  "list",
+				Usage: "List the server URLs and usernames of the stored credentials",
+				Action: func(ctx *cli.Context) error {
+					return dockerCredential(ctx, func(handler credential.Handler) error {
+						list, err := credential.DockerList(handler)
+						if err != nil {
+							return err
+						}
+						return json.NewEncoder(os.Stdout).Encode(list)
+					})
+				},
+			},
+		},
+		Before: func(ctx *cli.Context) error {
+			if err := initHandler(ctx); err != nil {
+				return dockerCredentialError(err)
+			}
+			return nil
+		},
+	}
+}
+
+// dockerCredential calls fn with the handler. Errors are written
+// to stdout as expected by docker.
+func dockerCredential(ctx *cli.Context, fn func(handler credential.Handler) error) error {
+	handler, err := handler(ctx)
+	if err != nil {
+		return dockerCredentialError(err)
+	}
+	if err := warnPostHook(fn(handler)); err != nil {
+		return dockerCredentialError(err)
+	}
+	return nil
+}
+
+// dockerCredentialError writes the error to stdout and returns an
+// exit code.
+func dockerCredentialError(err error) error {
+	output.Println(err.Error())
+	return cli.Exit("", 1)
+}
+
+// readServerURL reads the server URL from r.
+func readServerURL(r io.Reader) (string, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	serverURL := strings.TrimSpace(string(b))
+	if len(serverURL) == 0 {
+		return "", errors.New("a server URL must be provided")
+	}
+	return serverURL, nil
+}
@@ -0,0 +1,94 @@
+package command
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/KarlGW/secman/env"
+	"github.com/KarlGW/secman/secret"
+	"github.com/urfave/cli/v2"
+)
+
+// Env is a command for printing secrets as environment variables.
+func Env() *cli.Command {
+	formats := make([]string, len(env.Formats))
+	for i, f := range env.Formats {
+		formats[i] = string(f)
+	}
+
+	return &cli.Command{
+		Name:     "env",
+		Category: "Secrets",
+		Usage:    "Print decrypted secrets as environment variables",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "label",
+				Aliases: []string{"l"},
+				Usage:   "Label of the secrets to print",
+			},
+			&cli.StringSliceFlag{
+				Name:    "name",
+				Aliases: []string{"n"},
+				Usage:   "Name of a secret to print. Can be set several times",
+			},
+			&cli.StringFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Usage:   "Output format (" + strings.Join(formats, ", ") + ")",
+				Value:   string(env.FormatPOSIX),
+			},
+		},
+		Before: func(ctx *cli.Context) error {
+			return initHandler(ctx)
+		},
+		Action: func(ctx *cli.Context) error {
+			if !ctx.IsSet("label") && !ctx.IsSet("name") {
+				return errors.New("a label or name must be provided")
+			}
+			format, err := env.ParseFormat(ctx.String("format"))
+			if err != nil {
+				return err
+			}
+			handler, err := handler(ctx)
+			if err != nil {
+				return err
+			}
+
+			var secrets secret.Secrets
+			if ctx.IsSet("label") {
+				secrets, err = handler.GetSecretsByLabel(ctx.String("label"))
+				if err != nil {
+					return err
+				}
+			}
+			for _, name := range ctx.StringSlice("name") {
+				s, err := handler.GetSecretByName(name)
+				if err != nil {
+					return err
+				}
+				// A secret can be selected both by label and by name.
+				if slices.ContainsFunc(secrets, func(selected secret.Secret) bool {
+					return selected.ID == s.ID
+				}) {
+					continue
+				}
+				secrets = append(secrets, s)
+			}
+			if err := env.CheckNames(secrets); err != nil {
+				return err
+			}
+
+			vars := make([]env.Var, 0, len(secrets))
+			for _, s := range secrets {
+				decrypted, err := s.Decrypt()
+				if err != nil {
+					return err
+				}
+				vars = append(vars, env.Var{Name: env.Name(s), Value: string(decrypted)})
+			}
+			return env.Write(os.Stdout, format, vars)
+		},
+	}
+}
@@ -0,0 +1,196 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/KarlGW/secman/exporter"
+	"github.com/KarlGW/secman/output"
+	"github.com/KarlGW/secman/secret"
+	"github.com/urfave/cli/v2"
+)
+
+// SecretExport is a command for exporting decrypted secrets.
+func SecretExport() *cli.Command {
+	formats := make([]string, len(exporter.Formats))
+	for i, f := range exporter.Formats {
+		formats[i] = string(f)
+	}
+
+	return &cli.Command{
+		Name:     "export-secrets",
+		Category: "Secrets",
+		Usage:    "Export the decrypted secrets in an encrypted envelope",
+		Description: `The secrets are written in the format, sealed in an envelope encrypted with
+a password, or with age if recipients are provided. Use decrypt-export to open
+a password encrypted envelope.`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Usage:   "Format of the export (" + strings.Join(formats, ", ") + ")",
+				Value:   string(exporter.FormatJSON),
+			},
+			&cli.StringFlag{
+				Name:    "query",
+				Aliases: []string{"q"},
+				Usage:   "Export only the secrets matching the search query",
+			},
+			&cli.StringFlag{
+				Name:  "file",
+				Usage: "File to write the export to. Written to stdout if not set",
+			},
+			&cli.StringSliceFlag{
+				Name:    "recipient",
+				Aliases: []string{"r"},
+				Usage:   "Encrypt to the age recipient (requires age). Can be set several times",
+			},
+			&cli.BoolFlag{
+				Name:  "plaintext",
+				Usage: "Write the export without encryption",
+			},
+		},
+		Before: func(ctx *cli.Context) error {
+			return initHandler(ctx)
+		},
+		Action: func(ctx *cli.Context) error {
+			return exportSecrets(ctx)
+		},
+	}
+}
+
+// SecretDecryptExport is a command for decrypting a password
+// encrypted export.
+func SecretDecryptExport() *cli.Command {
+	return &cli.Command{
+		Name:      "decrypt-export",
+		Category:  "Secrets",
+		Usage:     "Decrypt a password encrypted export of secrets",
+		ArgsUsage: "<path>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "file",
+				Usage: "File to write the decrypted export to. Written to stdout if not set",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if !ctx.Args().Present() {
+				return errors.New("a path must be provided")
+			}
+			b, err := os.ReadFile(ctx.Args().First())
+			if err != nil {
+				return err
+			}
+			password, err := passwordPrompt("Enter password for export: ")
+			if err != nil {
+				return err
+			}
+			decrypted, _, err := exporter.Open(b, password)
+			if err != nil {
+				return err
+			}
+			return writeExport(ctx.String("file"), decrypted)
+		},
+	}
+}
+
+// exportSecrets exports the secrets after the password of the
+// profile has been verified.
+func exportSecrets(ctx *cli.Context) error {
+	format, err := exporter.ParseFormat(ctx.String("format"))
+	if err != nil {
+		return err
+	}
+	handler, err := handler(ctx)
+	if err != nil {
+		return err
+	}
+	cfg, err := configuration(ctx)
+	if err != nil {
+		return err
+	}
+
+	password, err := passwordPrompt()
+	if err != nil {
+		return err
+	}
+	if err := verifyPassword(&cfg, password); err != nil {
+		return err
+	}
+
+	var secrets secret.Secrets
+	if ctx.IsSet("query") {
+		secrets, err = handler.SearchSecrets(ctx.String("query"))
+	} else {
+		secrets, err = handler.ListSecrets()
+		sort.SliceStable(secrets, func(i, j int) bool {
+			return secrets[i].Name < secrets[j].Name
+		})
+	}
+	if err != nil {
+		return err
+	}
+	records, err := exporter.NewRecords(secrets)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := exporter.Write(&buf, format, records); err != nil {
+		return err
+	}
+
+	var b []byte
+	switch {
+	case ctx.Bool("plaintext"):
+		b = buf.Bytes()
+	case ctx.IsSet("recipient"):
+		b, err = exporter.SealAge(buf.Bytes(), ctx.StringSlice("recipient"))
+	default:
+		b, err = sealExport(buf.Bytes(), format)
+	}
+	if err != nil {
+		return err
+	}
+	if err := writeExport(ctx.String("file"), b); err != nil {
+		return err
+	}
+	if file := ctx.String("file"); len(file) > 0 {
+		output.Println(fmt.Sprintf("Exported %d secrets to %s", len(records), file))
+	}
+	return nil
+}
+
+// sealExport prompts for a password and seals the export
+// in a password encrypted envelope.
+func sealExport(b []byte, format exporter.Format) ([]byte, error) {
+	password, err := passwordPrompt("Set password for export: ")
+	if err != nil {
+		return nil, err
+	}
+	if len(password) == 0 {
+		return nil, errors.New("a password must be provided")
+	}
+	confirmed, err := passwordPrompt("Confirm password for export: ")
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(password, confirmed) {
+		return nil, errors.New("passwords do not match")
+	}
+	return exporter.Seal(b, password, format)
+}
+
+// writeExport writes the export to the file, or to stdout if
+// no file is provided.
+func writeExport(file string, b []byte) error {
+	if len(file) == 0 {
+		_, err := io.Copy(os.Stdout, bytes.NewReader(b))
+		return err
+	}
+	return os.WriteFile(file, b, 0600)
+}
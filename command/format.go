@@ -0,0 +1,209 @@
+package command
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KarlGW/secman/audit"
+	"github.com/KarlGW/secman/config"
+	"github.com/KarlGW/secman/internal/security"
+	"github.com/KarlGW/secman/output"
+	"github.com/KarlGW/secman/secret"
+	"github.com/urfave/cli/v2"
+)
+
+// outputFlag returns the flag for setting the output format on a command.
+// It takes precedence over the global flag.
+func outputFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:    "output",
+		Aliases: []string{"o"},
+		Usage:   "Output format: table, wide, json, yaml or template=<go-template>",
+	}
+}
+
+// outputFormat returns the output format set on the command or
+// globally. If none is set the provided default is returned.
+func outputFormat(ctx *cli.Context, def output.Kind) (output.Format, error) {
+	for _, c := range ctx.Lineage() {
+		if f := c.String("output"); len(f) > 0 {
+			return output.ParseFormat(f)
+		}
+	}
+	return output.Format{Kind: def}, nil
+}
+
+// writeOutput writes the value to stdout in the output format of the
+// command.
+func writeOutput(ctx *cli.Context, def output.Kind, v any) error {
+	format, err := outputFormat(ctx, def)
+	if err != nil {
+		return err
+	}
+	return output.Write(os.Stdout, format, v)
+}
+
+// secretsTable formats secrets as a table.
+type secretsTable secret.Secrets
+
+// Table returns the header and rows of the secrets.
+func (t secretsTable) Table(wide bool) ([]string, [][]string) {
+	header := []string{"NAME", "TYPE", "LABELS", "UPDATED"}
+	if wide {
+		header = []string{"ID", "NAME", "DISPLAY NAME", "TYPE", "LABELS", "TAGS", "CREATED", "UPDATED"}
+	}
+	rows := make([][]string, 0, len(t))
+	for _, s := range t {
+		if wide {
+			rows = append(rows, []string{s.ID, s.Name, s.DisplayName, s.Type.String(), strings.Join(s.Labels, ","), formatTags(s.Tags), formatTime(s.Created), formatTime(s.Updated)})
+			continue
+		}
+		rows = append(rows, []string{s.Name, s.Type.String(), strings.Join(s.Labels, ","), formatTime(s.Updated)})
+	}
+	return header, rows
+}
+
+// secretTable formats a secret as a table.
+type secretTable secret.Secret
+
+// Table returns the header and row of the secret.
+func (t secretTable) Table(wide bool) ([]string, [][]string) {
+	return secretsTable{secret.Secret(t)}.Table(wide)
+}
+
+// profileView is the presentation of a profile.
+type profileView struct {
+	Current     bool             `json:"current"`
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	DisplayName string           `json:"displayName,omitempty"`
+	Description string           `json:"description,omitempty"`
+	KDF         *security.Params `json:"kdf,omitempty"`
+}
+
+// profilesTable formats profiles as a table.
+type profilesTable []profileView
+
+// Table returns the header and rows of the profiles.
+func (t profilesTable) Table(wide bool) ([]string, [][]string) {
+	header := []string{"", "NAME", "ID", "DISPLAY NAME"}
+	if wide {
+		header = append(header, "DESCRIPTION", "KDF")
+	}
+	rows := make([][]string, 0, len(t))
+	for _, p := range t {
+		var current string
+		if p.Current {
+			current = "*"
+		}
+		row := []string{current, p.Name, p.ID, p.DisplayName}
+		if wide {
+			var kdf string
+			if p.KDF != nil {
+				kdf = p.KDF.String()
+			}
+			row = append(row, p.Description, kdf)
+		}
+		rows = append(rows, row)
+	}
+	return header, rows
+}
+
+// profileTable formats a profile as a table.
+type profileTable profileView
+
+// Table returns the header and row of the profile.
+func (t profileTable) Table(wide bool) ([]string, [][]string) {
+	return profilesTable{profileView(t)}.Table(wide)
+}
+
+// newProfileView creates a profileView from the fields of a profile.
+func newProfileView(id, name, displayName, description string, kdf security.Params, current bool) profileView {
+	p := profileView{
+		Current:     current,
+		ID:          id,
+		Name:        name,
+		DisplayName: displayName,
+		Description: description,
+	}
+	if !kdf.IsZero() {
+		p.KDF = &kdf
+	}
+	return p
+}
+
+// formatTags formats tags as key=value pairs sorted by key.
+func formatTags(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for k, v := range tags {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// formatTime formats the time for tables. The zero time is formatted
+// as an empty string.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format(time.DateTime)
+}
+
+// findingsTable formats findings of an audit as a table.
+type findingsTable []audit.Finding
+
+// Table returns the header and rows of the findings.
+func (t findingsTable) Table(wide bool) ([]string, [][]string) {
+	header := []string{"SEVERITY", "NAME", "CHECK", "MESSAGE"}
+	if wide {
+		header = []string{"SEVERITY", "ID", "NAME", "CHECK", "MESSAGE"}
+	}
+	rows := make([][]string, 0, len(t))
+	for _, f := range t {
+		if wide {
+			rows = append(rows, []string{f.Severity.String(), f.ID, f.Name, f.Check, f.Message})
+			continue
+		}
+		rows = append(rows, []string{f.Severity.String(), f.Name, f.Check, f.Message})
+	}
+	return header, rows
+}
+
+// apiTokensTable formats tokens of clients of the API server as a table.
+type apiTokensTable []config.APIToken
+
+// Table returns the header and rows of the tokens.
+func (t apiTokensTable) Table(wide bool) ([]string, [][]string) {
+	header := []string{"NAME", "ACCESS", "CREATED"}
+	rows := make([][]string, 0, len(t))
+	for _, token := range t {
+		access := "read-write"
+		if token.ReadOnly {
+			access = "read-only"
+		}
+		rows = append(rows, []string{token.Name, access, formatTime(token.Created)})
+	}
+	return header, rows
+}
+
+// hooksTable formats hooks of a profile as a table.
+type hooksTable []config.Hook
+
+// Table returns the header and rows of the hooks.
+func (t hooksTable) Table(wide bool) ([]string, [][]string) {
+	header := []string{"NAME", "STAGE", "OPERATIONS", "COMMAND"}
+	rows := make([][]string, 0, len(t))
+	for _, hook := range t {
+		operations := "all"
+		if len(hook.Operations) > 0 {
+			operations = strings.Join(hook.Operations, ",")
+		}
+		command := strings.Join(append([]string{hook.Command}, hook.Args...), " ")
+		rows = append(rows, []string{hook.Name, hook.Stage, operations, command})
+	}
+	return header, rows
+}
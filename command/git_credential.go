@@ -0,0 +1,68 @@
+package command
+
+import (
+	"os"
+
+	"github.com/KarlGW/secman/credential"
+	"github.com/urfave/cli/v2"
+)
+
+// GitCredentialHelper is the name of the executable of the
+// git credential helper.
+const GitCredentialHelper = "git-credential-secman"
+
+// GitCredential is a command implementing the git credential
+// helper protocol.
+func GitCredential() *cli.Command {
+	return &cli.Command{
+		Name:     "git-credential",
+		Category: "Credential helpers",
+		Usage:    "Git credential helper. Link or install as " + GitCredentialHelper + " and set credential.helper to secman",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "get",
+				Usage: "Get the credential matching the description on stdin",
+				Action: func(ctx *cli.Context) error {
+					return gitCredential(ctx, func(handler credential.Handler, g credential.Git) error {
+						g, ok, err := credential.GitGet(handler, g)
+						if err != nil || !ok {
+							return err
+						}
+						return g.Write(os.Stdout)
+					})
+				},
+			},
+			{
+				Name:  "store",
+				Usage: "Store the credential on stdin",
+				Action: func(ctx *cli.Context) error {
+					return gitCredential(ctx, credential.GitStore)
+				},
+			},
+			{
+				Name:  "erase",
+				Usage: "Erase the credentials matching the description on stdin",
+				Action: func(ctx *cli.Context) error {
+					return gitCredential(ctx, credential.GitErase)
+				},
+			},
+		},
+		Before: func(ctx *cli.Context) error {
+			return initHandler(ctx)
+		},
+	}
+}
+
+// gitCredential reads the credential description from stdin and
+// calls fn with it.
+func gitCredential(ctx *cli.Context, fn func(handler credential.Handler, g credential.Git) error) error {
+	handler, err := handler(ctx)
+	if err != nil {
+		return err
+	}
+	g, err := credential.ReadGit(os.Stdin)
+	if err != nil {
+		return err
+	}
+	return warnPostHook(fn(handler, g))
+}
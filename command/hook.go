@@ -0,0 +1,139 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/KarlGW/secman/config"
+	"github.com/KarlGW/secman/hook"
+	"github.com/KarlGW/secman/output"
+	"github.com/urfave/cli/v2"
+)
+
+// ProfileHook is a subcommand containing subcommands for handling
+// hooks of the current profile.
+func ProfileHook() *cli.Command {
+	return &cli.Command{
+		Name:  "hook",
+		Usage: "Manage hooks run before and after secrets are changed",
+		Subcommands: []*cli.Command{
+			ProfileHookAdd(),
+			ProfileHookList(),
+			ProfileHookDelete(),
+		},
+	}
+}
+
+// ProfileHookAdd is a subcommand for adding a hook.
+func ProfileHookAdd() *cli.Command {
+	return &cli.Command{
+		Name:      "add",
+		Usage:     "Add a hook. The hook receives the event as JSON on stdin",
+		ArgsUsage: "-- <command> [arguments]",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "name",
+				Aliases:  []string{"n"},
+				Usage:    "Name of the hook",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "stage",
+				Aliases:  []string{"s"},
+				Usage:    "Stage of the hook: pre or post. A failing pre-hook aborts the operation",
+				Required: true,
+			},
+			&cli.StringSliceFlag{
+				Name:  "operation",
+				Usage: fmt.Sprintf("Operation to run the hook for: %s. Runs for all operations if not set", joinOperations()),
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if !ctx.Args().Present() {
+				return errors.New("a command must be provided")
+			}
+			cfg, err := configuration(ctx)
+			if err != nil {
+				return err
+			}
+			h := config.Hook{
+				Name:       ctx.String("name"),
+				Stage:      ctx.String("stage"),
+				Operations: ctx.StringSlice("operation"),
+				Command:    ctx.Args().First(),
+				Args:       ctx.Args().Tail(),
+			}
+			if err := hook.ValidateHook(h); err != nil {
+				return err
+			}
+			hooks := cfg.Hooks()
+			if slices.ContainsFunc(hooks, func(hk config.Hook) bool {
+				return hk.Name == h.Name
+			}) {
+				return fmt.Errorf("a hook with name %s already exists", h.Name)
+			}
+			return cfg.SetHooks(append(hooks, h))
+		},
+	}
+}
+
+// ProfileHookList is a subcommand for listing hooks.
+func ProfileHookList() *cli.Command {
+	return &cli.Command{
+		Name:  "list",
+		Usage: "List hooks",
+		Flags: []cli.Flag{
+			outputFlag(),
+		},
+		Action: func(ctx *cli.Context) error {
+			cfg, err := configuration(ctx)
+			if err != nil {
+				return err
+			}
+			return writeOutput(ctx, output.KindTable, hooksTable(cfg.Hooks()))
+		},
+	}
+}
+
+// ProfileHookDelete is a subcommand for deleting a hook.
+func ProfileHookDelete() *cli.Command {
+	return &cli.Command{
+		Name:  "delete",
+		Usage: "Delete a hook",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "name",
+				Aliases:  []string{"n"},
+				Usage:    "Name of the hook",
+				Required: true,
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			cfg, err := configuration(ctx)
+			if err != nil {
+				return err
+			}
+			hooks := cfg.Hooks()
+			name := ctx.String("name")
+			i := slices.IndexFunc(hooks, func(h config.Hook) bool {
+				return h.Name == name
+			})
+			if i == -1 {
+				return fmt.Errorf("a hook with name %s does not exist", name)
+			}
+			return cfg.SetHooks(slices.Delete(slices.Clone(hooks), i, i+1))
+		},
+	}
+}
+
+// joinOperations returns the operations hooks can be run for
+// separated by commas.
+func joinOperations() string {
+	operations := make([]string, len(hook.Operations))
+	for i, op := range hook.Operations {
+		operations[i] = string(op)
+	}
+	return strings.Join(operations, ", ")
+}
@@ -0,0 +1,83 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/KarlGW/secman/importer"
+	"github.com/KarlGW/secman/output"
+	"github.com/urfave/cli/v2"
+)
+
+// SecretImport is a command for importing secrets from the exports
+// of other password managers.
+func SecretImport() *cli.Command {
+	formats := make([]string, len(importer.Formats))
+	for i, f := range importer.Formats {
+		formats[i] = string(f)
+	}
+
+	return &cli.Command{
+		Name:      "import",
+		Category:  "Secrets",
+		Usage:     "Import secrets from the export of another password manager",
+		ArgsUsage: "<path>",
+		Description: `Folders are imported as labels, custom fields as tags and entries with a URL
+or username as credentials. Hidden custom fields are stored in the encrypted
+value. The path of a pass-store is the directory of the store.`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "format",
+				Aliases:  []string{"f"},
+				Usage:    "Format of the export (" + strings.Join(formats, ", ") + ")",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "on-duplicate",
+				Usage: "Policy for entries with the same name as an existing secret (skip or rename)",
+				Value: string(importer.PolicySkip),
+			},
+		},
+		Before: func(ctx *cli.Context) error {
+			return initHandler(ctx)
+		},
+		Action: func(ctx *cli.Context) error {
+			if !ctx.Args().Present() {
+				return errors.New("a path must be provided")
+			}
+			format, err := importer.ParseFormat(ctx.String("format"))
+			if err != nil {
+				return err
+			}
+			policy, err := importer.ParsePolicy(ctx.String("on-duplicate"))
+			if err != nil {
+				return err
+			}
+			handler, err := handler(ctx)
+			if err != nil {
+				return err
+			}
+
+			entries, err := importer.Read(format, ctx.Args().First())
+			if err != nil {
+				return err
+			}
+			result, err := importer.Import(handler, entries, importer.WithPolicy(policy))
+			printImportResult(result)
+			return warnPostHook(err)
+		},
+	}
+}
+
+// printImportResult prints the renamed and skipped entries
+// and a summary of the import.
+func printImportResult(result importer.Result) {
+	for _, r := range result.Renamed {
+		output.Println(fmt.Sprintf("renamed: %s -> %s", r.From, r.To))
+	}
+	for _, s := range result.Skipped {
+		output.Println(fmt.Sprintf("skipped: %s (%s)", s.Name, s.Reason))
+	}
+	output.Println(fmt.Sprintf("Imported %d secrets, renamed %d, skipped %d", len(result.Imported), len(result.Renamed), len(result.Skipped)))
+}
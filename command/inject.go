@@ -0,0 +1,90 @@
+package command
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/KarlGW/secman/inject"
+	"github.com/urfave/cli/v2"
+)
+
+// Inject is a command for rendering templates with secrets.
+func Inject() *cli.Command {
+	return &cli.Command{
+		Name:     "inject",
+		Category: "Secrets",
+		Usage:    "Render a template with secrets",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "input",
+				Aliases: []string{"i"},
+				Usage:   "Template file to render. If omitted, the template is read from stdin",
+			},
+			&cli.StringFlag{
+				Name:    "file",
+				Aliases: []string{"f"},
+				Usage:   "File to write the rendered template to (with permissions 0600). If omitted, it is written to stdout",
+			},
+		},
+		Before: func(ctx *cli.Context) error {
+			return initHandler(ctx)
+		},
+		Action: func(ctx *cli.Context) error {
+			handler, err := handler(ctx)
+			if err != nil {
+				return err
+			}
+
+			var text []byte
+			name := "stdin"
+			if ctx.IsSet("input") {
+				name = ctx.String("input")
+				text, err = os.ReadFile(name)
+			} else {
+				text, err = io.ReadAll(os.Stdin)
+			}
+			if err != nil {
+				return err
+			}
+
+			// Render the template completely before writing, to not leave
+			// a partially rendered file.
+			var buf bytes.Buffer
+			if err := inject.Render(&buf, filepath.Base(name), string(text), handler); err != nil {
+				return err
+			}
+			if !ctx.IsSet("file") {
+				_, err := os.Stdout.Write(buf.Bytes())
+				return err
+			}
+			return writeFile(ctx.String("file"), buf.Bytes())
+		},
+	}
+}
+
+// writeFile writes data to a file with permissions 0600. The data is
+// written to a temporary file in the same directory that then replaces
+// the file, so that an existing file never has other permissions or
+// partial contents.
+func writeFile(name string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if err := file.Chmod(0600); err != nil {
+		file.Close()
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), name)
+}
@@ -2,9 +2,16 @@ package command
 
 import (
 	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
 
 	"github.com/KarlGW/secman/config"
 	"github.com/KarlGW/secman/internal/security"
+	"github.com/KarlGW/secman/output"
+	"github.com/KarlGW/secman/secret"
+	"github.com/KarlGW/secman/storage"
 	"github.com/urfave/cli/v2"
 )
 
@@ -17,10 +24,16 @@ func Profile() *cli.Command {
 		Category: "Subcommands",
 		Subcommands: []*cli.Command{
 			ProfileNew(),
+			ProfileList(),
+			ProfileShow(),
 			ProfileSet(),
+			ProfileRename(),
+			ProfileDelete(),
 			ProfileUpdate(),
 			ProfileExport(),
 			ProfileImport(),
+			ProfileCalibrate(),
+			ProfileHook(),
 		},
 		Before: func(ctx *cli.Context) error {
 			return configure(ctx)
@@ -53,28 +66,203 @@ func ProfileNew() *cli.Command {
 // ProfileSet is a subcommand for setting current profile.
 func ProfileSet() *cli.Command {
 	return &cli.Command{
-		Name:  "set-current",
-		Usage: "Set current profile",
+		Name:      "set-current",
+		Usage:     "Set current profile",
+		ArgsUsage: "[id or name]",
+		Flags:     profileFlags(),
+		Action: func(ctx *cli.Context) error {
+			cfg, err := configuration(ctx)
+			if err != nil {
+				return err
+			}
+			id, err := profileIdentifier(ctx)
+			if err != nil {
+				return err
+			}
+			p, err := cfg.FindProfile(id)
+			if err != nil {
+				return err
+			}
+			return cfg.SetProfile(p.ID)
+		},
+	}
+}
+
+// ProfileList is a subcommand for listing profiles.
+func ProfileList() *cli.Command {
+	return &cli.Command{
+		Name:  "list",
+		Usage: "List profiles. The current profile is marked with *",
 		Flags: []cli.Flag{
+			outputFlag(),
+		},
+		Action: func(ctx *cli.Context) error {
+			cfg, err := configuration(ctx)
+			if err != nil {
+				return err
+			}
+			var profiles profilesTable
+			for _, p := range cfg.Profiles() {
+				profiles = append(profiles, newProfileView(p.ID, p.Name, p.DisplayName, p.Description, p.KDF, p.ID == cfg.ProfileID))
+			}
+			return writeOutput(ctx, output.KindTable, profiles)
+		},
+	}
+}
+
+// ProfileShow is a subcommand for showing a profile.
+func ProfileShow() *cli.Command {
+	return &cli.Command{
+		Name:      "show",
+		Usage:     "Show profile. If no profile is provided, the current profile is shown",
+		ArgsUsage: "[id or name]",
+		Flags:     append(profileFlags(), outputFlag()),
+		Action: func(ctx *cli.Context) error {
+			cfg, err := configuration(ctx)
+			if err != nil {
+				return err
+			}
+			id, err := profileIdentifierOrCurrent(ctx, cfg)
+			if err != nil {
+				return err
+			}
+			p, err := cfg.FindProfile(id)
+			if err != nil {
+				return err
+			}
+			return writeOutput(ctx, output.KindYAML, profileTable(newProfileView(p.ID, p.Name, p.DisplayName, p.Description, p.KDF, p.ID == cfg.ProfileID)))
+		},
+	}
+}
+
+// ProfileRename is a subcommand for renaming a profile and setting its
+// display name and description.
+func ProfileRename() *cli.Command {
+	return &cli.Command{
+		Name:      "rename",
+		Usage:     "Rename profile and set display name and description. If no profile is provided, the current profile is used",
+		ArgsUsage: "[id or name]",
+		Flags: append(profileFlags(),
 			&cli.StringFlag{
-				Name:    "id",
-				Usage:   "id of profile",
-				Aliases: []string{"i"},
+				Name:  "new-name",
+				Usage: "New name of the profile",
 			},
-		},
+			&cli.StringFlag{
+				Name:    "display-name",
+				Aliases: []string{"d"},
+				Usage:   "Display name of the profile",
+			},
+			&cli.StringFlag{
+				Name:  "description",
+				Usage: "Description of the profile",
+			},
+		),
 		Action: func(ctx *cli.Context) error {
-			if !ctx.IsSet("id") {
-				return errors.New("an ID must be provided")
+			if !ctx.IsSet("new-name") && !ctx.IsSet("display-name") && !ctx.IsSet("description") {
+				return errors.New("a new name, display name or description must be provided")
+			}
+			cfg, err := configuration(ctx)
+			if err != nil {
+				return err
+			}
+			id, err := profileIdentifierOrCurrent(ctx, cfg)
+			if err != nil {
+				return err
+			}
+			p, err := cfg.FindProfile(id)
+			if err != nil {
+				return err
 			}
+			_, err = cfg.UpdateProfile(p.ID, ctx.String("new-name"), ctx.String("display-name"), ctx.String("description"))
+			return err
+		},
+	}
+}
+
+// ProfileDelete is a subcommand for deleting a profile.
+func ProfileDelete() *cli.Command {
+	return &cli.Command{
+		Name:      "delete",
+		Usage:     "Delete profile together with its keys and collection",
+		Aliases:   []string{"remove"},
+		ArgsUsage: "[id or name]",
+		Flags: append(profileFlags(),
+			&cli.BoolFlag{
+				Name:    "yes",
+				Aliases: []string{"y"},
+				Usage:   "Delete without confirmation",
+			},
+		),
+		Action: func(ctx *cli.Context) error {
 			cfg, err := configuration(ctx)
 			if err != nil {
 				return err
 			}
-			return cfg.SetProfile(ctx.String("id"))
+			id, err := profileIdentifier(ctx)
+			if err != nil {
+				return err
+			}
+			p, err := cfg.FindProfile(id)
+			if err != nil {
+				return err
+			}
+			if !ctx.Bool("yes") {
+				ok, err := confirmPrompt(fmt.Sprintf("Delete profile %s (%s) together with its keys and collection? This cannot be undone.", p.Name, p.ID))
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return nil
+				}
+			}
+			return cfg.DeleteProfile(p.ID)
 		},
 	}
 }
 
+// profileFlags returns the flags for selecting a profile.
+func profileFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "id",
+			Usage:   "id of profile",
+			Aliases: []string{"i"},
+		},
+		&cli.StringFlag{
+			Name:    "name",
+			Usage:   "name of profile",
+			Aliases: []string{"n"},
+		},
+	}
+}
+
+// profileIdentifier returns the ID or name of a profile from the flags
+// id or name, or from the first argument.
+func profileIdentifier(ctx *cli.Context) (string, error) {
+	switch {
+	case ctx.IsSet("id"):
+		return ctx.String("id"), nil
+	case ctx.IsSet("name"):
+		return ctx.String("name"), nil
+	case ctx.Args().Present():
+		return ctx.Args().First(), nil
+	}
+	return "", errors.New("an ID or name must be provided")
+}
+
+// profileIdentifierOrCurrent returns the ID or name of a profile like
+// profileIdentifier. If none is provided, the ID of the current profile
+// is returned.
+func profileIdentifierOrCurrent(ctx *cli.Context, cfg config.Configuration) (string, error) {
+	if !ctx.IsSet("id") && !ctx.IsSet("name") && !ctx.Args().Present() {
+		if len(cfg.ProfileID) == 0 {
+			return "", errors.New("no current profile set")
+		}
+		return cfg.ProfileID, nil
+	}
+	return profileIdentifier(ctx)
+}
+
 // ProfileUpdate is a subcommand for updating profiles.
 func ProfileUpdate() *cli.Command {
 	return &cli.Command{
@@ -87,6 +275,10 @@ func ProfileUpdate() *cli.Command {
 				Usage:   "Set passwprd for secret encryption key generation",
 				Aliases: []string{"p"},
 			},
+			&cli.DurationFlag{
+				Name:  "clipboard-timeout",
+				Usage: "Clear the clipboard after the duration when a secret value has been copied to it. 0 disables clearing",
+			},
 		},
 		Action: func(ctx *cli.Context) error {
 			if ctx.IsSet("password") {
@@ -94,6 +286,15 @@ func ProfileUpdate() *cli.Command {
 					return err
 				}
 			}
+			if ctx.IsSet("clipboard-timeout") {
+				cfg, err := configuration(ctx)
+				if err != nil {
+					return err
+				}
+				if err := cfg.SetClipboardTimeout(ctx.Duration("clipboard-timeout")); err != nil {
+					return err
+				}
+			}
 			return nil
 		},
 	}
@@ -111,6 +312,11 @@ func ProfileExport() *cli.Command {
 				Usage:    "File to export to.",
 				Required: true,
 			},
+			&cli.BoolFlag{
+				Name:    "collection",
+				Aliases: []string{"c"},
+				Usage:   "Include the collection of the profile in the export",
+			},
 		},
 		Action: func(ctx *cli.Context) error {
 			return exportProfile(ctx)
@@ -135,6 +341,11 @@ func ProfileImport() *cli.Command {
 				Aliases: []string{"o"},
 				Usage:   "Overwrite if profile with same ID already exist",
 			},
+			&cli.BoolFlag{
+				Name:    "merge",
+				Aliases: []string{"m"},
+				Usage:   "Merge an imported collection into an existing collection of the profile",
+			},
 		},
 		Action: func(ctx *cli.Context) error {
 			return importProfile(ctx)
@@ -142,30 +353,94 @@ func ProfileImport() *cli.Command {
 	}
 }
 
-// setPassword takes the provided password and creates a new key from it
-// and sets it to the provided configuration and updates all
-// the secrets contained in the handler.
-func setPassword(ctx *cli.Context) error {
-	password, err := passwordPrompt()
+// ProfileCalibrate is a subcommand for calibrating the key derivation
+// parameters of the profile.
+func ProfileCalibrate() *cli.Command {
+	return &cli.Command{
+		Name:  "calibrate",
+		Usage: "Calibrate key derivation parameters for a target unlock time",
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:    "target",
+				Aliases: []string{"t"},
+				Usage:   "Target time for deriving the key from the password",
+				Value:   time.Second,
+			},
+			&cli.UintFlag{
+				Name:  "threads",
+				Usage: "Number of threads to use",
+				Value: uint(security.DefaultParams.Threads),
+			},
+			&cli.UintFlag{
+				Name:  "max-memory",
+				Usage: "Maximum memory to use in MiB",
+				Value: 1024,
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			return calibrate(ctx)
+		},
+	}
+}
+
+// calibrate calibrates key derivation parameters and sets them to the
+// profile. If a key is set on the profile, the password is prompted
+// for and the key is derived again with the new parameters.
+func calibrate(ctx *cli.Context) error {
+	cfg, err := configuration(ctx)
 	if err != nil {
 		return err
 	}
+	threads, maxMemory := ctx.Uint("threads"), ctx.Uint("max-memory")
+	if threads < uint(security.MinParams.Threads) || threads > uint(security.MaxParams.Threads) {
+		return fmt.Errorf("threads must be between %d and %d", security.MinParams.Threads, security.MaxParams.Threads)
+	}
+	if maxMemory < uint(security.MinParams.Memory/1024) || maxMemory > uint(security.MaxParams.Memory/1024) {
+		return fmt.Errorf("max memory must be between %d and %d MiB", security.MinParams.Memory/1024, security.MaxParams.Memory/1024)
+	}
+
+	params := security.Calibrate(
+		ctx.Duration("target"),
+		security.WithThreads(uint8(threads)),
+		security.WithMaxMemory(uint32(maxMemory*1024)),
+	)
+	if err := cfg.SetKDFParams(params); err != nil {
+		return err
+	}
+	output.Println("Parameters: " + params.String())
 
-	key, err := security.NewKeyFromPassword(password)
+	if !cfg.Key().Valid() {
+		return nil
+	}
+	password, err := passwordPrompt()
 	if err != nil {
 		return err
 	}
+	return verifyPassword(&cfg, password)
+}
 
-	cfg, err := configuration(ctx)
+// verifyPassword compares the password with the key of the current profile.
+// If the key was derived with other parameters than those set on the profile,
+// a new key is derived and all secrets are updated with it.
+func verifyPassword(cfg *config.Configuration, password []byte) error {
+	if ok := security.ComparePasswordAndKey(password, cfg.Key()); !ok {
+		return errors.New("invalid password")
+	}
+	if !cfg.KeyOutdated() {
+		return nil
+	}
+	key, err := security.NewKeyFromPassword(password, security.WithParams(cfg.KDFParams()))
 	if err != nil {
 		return err
 	}
+	return updateKey(cfg, key)
+}
 
+// updateKey updates all secrets of the current profile with the
+// provided key, and sets it to the configuration.
+func updateKey(cfg *config.Configuration, key security.Key) error {
 	if cfg.Key().Valid() {
-		if err := initHandler(ctx); err != nil {
-			return err
-		}
-		handler, err := handler(ctx)
+		handler, err := newHandler(*cfg)
 		if err != nil {
 			return err
 		}
@@ -173,12 +448,28 @@ func setPassword(ctx *cli.Context) error {
 			return err
 		}
 	}
+	return cfg.SetKey(key)
+}
 
-	if err := cfg.SetKey(key); err != nil {
+// setPassword takes the provided password and creates a new key from it
+// and sets it to the provided configuration and updates all
+// the secrets contained in the handler.
+func setPassword(ctx *cli.Context) error {
+	password, err := passwordPrompt()
+	if err != nil {
 		return err
 	}
 
-	return nil
+	cfg, err := configuration(ctx)
+	if err != nil {
+		return err
+	}
+
+	key, err := security.NewKeyFromPassword(password, security.WithParams(cfg.KDFParams()))
+	if err != nil {
+		return err
+	}
+	return updateKey(&cfg, key)
 }
 
 // newProfile creates a new profile.
@@ -219,42 +510,58 @@ func exportProfile(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	if ok := security.ComparePasswordAndKey(password, cfg.Key()); !ok {
-		return errors.New("invalid password")
+	if err := verifyPassword(&cfg, password); err != nil {
+		return err
 	}
 
 	password, err = passwordPrompt("Set password for output file: ")
 	if err != nil {
 		return err
 	}
-	key, err := security.NewSHA256FromPassword(password)
-	if err != nil {
-		return err
+	var options []config.ExportOption
+	if ctx.Bool("collection") {
+		options = append(options, config.WithCollection())
 	}
-	return cfg.Export(ctx.String("file"), key)
+	return cfg.Export(ctx.String("file"), password, options...)
 }
 
 // importProfile after a valid password has been entered.
 func importProfile(ctx *cli.Context) error {
-	cfg, err := configuration(ctx)
-	if err != nil {
-		return err
-	}
 	password, err := passwordPrompt()
 	if err != nil {
 		return err
 	}
-	key, err := security.NewSHA256FromPassword(password)
+	return importProfileFile(ctx, password)
+}
+
+// importProfileFile imports the profile of the file decrypted with the
+// password. An existing profile is replaced if the flag overwrite is set
+// or its collection is merged with the imported collection.
+func importProfileFile(ctx *cli.Context, password []byte) error {
+	cfg, err := configuration(ctx)
 	if err != nil {
 		return err
 	}
 
-	imported, err := config.Import(ctx.String("file"), key)
+	imported, err := config.Import(ctx.String("file"), password)
 	if err != nil {
 		return err
 	}
 
-	if err := cfg.AddProfile(imported.Profile, ctx.Bool("overwrite")); err != nil {
+	// Prepare the imported collection before the profile and keys
+	// are set, to not leave a profile without a usable collection.
+	var collection []byte
+	var merged bool
+	if len(imported.Collection) > 0 {
+		collection, merged, err = importCollection(ctx, cfg, imported.Profile.ID, imported.KeyringItem.StorageKey, imported.KeyringItem.Key, imported.Collection)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The existing profile is replaced when the collections are merged,
+	// since the keys of the imported profile replace the existing keys.
+	if err := cfg.AddProfile(imported.Profile, ctx.Bool("overwrite") || merged); err != nil {
 		return err
 	}
 	if err := cfg.SetProfile(imported.Profile.ID); err != nil {
@@ -266,5 +573,69 @@ func importProfile(ctx *cli.Context) error {
 	if err := cfg.SetKey(imported.KeyringItem.Key); err != nil {
 		return err
 	}
+	if collection != nil {
+		return storage.NewFileSystem(cfg.CollectionPath(imported.Profile.ID)).Save(collection)
+	}
 	return nil
 }
+
+// importCollection returns the imported collection to write for the profile.
+// If a collection already exists for the profile it is overwritten if
+// flag overwrite is set, otherwise the imported collection is merged into it
+// if flag merge is set or merging is confirmed. It returns true if the
+// collections were merged.
+func importCollection(ctx *cli.Context, cfg config.Configuration, profileID string, storageKey, key security.Key, imported []byte) ([]byte, bool, error) {
+	existing, err := os.ReadFile(cfg.CollectionPath(profileID))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return imported, false, nil
+		}
+		return nil, false, err
+	}
+
+	merge := ctx.Bool("merge")
+	if !merge && !ctx.Bool("overwrite") {
+		merge, err = confirmPrompt("A collection already exists for the profile. Merge the imported collection into it?")
+		if err != nil {
+			return nil, false, err
+		}
+		if !merge {
+			return nil, false, errors.New("a collection already exists for the profile, use --merge or --overwrite")
+		}
+	}
+	if !merge {
+		return imported, false, nil
+	}
+
+	importedHandler, err := secret.NewHandler(profileID, storageKey, key, storage.NewMemory(imported), secret.WithLoadCollection())
+	if err != nil {
+		return nil, false, err
+	}
+
+	existingStorageKey, existingKey, err := cfg.ProfileKeys(profileID)
+	if err != nil {
+		return nil, false, err
+	}
+	stg := storage.NewMemory(existing)
+	handler, err := secret.NewHandler(profileID, existingStorageKey, existingKey, stg, secret.WithLoadCollection())
+	if err != nil {
+		return nil, false, err
+	}
+	// Update the existing collection with the imported keys, since they
+	// replace the existing keys of the profile.
+	if err := handler.UpdateKey(key); err != nil {
+		return nil, false, err
+	}
+	skipped, err := handler.Merge(importedHandler.Collection())
+	if err != nil {
+		return nil, false, err
+	}
+	for _, name := range skipped {
+		output.PrintErrorln("skipped imported secret with conflicting name: " + name)
+	}
+	if err := handler.UpdateStorageKey(storageKey); err != nil {
+		return nil, false, err
+	}
+	b, err := stg.Load()
+	return b, true, err
+}
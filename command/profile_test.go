@@ -0,0 +1,185 @@
+package command
+
+import (
+	"flag"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/KarlGW/secman/config"
+	"github.com/KarlGW/secman/secret"
+	"github.com/KarlGW/secman/storage"
+	"github.com/google/go-cmp/cmp"
+	"github.com/urfave/cli/v2"
+	"github.com/zalando/go-keyring"
+)
+
+func TestImportProfileFile(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			merge     bool
+			overwrite bool
+		}
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "merge into existing profile",
+			input: struct {
+				merge     bool
+				overwrite bool
+			}{
+				merge: true,
+			},
+			want: []string{"exported", "local"},
+		},
+		{
+			name: "overwrite existing profile",
+			input: struct {
+				merge     bool
+				overwrite bool
+			}{
+				overwrite: true,
+			},
+			want: []string{"exported"},
+		},
+	}
+
+	keyring.MockInit()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			password := []byte("password")
+			path := t.TempDir()
+			cfg, err := config.Configure(config.WithPath(path))
+			if err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+			profile, err := cfg.NewProfile("test", password)
+			if err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+
+			stg := storage.NewFileSystem(cfg.CollectionPath(profile.ID))
+			handler, err := secret.NewHandler(profile.ID, cfg.StorageKey(), cfg.Key(), stg)
+			if err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+			exported, err := handler.AddSecret("exported", "value")
+			if err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+
+			cfg, err = config.Configure(config.WithPath(path), config.WithProfile(profile.ID))
+			if err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+			file := filepath.Join(t.TempDir(), "export")
+			if err := cfg.Export(file, password, config.WithCollection()); err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+
+			if err := handler.DeleteSecretByID(exported.ID); err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+			if _, err := handler.AddSecret("local", "value"); err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+
+			set := flag.NewFlagSet("import", flag.ContinueOnError)
+			set.String("file", file, "")
+			set.Bool("merge", test.input.merge, "")
+			set.Bool("overwrite", test.input.overwrite, "")
+			app := &cli.App{Metadata: map[string]any{"config": cfg}}
+
+			gotErr := importProfileFile(cli.NewContext(app, set, nil), password)
+			if test.wantErr != (gotErr != nil) {
+				t.Fatalf("importProfileFile() = unexpected error: %v\n", gotErr)
+			}
+
+			cfg, err = config.Configure(config.WithPath(path), config.WithProfile(profile.ID))
+			if err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+			handler, err = secret.NewHandler(profile.ID, cfg.StorageKey(), cfg.Key(), stg, secret.WithLoadCollection())
+			if err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+			secrets, err := handler.ListSecrets()
+			if err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+			var got []string
+			for _, s := range secrets {
+				got = append(got, s.Name)
+			}
+			slices.Sort(got)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("importProfileFile() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestCalibrate(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			threads   uint
+			maxMemory uint
+		}
+		wantErr bool
+	}{
+		{
+			name: "threads above the highest",
+			input: struct {
+				threads   uint
+				maxMemory uint
+			}{
+				threads:   256,
+				maxMemory: 64,
+			},
+			wantErr: true,
+		},
+		{
+			name: "max memory of 4 GiB",
+			input: struct {
+				threads   uint
+				maxMemory uint
+			}{
+				threads:   1,
+				maxMemory: 4 * 1024,
+			},
+			wantErr: true,
+		},
+		{
+			name: "max memory below the lowest",
+			input: struct {
+				threads   uint
+				maxMemory uint
+			}{
+				threads:   1,
+				maxMemory: 1,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			set := flag.NewFlagSet("calibrate", flag.ContinueOnError)
+			set.Duration("target", time.Millisecond, "")
+			set.Uint("threads", test.input.threads, "")
+			set.Uint("max-memory", test.input.maxMemory, "")
+			app := &cli.App{Metadata: map[string]any{"config": config.Configuration{}}}
+
+			gotErr := calibrate(cli.NewContext(app, set, nil))
+			if test.wantErr != (gotErr != nil) {
+				t.Errorf("calibrate() = unexpected error: %v\n", gotErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+
+	"github.com/KarlGW/secman/internal/mask"
+	"github.com/KarlGW/secman/secret"
+	"github.com/urfave/cli/v2"
+)
+
+// Run is a command for running a command with secrets set as
+// environment variables.
+func Run() *cli.Command {
+	return &cli.Command{
+		Name:      "run",
+		Category:  "Secrets",
+		Usage:     "Run a command with secrets set as environment variables",
+		ArgsUsage: "-- <command> [arguments]",
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:    "env",
+				Aliases: []string{"e"},
+				Usage:   "Environment variable and secret in the format NAME=<reference>. The reference is a name, ID, @id:<id>, @name:<name> or @label:<label>",
+			},
+			&cli.BoolFlag{
+				Name:    "mask",
+				Aliases: []string{"m"},
+				Usage:   "Mask the secret values if they appear in the output of the command",
+			},
+		},
+		Before: func(ctx *cli.Context) error {
+			return initHandler(ctx)
+		},
+		Action: func(ctx *cli.Context) error {
+			return run(ctx)
+		},
+	}
+}
+
+// run resolves and decrypts the secrets, and runs the command with them
+// set as environment variables. Signals are forwarded to the command and
+// its exit code is passed through.
+func run(ctx *cli.Context) error {
+	if !ctx.Args().Present() {
+		return errors.New("a command must be provided")
+	}
+	handler, err := handler(ctx)
+	if err != nil {
+		return err
+	}
+
+	env, values, err := secretEnv(handler, ctx.StringSlice("env"))
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(ctx.Args().First(), ctx.Args().Tail()...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = os.Stdin
+
+	var stdout, stderr io.Writer = os.Stdout, os.Stderr
+	if ctx.Bool("mask") {
+		mstdout, mstderr := mask.NewWriter(os.Stdout, values...), mask.NewWriter(os.Stderr, values...)
+		defer mstdout.Flush()
+		defer mstderr.Flush()
+		stdout, stderr = mstdout, mstderr
+	}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardSignals...)
+	defer signal.Stop(signals)
+	go func() {
+		for sig := range signals {
+			_ = cmd.Process.Signal(sig)
+		}
+	}()
+
+	if err := cmd.Wait(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return cli.Exit("", exitCode(exitErr))
+		}
+		return err
+	}
+	return nil
+}
+
+// secretEnv resolves and decrypts the secrets of the provided
+// NAME=<reference> pairs and returns them as environment variables
+// together with the decrypted values.
+func secretEnv(handler *secret.Handler, pairs []string) ([]string, [][]byte, error) {
+	env := make([]string, 0, len(pairs))
+	values := make([][]byte, 0, len(pairs))
+	for _, pair := range pairs {
+		name, ref, ok := strings.Cut(pair, "=")
+		if !ok || len(name) == 0 || len(ref) == 0 {
+			return nil, nil, fmt.Errorf("invalid environment variable %q, must be in the format NAME=<reference>", pair)
+		}
+		s, err := handler.GetSecretByReference(ref)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", ref, err)
+		}
+		decrypted, err := s.Decrypt()
+		if err != nil {
+			return nil, nil, err
+		}
+		env = append(env, name+"="+string(decrypted))
+		values = append(values, decrypted)
+	}
+	return env, values, nil
+}
+
+// exitCode returns the exit code of the exited process. If the process
+// was terminated by a signal, the code follows the shell convention
+// of 128 + signal number where available.
+func exitCode(err *exec.ExitError) int {
+	if code := err.ExitCode(); code >= 0 {
+		return code
+	}
+	return signalExitCode(err)
+}
@@ -0,0 +1,20 @@
+//go:build !unix
+
+package command
+
+import (
+	"os"
+	"os/exec"
+)
+
+// forwardSignals contains the signals that are forwarded to
+// a command started by run.
+var forwardSignals = []os.Signal{
+	os.Interrupt,
+}
+
+// signalExitCode returns the exit code for a process terminated
+// by a signal.
+func signalExitCode(err *exec.ExitError) int {
+	return 1
+}
@@ -0,0 +1,30 @@
+//go:build unix
+
+package command
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// forwardSignals contains the signals that are forwarded to
+// a command started by run.
+var forwardSignals = []os.Signal{
+	syscall.SIGINT,
+	syscall.SIGTERM,
+	syscall.SIGHUP,
+	syscall.SIGQUIT,
+	syscall.SIGUSR1,
+	syscall.SIGUSR2,
+	syscall.SIGWINCH,
+}
+
+// signalExitCode returns 128 + the number of the signal that
+// terminated the process.
+func signalExitCode(err *exec.ExitError) int {
+	if status, ok := err.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return 1
+}
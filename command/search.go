@@ -0,0 +1,59 @@
+package command
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/KarlGW/secman/output"
+	"github.com/KarlGW/secman/secret"
+	"github.com/urfave/cli/v2"
+)
+
+// SecretSearch is a command for searching secrets.
+func SecretSearch() *cli.Command {
+	return &cli.Command{
+		Name:      "search",
+		Category:  "Secrets",
+		Usage:     "Search secrets. Terms are matched fuzzily against name, display name, labels and tag values",
+		ArgsUsage: "<query>",
+		Description: `The query consists of terms and the filters:
+  type:<type>                  secrets of the type
+  label:<label>                secrets with the label
+  tag:<key>, tag:<key>=<value> secrets with the tag
+  updated<age, updated>age     secrets updated within or before the age (30d, 2w, 12h) or before or after a date (2006-01-02)
+  created<age, created>age     secrets created within or before the age or before or after a date`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    "first",
+				Aliases: []string{"f"},
+				Usage:   "Print only the name of the best match, for use with get",
+			},
+			outputFlag(),
+		},
+		Before: func(ctx *cli.Context) error {
+			return initHandler(ctx)
+		},
+		Action: func(ctx *cli.Context) error {
+			if !ctx.Args().Present() {
+				return errors.New("a query must be provided")
+			}
+			handler, err := handler(ctx)
+			if err != nil {
+				return err
+			}
+			secrets, err := handler.SearchSecrets(strings.Join(ctx.Args().Slice(), " "))
+			if err != nil {
+				return err
+			}
+
+			if ctx.Bool("first") {
+				if len(secrets) == 0 {
+					return secret.ErrSecretNotFound
+				}
+				output.Println(secrets[0].Name)
+				return nil
+			}
+			return writeOutput(ctx, output.KindTable, secretsTable(secrets))
+		},
+	}
+}
@@ -2,6 +2,7 @@ package command
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"strings"
@@ -54,6 +55,9 @@ func SecretList() *cli.Command {
 		Name:     "list",
 		Category: "Secrets",
 		Usage:    "List secrets",
+		Flags: []cli.Flag{
+			outputFlag(),
+		},
 		Before: func(ctx *cli.Context) error {
 			return initHandler(ctx)
 		},
@@ -66,8 +70,7 @@ func SecretList() *cli.Command {
 			if err != nil {
 				return err
 			}
-			output.Println(string(secrets.JSON()))
-			return nil
+			return writeOutput(ctx, output.KindJSON, secretsTable(secrets))
 		},
 	}
 }
@@ -100,6 +103,7 @@ func SecretGet() *cli.Command {
 				Name:    "clipboard",
 				Usage:   "Copy the secret value to the clipboard",
 			},
+			outputFlag(),
 		},
 		Before: func(ctx *cli.Context) error {
 			return initHandler(ctx)
@@ -120,14 +124,16 @@ func SecretGet() *cli.Command {
 					return err
 				}
 				if ctx.IsSet("clipboard") {
-					return clipboard.WriteAll(string(decrypted))
+					if err := clipboard.WriteAll(string(decrypted)); err != nil {
+						return err
+					}
+					return clearClipboardAfter(ctx, string(decrypted))
 				}
 				output.Println(string(decrypted))
 				return nil
 			}
 
-			output.Println(string(s.JSON()))
-			return nil
+			return writeOutput(ctx, output.KindJSON, secretTable(s))
 		},
 	}
 }
@@ -139,7 +145,7 @@ func SecretCreate() *cli.Command {
 		Category: "Secrets",
 		Usage:    "Create a secret",
 		Aliases:  []string{"add"},
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:    "name",
 				Aliases: []string{"n"},
@@ -155,7 +161,8 @@ func SecretCreate() *cli.Command {
 				Aliases: []string{"c"},
 				Usage:   "Get the secret value from clipboard",
 			},
-		},
+			breachDBFlag(),
+		}, secretFlags()...),
 		Before: func(ctx *cli.Context) error {
 			return initHandler(ctx)
 		},
@@ -184,8 +191,20 @@ func SecretCreate() *cli.Command {
 				}
 			}
 
-			_, err = handler.AddSecret(ctx.String("name"), value)
-			return err
+			options, err := secretOptions(ctx)
+			if err != nil {
+				return err
+			}
+
+			s, err := handler.AddSecret(ctx.String("name"), value, options...)
+			if err := warnPostHook(err); err != nil {
+				return err
+			}
+			warnBreached(ctx, value, s.Type)
+			if ctx.IsSet("clipboard") && !ctx.IsSet("value") {
+				return clearClipboardAfter(ctx, value)
+			}
+			return nil
 		},
 	}
 }
@@ -197,7 +216,7 @@ func SecretUpdate() *cli.Command {
 		Category: "Secrets",
 		Usage:    "Update a secret",
 		Aliases:  []string{"set"},
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:    "id",
 				Aliases: []string{"i"},
@@ -218,7 +237,8 @@ func SecretUpdate() *cli.Command {
 				Aliases: []string{"c"},
 				Usage:   "Get the secret value from clipboard",
 			},
-		},
+			breachDBFlag(),
+		}, secretFlags()...),
 		Before: func(ctx *cli.Context) error {
 			return initHandler(ctx)
 		},
@@ -242,20 +262,28 @@ func SecretUpdate() *cli.Command {
 				}
 			} else {
 				value, err = fromPipe()
-				if err != nil {
+				// Allow updates of only the metadata of the secret.
+				if err != nil && !(errors.Is(err, errNoValue) && hasSecretOptions(ctx)) {
 					return err
 				}
 			}
 
-			var options []secret.SecretOption
+			options, err := secretOptions(ctx)
+			if err != nil {
+				return err
+			}
 			if len(value) > 0 {
 				options = append(options, secret.WithValue([]byte(value)))
 			}
 
-			_, err = handler.UpdateSecretByID(s.ID, options...)
-			if err != nil {
+			s, err = handler.UpdateSecretByID(s.ID, options...)
+			if err := warnPostHook(err); err != nil {
 				return err
 			}
+			warnBreached(ctx, value, s.Type)
+			if ctx.IsSet("clipboard") && !ctx.IsSet("value") {
+				return clearClipboardAfter(ctx, value)
+			}
 
 			return nil
 		},
@@ -294,11 +322,75 @@ func SecretDelete() *cli.Command {
 				return err
 			}
 
-			return handler.DeleteSecretByID(s.ID)
+			return warnPostHook(handler.DeleteSecretByID(s.ID))
+		},
+	}
+}
+
+// errNoValue is returned when no value is provided.
+var errNoValue = errors.New("no value provided")
+
+// secretFlags returns the flags for the metadata of a secret.
+func secretFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "display-name",
+			Usage: "Display name of the secret",
+		},
+		&cli.StringFlag{
+			Name:    "type",
+			Aliases: []string{"t"},
+			Usage:   "Type of the secret (generic, credential, note, file or ssh-key)",
+		},
+		&cli.StringSliceFlag{
+			Name:    "label",
+			Aliases: []string{"l"},
+			Usage:   "Label of the secret. Can be set several times",
+		},
+		&cli.StringSliceFlag{
+			Name:  "tag",
+			Usage: "Tag of the secret in the format key=value. Can be set several times",
 		},
 	}
 }
 
+// hasSecretOptions returns true if any of the flags for the
+// metadata of a secret are set.
+func hasSecretOptions(ctx *cli.Context) bool {
+	return ctx.IsSet("display-name") || ctx.IsSet("type") || ctx.IsSet("label") || ctx.IsSet("tag")
+}
+
+// secretOptions returns secret options from the flags for the
+// metadata of a secret.
+func secretOptions(ctx *cli.Context) ([]secret.SecretOption, error) {
+	var options []secret.SecretOption
+	if ctx.IsSet("display-name") {
+		options = append(options, secret.WithDisplayName(ctx.String("display-name")))
+	}
+	if ctx.IsSet("type") {
+		t, err := secret.ParseType(ctx.String("type"))
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, secret.WithType(t))
+	}
+	if ctx.IsSet("label") {
+		options = append(options, secret.WithLabels(ctx.StringSlice("label")...))
+	}
+	if ctx.IsSet("tag") {
+		tags := make(map[string]string)
+		for _, tag := range ctx.StringSlice("tag") {
+			k, v, ok := strings.Cut(tag, "=")
+			if !ok || len(k) == 0 {
+				return nil, fmt.Errorf("invalid tag %q, must be in the format key=value", tag)
+			}
+			tags[k] = v
+		}
+		options = append(options, secret.WithTags(tags))
+	}
+	return options, nil
+}
+
 // getSecret gets a secret by either id or name.
 func getSecret(handler *secret.Handler, id, name string) (secret.Secret, error) {
 	// Retrieve the secret.
@@ -325,7 +417,7 @@ func fromPipe() (string, error) {
 	}
 
 	if (info.Mode() & os.ModeCharDevice) != 0 {
-		return "", errors.New("no value provided")
+		return "", errNoValue
 	}
 
 	b, err := io.ReadAll(os.Stdin)
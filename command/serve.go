@@ -0,0 +1,314 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/KarlGW/secman/config"
+	"github.com/KarlGW/secman/output"
+	"github.com/KarlGW/secman/rpc"
+	"github.com/KarlGW/secman/secret"
+	"github.com/KarlGW/secman/server"
+	"github.com/urfave/cli/v2"
+)
+
+// Serve is a command for serving the secrets of the current profile
+// over a REST/JSON API on a Unix socket.
+func Serve() *cli.Command {
+	return &cli.Command{
+		Name:     "serve",
+		Usage:    "Serve the secrets of the current profile over an API on a Unix socket",
+		Category: "Subcommands",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "socket",
+				Aliases: []string{"s"},
+				Usage:   "Path to the socket of the server",
+				Value:   server.DefaultSocketPath(),
+			},
+			&cli.BoolFlag{
+				Name:  "read-only",
+				Usage: "Serve only read requests",
+			},
+		},
+		Subcommands: []*cli.Command{
+			ServeToken(),
+			ServeGRPC(),
+		},
+		Before: func(ctx *cli.Context) error {
+			return configure(ctx)
+		},
+		Action: func(ctx *cli.Context) error {
+			return serve(ctx)
+		},
+	}
+}
+
+// ServeGRPC is a subcommand for serving the secrets of the current
+// profile over gRPC on a Unix socket, or over TCP with mutual TLS.
+func ServeGRPC() *cli.Command {
+	return &cli.Command{
+		Name:  "grpc",
+		Usage: "Serve the secrets of the current profile over gRPC",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "socket",
+				Aliases: []string{"s"},
+				Usage:   "Path to the socket of the server",
+				Value:   rpc.DefaultSocketPath(),
+			},
+			&cli.StringFlag{
+				Name:    "address",
+				Aliases: []string{"a"},
+				Usage:   "Address (host:port) to serve on over TCP instead of the socket. Requires mutual TLS",
+			},
+			&cli.StringFlag{
+				Name:  "tls-cert",
+				Usage: "Path to the certificate of the server",
+			},
+			&cli.StringFlag{
+				Name:  "tls-key",
+				Usage: "Path to the key of the certificate of the server",
+			},
+			&cli.StringFlag{
+				Name:  "tls-ca",
+				Usage: "Path to the certificate authority of client certificates",
+			},
+			&cli.BoolFlag{
+				Name:  "read-only",
+				Usage: "Serve only read requests",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			return serveGRPC(ctx)
+		},
+	}
+}
+
+// ServeToken is a subcommand containing subcommands for handling
+// tokens of clients of the server.
+func ServeToken() *cli.Command {
+	return &cli.Command{
+		Name:  "token",
+		Usage: "Manage tokens of clients of the server",
+		Subcommands: []*cli.Command{
+			ServeTokenCreate(),
+			ServeTokenList(),
+			ServeTokenDelete(),
+		},
+	}
+}
+
+// ServeTokenCreate is a subcommand for creating a token.
+func ServeTokenCreate() *cli.Command {
+	return &cli.Command{
+		Name:  "create",
+		Usage: "Create a token. The token is only shown once",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "name",
+				Aliases:  []string{"n"},
+				Usage:    "Name of the client of the token",
+				Required: true,
+			},
+			&cli.BoolFlag{
+				Name:  "read-only",
+				Usage: "Allow only read requests with the token",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			cfg, err := configuration(ctx)
+			if err != nil {
+				return err
+			}
+			tokens, err := cfg.APITokens()
+			if err != nil {
+				return err
+			}
+			name := ctx.String("name")
+			if slices.ContainsFunc(tokens, func(t config.APIToken) bool {
+				return t.Name == name
+			}) {
+				return fmt.Errorf("a token with name %s already exists", name)
+			}
+
+			token, err := server.NewToken()
+			if err != nil {
+				return err
+			}
+			tokens = append(tokens, config.APIToken{
+				Name:     name,
+				Hash:     server.HashToken(token),
+				ReadOnly: ctx.Bool("read-only"),
+				Created:  time.Now(),
+			})
+			if err := cfg.SetAPITokens(tokens); err != nil {
+				return err
+			}
+			output.Println(token)
+			return nil
+		},
+	}
+}
+
+// ServeTokenList is a subcommand for listing tokens.
+func ServeTokenList() *cli.Command {
+	return &cli.Command{
+		Name:  "list",
+		Usage: "List tokens",
+		Flags: []cli.Flag{
+			outputFlag(),
+		},
+		Action: func(ctx *cli.Context) error {
+			cfg, err := configuration(ctx)
+			if err != nil {
+				return err
+			}
+			tokens, err := cfg.APITokens()
+			if err != nil {
+				return err
+			}
+			return writeOutput(ctx, output.KindTable, apiTokensTable(tokens))
+		},
+	}
+}
+
+// ServeTokenDelete is a subcommand for deleting a token.
+func ServeTokenDelete() *cli.Command {
+	return &cli.Command{
+		Name:  "delete",
+		Usage: "Delete a token",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "name",
+				Aliases:  []string{"n"},
+				Usage:    "Name of the client of the token",
+				Required: true,
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			cfg, err := configuration(ctx)
+			if err != nil {
+				return err
+			}
+			tokens, err := cfg.APITokens()
+			if err != nil {
+				return err
+			}
+			name := ctx.String("name")
+			i := slices.IndexFunc(tokens, func(t config.APIToken) bool {
+				return t.Name == name
+			})
+			if i == -1 {
+				return fmt.Errorf("a token with name %s does not exist", name)
+			}
+			return cfg.SetAPITokens(slices.Delete(tokens, i, i+1))
+		},
+	}
+}
+
+// serve serves the API until interrupted. The server holds a single
+// handler for the lifetime of the process, and reloads it when the
+// collection is changed by other processes.
+func serve(ctx *cli.Context) error {
+	handler, tokens, err := serveHandler(ctx)
+	if err != nil {
+		return err
+	}
+
+	var options []server.Option
+	if ctx.Bool("read-only") {
+		options = append(options, server.WithReadOnly())
+	}
+
+	socket := ctx.String("socket")
+	listener, err := server.Listen(socket)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(socket)
+
+	sctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	output.Prompt(fmt.Sprintf("Serving on %s\n", socket))
+	return server.New(handler, tokens, options...).Serve(sctx, listener)
+}
+
+// serveGRPC serves the gRPC service until interrupted. The server
+// holds a single handler for the lifetime of the process.
+func serveGRPC(ctx *cli.Context) error {
+	handler, tokens, err := serveHandler(ctx)
+	if err != nil {
+		return err
+	}
+
+	options := []rpc.Option{rpc.WithTokens(tokens)}
+	if ctx.Bool("read-only") {
+		options = append(options, rpc.WithReadOnly())
+	}
+
+	var listener net.Listener
+	var addr string
+	if ctx.IsSet("address") {
+		if !ctx.IsSet("tls-cert") || !ctx.IsSet("tls-key") || !ctx.IsSet("tls-ca") {
+			return errors.New("serving over TCP requires --tls-cert, --tls-key and --tls-ca")
+		}
+		tlsConfig, err := rpc.ServerTLSConfig(ctx.String("tls-cert"), ctx.String("tls-key"), ctx.String("tls-ca"))
+		if err != nil {
+			return err
+		}
+		options = append(options, rpc.WithTLSConfig(tlsConfig))
+		addr = ctx.String("address")
+		if listener, err = net.Listen("tcp", addr); err != nil {
+			return err
+		}
+	} else {
+		addr = ctx.String("socket")
+		if listener, err = server.Listen(addr); err != nil {
+			return err
+		}
+		defer os.Remove(addr)
+	}
+
+	sctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	output.Prompt(fmt.Sprintf("Serving gRPC on %s\n", addr))
+	return rpc.New(handler, options...).Serve(sctx, listener)
+}
+
+// serveHandler returns the handler of the current profile and the
+// tokens of the clients of the servers.
+func serveHandler(ctx *cli.Context) (*secret.Handler, []server.Token, error) {
+	if err := initHandler(ctx); err != nil {
+		return nil, nil, err
+	}
+	handler, err := handler(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg, err := configuration(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	apiTokens, err := cfg.APITokens()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(apiTokens) == 0 {
+		return nil, nil, errors.New("no tokens exist, create one with: secman serve token create --name <name>")
+	}
+	tokens := make([]server.Token, len(apiTokens))
+	for i, t := range apiTokens {
+		tokens[i] = server.Token{Name: t.Name, Hash: t.Hash, ReadOnly: t.ReadOnly}
+	}
+	return handler, tokens, nil
+}
@@ -0,0 +1,256 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/KarlGW/secman/internal/process"
+	"github.com/KarlGW/secman/output"
+	"github.com/KarlGW/secman/secret"
+	"github.com/KarlGW/secman/sshagent"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// SSHAgent is a command for starting an SSH agent serving SSH keys
+// stored as secrets.
+func SSHAgent() *cli.Command {
+	return &cli.Command{
+		Name:     "ssh-agent",
+		Category: "SSH",
+		Usage:    "Start an SSH agent with SSH keys stored as secrets. Without label or name all secrets of type ssh-key are loaded",
+		Flags: append(sshKeyFlags(),
+			&cli.StringFlag{
+				Name:    "socket",
+				Aliases: []string{"s"},
+				Usage:   "Path to the socket of the agent",
+				Value:   sshagent.DefaultSocketPath(),
+			},
+			&cli.BoolFlag{
+				Name:  "foreground",
+				Usage: "Run the agent in the foreground",
+			},
+		),
+		Before: func(ctx *cli.Context) error {
+			return initHandler(ctx)
+		},
+		Action: func(ctx *cli.Context) error {
+			if ctx.Bool("foreground") {
+				return runSSHAgent(ctx)
+			}
+			return startSSHAgent(ctx)
+		},
+	}
+}
+
+// SSHAdd is a command for adding SSH keys stored as secrets to a
+// running SSH agent.
+func SSHAdd() *cli.Command {
+	return &cli.Command{
+		Name:     "ssh-add",
+		Category: "SSH",
+		Usage:    "Add SSH keys stored as secrets to the running SSH agent. Without label or name all secrets of type ssh-key are added",
+		Flags:    sshKeyFlags(),
+		Before: func(ctx *cli.Context) error {
+			return initHandler(ctx)
+		},
+		Action: func(ctx *cli.Context) error {
+			socket := os.Getenv(sshagent.SocketEnv)
+			if len(socket) == 0 {
+				return errors.New(sshagent.SocketEnv + " is not set")
+			}
+			conn, err := net.Dial("unix", socket)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			n, err := addSSHKeys(ctx, agent.NewClient(conn))
+			if err != nil {
+				return err
+			}
+			output.Prompt(fmt.Sprintf("Added %d keys\n", n))
+			return nil
+		},
+	}
+}
+
+// sshKeyFlags returns the flags for selecting SSH keys and their
+// constraints.
+func sshKeyFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "label",
+			Aliases: []string{"l"},
+			Usage:   "Label of the secrets with SSH keys",
+		},
+		&cli.StringSliceFlag{
+			Name:    "name",
+			Aliases: []string{"n"},
+			Usage:   "Name of a secret with an SSH key. Can be set several times",
+		},
+		&cli.BoolFlag{
+			Name:    "confirm",
+			Aliases: []string{"c"},
+			Usage:   "Require confirmation with SSH_ASKPASS before each use of the keys",
+		},
+		&cli.DurationFlag{
+			Name:    "lifetime",
+			Aliases: []string{"t"},
+			Usage:   "Remove the keys from the agent after the duration",
+		},
+	}
+}
+
+// startSSHAgent verifies that the SSH keys can be loaded, and starts
+// the SSH agent as a detached process. The environment needed to use
+// the agent is printed.
+func startSSHAgent(ctx *cli.Context) error {
+	if _, err := addSSHKeys(ctx, sshagent.New()); err != nil {
+		return err
+	}
+
+	socket := ctx.String("socket")
+	args := []string{"ssh-agent", "--foreground", "--socket", socket}
+	if ctx.IsSet("label") {
+		args = append(args, "--label", ctx.String("label"))
+	}
+	for _, name := range ctx.StringSlice("name") {
+		args = append(args, "--name", name)
+	}
+	if ctx.Bool("confirm") {
+		args = append(args, "--confirm")
+	}
+	if ctx.IsSet("lifetime") {
+		args = append(args, "--lifetime", ctx.Duration("lifetime").String())
+	}
+
+	pid, err := process.Spawn(nil, args...)
+	if err != nil {
+		return err
+	}
+	if err := waitForSocket(socket, 5*time.Second); err != nil {
+		return err
+	}
+
+	output.Println(fmt.Sprintf("%s=%s; export %s;\necho Agent pid %d;", sshagent.SocketEnv, socket, sshagent.SocketEnv, pid))
+	return nil
+}
+
+// runSSHAgent runs the SSH agent in the foreground until interrupted.
+func runSSHAgent(ctx *cli.Context) error {
+	a := sshagent.New()
+	if _, err := addSSHKeys(ctx, a); err != nil {
+		return err
+	}
+
+	socket := ctx.String("socket")
+	listener, err := a.Listen(socket)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(socket)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		<-signals
+		listener.Close()
+	}()
+
+	return a.Serve(listener)
+}
+
+// addSSHKeys adds the selected SSH keys to the agent with the
+// constraints of the flags. Returns the number of added keys.
+func addSSHKeys(ctx *cli.Context, a agent.Agent) (int, error) {
+	handler, err := handler(ctx)
+	if err != nil {
+		return 0, err
+	}
+	secrets, err := sshKeySecrets(ctx, handler)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, s := range secrets {
+		key, err := sshagent.ParseKey(s)
+		if err != nil {
+			return 0, err
+		}
+		if err := a.Add(agent.AddedKey{
+			PrivateKey:       key,
+			Comment:          s.Name,
+			LifetimeSecs:     uint32(ctx.Duration("lifetime").Seconds()),
+			ConfirmBeforeUse: ctx.Bool("confirm"),
+		}); err != nil {
+			return 0, fmt.Errorf("%s: %w", s.Name, err)
+		}
+	}
+	return len(secrets), nil
+}
+
+// sshKeySecrets returns the secrets with the label and names of the
+// flags. If neither is set, all secrets of type ssh-key are returned.
+func sshKeySecrets(ctx *cli.Context, handler *secret.Handler) (secret.Secrets, error) {
+	var secrets secret.Secrets
+	if !ctx.IsSet("label") && !ctx.IsSet("name") {
+		all, err := handler.ListSecrets()
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range all {
+			if s.Type == secret.TypeSSHKey {
+				secrets = append(secrets, s)
+			}
+		}
+	}
+	if ctx.IsSet("label") {
+		labeled, err := handler.GetSecretsByLabel(ctx.String("label"))
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, labeled...)
+	}
+	for _, name := range ctx.StringSlice("name") {
+		s, err := handler.GetSecretByName(name)
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, s)
+	}
+	if len(secrets) == 0 {
+		return nil, errors.New("no SSH keys found")
+	}
+	// Remove secrets selected by both label and name.
+	seen := make(map[string]struct{}, len(secrets))
+	unique := secrets[:0]
+	for _, s := range secrets {
+		if _, ok := seen[s.ID]; !ok {
+			seen[s.ID] = struct{}{}
+			unique = append(unique, s)
+		}
+	}
+	return unique, nil
+}
+
+// waitForSocket waits for the socket to accept connections.
+func waitForSocket(socket string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		conn, err := net.Dial("unix", socket)
+		if err == nil {
+			return conn.Close()
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
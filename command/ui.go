@@ -0,0 +1,32 @@
+package command
+
+import (
+	"github.com/KarlGW/secman/ui"
+	"github.com/atotto/clipboard"
+	"github.com/urfave/cli/v2"
+)
+
+// UI is a command for browsing and editing secrets in a
+// full-screen terminal interface.
+func UI() *cli.Command {
+	return &cli.Command{
+		Name:     "ui",
+		Category: "Secrets",
+		Usage:    "Browse and edit secrets in a terminal interface",
+		Before: func(ctx *cli.Context) error {
+			return initHandler(ctx)
+		},
+		Action: func(ctx *cli.Context) error {
+			handler, err := handler(ctx)
+			if err != nil {
+				return err
+			}
+			return ui.Run(handler, ui.WithClipboard(func(value string) error {
+				if err := clipboard.WriteAll(value); err != nil {
+					return err
+				}
+				return clearClipboardAfter(ctx, value)
+			}))
+		},
+	}
+}
@@ -0,0 +1,103 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/KarlGW/secman/output"
+	"github.com/KarlGW/secman/secret"
+	"github.com/urfave/cli/v2"
+)
+
+// Watch is a command for watching secrets for changes and running
+// a command on each change.
+func Watch() *cli.Command {
+	return &cli.Command{
+		Name:      "watch",
+		Category:  "Secrets",
+		Usage:     "Watch secrets for changes and run a command on each change",
+		ArgsUsage: "[-- <command> [arguments]]",
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:    "name",
+				Aliases: []string{"n"},
+				Usage:   "Name of a secret to watch. Watches all secrets if not set",
+			},
+			&cli.DurationFlag{
+				Name:  "interval",
+				Usage: "Interval between checks for changes",
+				Value: time.Second,
+			},
+		},
+		Before: func(ctx *cli.Context) error {
+			if ctx.Duration("interval") <= 0 {
+				return errors.New("interval must be greater than 0")
+			}
+			return initHandler(ctx)
+		},
+		Action: func(ctx *cli.Context) error {
+			return watch(ctx)
+		},
+	}
+}
+
+// watch watches the secrets until interrupted. For each change the
+// command is run with the event as JSON on stdin and in environment
+// variables. Without a command the events are written to stdout.
+// A failing command does not stop the watch.
+func watch(ctx *cli.Context) error {
+	handler, err := handler(ctx)
+	if err != nil {
+		return err
+	}
+
+	sctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	events, err := handler.Watch(sctx, secret.WithInterval(ctx.Duration("interval")))
+	if err != nil {
+		return err
+	}
+
+	names := ctx.StringSlice("name")
+	for event := range events {
+		if len(names) > 0 && !slices.Contains(names, event.Name) {
+			continue
+		}
+		b, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if !ctx.Args().Present() {
+			output.Println(string(b))
+			continue
+		}
+		if err := runWatchCommand(ctx, event, b); err != nil {
+			output.Prompt(fmt.Sprintf("Command failed for %s of %s: %s\n", event.Type, event.Name, err))
+		}
+	}
+	return nil
+}
+
+// runWatchCommand runs the command of the watch for the event.
+func runWatchCommand(ctx *cli.Context, event secret.Event, b []byte) error {
+	cmd := exec.Command(ctx.Args().First(), ctx.Args().Tail()...)
+	cmd.Env = append(
+		os.Environ(),
+		"SECMAN_EVENT="+event.Type.String(),
+		"SECMAN_SECRET_ID="+event.ID,
+		"SECMAN_SECRET_NAME="+event.Name,
+	)
+	cmd.Stdin = bytes.NewReader(b)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	return cmd.Run()
+}
@@ -2,18 +2,29 @@ package config
 
 import (
 	"bytes"
+	"cmp"
 	"errors"
+	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"os/user"
 	"path/filepath"
+	"slices"
+	"time"
 
+	"github.com/KarlGW/secman/internal/clipboard"
 	"github.com/KarlGW/secman/internal/filesystem"
 	"github.com/KarlGW/secman/internal/gob"
 	"github.com/KarlGW/secman/internal/security"
 	"gopkg.in/yaml.v3"
 )
 
+var (
+	// ErrProfileNotFound is returned when a profile cannot be found.
+	ErrProfileNotFound = errors.New("profile does not exist")
+)
+
 const (
 	application       = "secman"
 	dir               = ".secman"
@@ -27,7 +38,7 @@ type Configuration struct {
 	ProfileID string `yaml:"profileId"`
 	Username  string `yaml:"username"`
 	u         *user.User
-	profile   profile
+	profile   Profile
 	profiles  profiles
 	// path to the application files for a user.
 	path string
@@ -71,7 +82,7 @@ func Configure(options ...Option) (Configuration, error) {
 		if err := cfg.SetProfile(cfg.ProfileID); err != nil {
 			return cfg, err
 		}
-		cfg.storagePath = filepath.Join(cfg.path, "collections", cfg.ProfileID+storageFileSuffix)
+		cfg.storagePath = cfg.CollectionPath(cfg.ProfileID)
 
 		if err := cfg.setupKeyringItem(cfg.ProfileID, false); err != nil {
 			return cfg, err
@@ -95,6 +106,14 @@ func WithUser(name string) Option {
 	}
 }
 
+// WithPath sets the path to the application files. Defaults to
+// .secman in the home directory of the user.
+func WithPath(path string) Option {
+	return func(c *Configuration) {
+		c.path = path
+	}
+}
+
 // YAML returns the YAML encoding of the Configuration.
 func (c Configuration) YAML() []byte {
 	var buf bytes.Buffer
@@ -188,28 +207,135 @@ func (c Configuration) StoragePath() string {
 	return c.storagePath
 }
 
-// Export a configuration and profile
-func (c Configuration) Export(dst string, key []byte) error {
-	exported := export{
+// KDFParams returns the argon2id parameters for deriving keys from
+// passwords for the current profile.
+func (c Configuration) KDFParams() security.Params {
+	if c.profile.KDF.IsZero() {
+		return security.DefaultParams
+	}
+	return c.profile.KDF
+}
+
+// SetKDFParams sets the argon2id parameters for deriving keys
+// from passwords for the current profile.
+func (c *Configuration) SetKDFParams(params security.Params) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	if len(c.profile.ID) == 0 {
+		return errors.New("no profile set")
+	}
+	c.profile.KDF = params
+	c.profiles.p[c.profile.ID] = c.profile
+	return c.Save()
+}
+
+// ClipboardTimeout returns the duration before the clipboard is cleared
+// for the current profile.
+func (c Configuration) ClipboardTimeout() time.Duration {
+	if c.profile.ClipboardTimeout == nil {
+		return clipboard.DefaultTimeout
+	}
+	return *c.profile.ClipboardTimeout
+}
+
+// SetClipboardTimeout sets the duration before the clipboard is cleared
+// for the current profile. 0 disables clearing.
+func (c *Configuration) SetClipboardTimeout(timeout time.Duration) error {
+	if timeout < 0 {
+		return errors.New("clipboard timeout must not be negative")
+	}
+	if len(c.profile.ID) == 0 {
+		return errors.New("no profile set")
+	}
+	c.profile.ClipboardTimeout = &timeout
+	c.profiles.p[c.profile.ID] = c.profile
+	return c.Save()
+}
+
+// KeyOutdated returns true if the key of the current profile was derived
+// with other parameters than those set on the profile, or with
+// an earlier version.
+func (c Configuration) KeyOutdated() bool {
+	key := c.keyringItem.Key
+	if !key.Valid() {
+		return false
+	}
+	return key.Version < security.KeyVersion || key.KDFParams() != c.KDFParams()
+}
+
+// CollectionPath returns the path of the collection file of the
+// profile with the provided ID.
+func (c Configuration) CollectionPath(profileID string) string {
+	return filepath.Join(c.path, "collections", profileID+storageFileSuffix)
+}
+
+// ProfileKeys returns the storage key and key of the profile with the
+// provided ID from the keyring. If no keys are set for the profile,
+// empty keys are returned.
+func (c Configuration) ProfileKeys(profileID string) (security.Key, security.Key, error) {
+	val, err := c.keyring.Get(application, profileID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return security.Key{}, security.Key{}, nil
+		}
+		return security.Key{}, security.Key{}, err
+	}
+	var item keyringItem
+	if err := item.Decode([]byte(val)); err != nil {
+		return security.Key{}, security.Key{}, err
+	}
+	return item.StorageKey, item.Key, nil
+}
+
+// Export a configuration and profile. The file is encrypted with a key
+// derived from the provided password with a salted argon2id. The salt and
+// parameters are stored in the header of the file.
+func (c Configuration) Export(dst string, password []byte, options ...ExportOption) error {
+	opts := ExportOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	exported := ProfileExport{
 		Version:     exportVersion,
 		KeyringItem: c.keyringItem,
 		Profile:     c.profile,
 	}
+	if opts.Collection {
+		collection, err := os.ReadFile(c.storagePath)
+		if err != nil {
+			return err
+		}
+		exported.Collection = collection
+	}
 
 	b, err := gob.Encode(exported)
 	if err != nil {
 		return err
 	}
-	encrypted, err := security.Encrypt(b, key)
+
+	key, err := security.NewKeyFromPassword(password, security.WithParams(exportParams))
+	if err != nil {
+		return err
+	}
+	header := exportHeader{
+		Format: exportFormat,
+		Salt:   key.Salt,
+		Params: key.Params,
+	}
+	encoded := header.encode()
+
+	encrypted, err := security.EncryptWithAdditionalData(b, key.Value, encoded)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(dst, encrypted, 0600)
+	return os.WriteFile(dst, append(encoded, encrypted...), 0600)
 }
 
 // NewProfile creates a new profile and generates a new storage
 // key for it.
-func (c *Configuration) NewProfile(name string, password []byte) (profile, error) {
+func (c *Configuration) NewProfile(name string, password []byte) (Profile, error) {
 	if len(name) == 0 {
 		name = c.u.Username
 	}
@@ -232,7 +358,7 @@ func (c *Configuration) NewProfile(name string, password []byte) (profile, error
 		return profile, nil
 	}
 
-	key, err := security.NewKeyFromPassword(password)
+	key, err := security.NewKeyFromPassword(password, security.WithParams(c.KDFParams()))
 	if err != nil {
 		return profile, err
 	}
@@ -244,7 +370,7 @@ func (c *Configuration) NewProfile(name string, password []byte) (profile, error
 }
 
 // AddProfile adds a profile to the configuration.
-func (c *Configuration) AddProfile(p profile, overwrite bool) error {
+func (c *Configuration) AddProfile(p Profile, overwrite bool) error {
 	if _, ok := c.profiles.p[p.ID]; ok {
 		if !overwrite {
 			return errors.New("profile already exist")
@@ -254,11 +380,97 @@ func (c *Configuration) AddProfile(p profile, overwrite bool) error {
 	return c.Save()
 }
 
+// Profiles returns all profiles sorted by name.
+func (c Configuration) Profiles() []Profile {
+	profiles := make([]Profile, 0, len(c.profiles.p))
+	for _, p := range c.profiles.p {
+		profiles = append(profiles, p)
+	}
+	slices.SortFunc(profiles, func(a, b Profile) int {
+		if n := cmp.Compare(a.Name, b.Name); n != 0 {
+			return n
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return profiles
+}
+
+// FindProfile finds a profile by ID or name. If several profiles
+// have the provided name, an error is returned.
+func (c Configuration) FindProfile(idOrName string) (Profile, error) {
+	if p, ok := c.profiles.p[idOrName]; ok {
+		return p, nil
+	}
+	var found []Profile
+	for _, p := range c.profiles.p {
+		if p.Name == idOrName {
+			found = append(found, p)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Profile{}, ErrProfileNotFound
+	case 1:
+		return found[0], nil
+	}
+	return Profile{}, fmt.Errorf("several profiles are named %s, use the ID", idOrName)
+}
+
+// UpdateProfile updates the name, display name and description of the
+// profile with the provided ID. Empty values are left unchanged.
+func (c *Configuration) UpdateProfile(id, name, displayName, description string) (Profile, error) {
+	p, ok := c.profiles.p[id]
+	if !ok {
+		return Profile{}, ErrProfileNotFound
+	}
+	if len(name) > 0 {
+		p.Name = name
+	}
+	if len(displayName) > 0 {
+		p.DisplayName = displayName
+	}
+	if len(description) > 0 {
+		p.Description = description
+	}
+	c.profiles.p[id] = p
+	if c.profile.ID == id {
+		c.profile = p
+	}
+	return p, c.Save()
+}
+
+// DeleteProfile deletes the profile with the provided ID together
+// with its keys from the keyring and its collection file. If it is
+// the current profile, no profile is set as current.
+func (c *Configuration) DeleteProfile(id string) error {
+	if _, ok := c.profiles.p[id]; !ok {
+		return ErrProfileNotFound
+	}
+	if err := c.keyring.Delete(application, id); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if err := c.keyring.Delete(application, id+apiTokensSuffix); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if err := os.Remove(c.CollectionPath(id)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	delete(c.profiles.p, id)
+	if c.profile.ID == id {
+		c.profile = Profile{}
+		c.ProfileID = ""
+		c.keyringItem = keyringItem{}
+		c.storagePath = ""
+	}
+	return c.Save()
+}
+
 // SetPorofile sets profile on the configuration.
 func (c *Configuration) SetProfile(id string) error {
 	profile, ok := c.profiles.p[id]
 	if !ok {
-		return errors.New("profile with that ID does not exist")
+		return ErrProfileNotFound
 	}
 	c.profile = profile
 	c.ProfileID = profile.ID
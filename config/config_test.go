@@ -1,11 +1,13 @@
 package config
 
 import (
+	"bytes"
 	"os"
 	"os/user"
 	"path/filepath"
 	"testing"
 
+	"github.com/KarlGW/secman/internal/gob"
 	"github.com/KarlGW/secman/internal/security"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -36,12 +38,12 @@ func TestConfigure(t *testing.T) {
 			want: Configuration{
 				Username:  "user1",
 				ProfileID: "AAAA",
-				profile: profile{
+				profile: Profile{
 					ID:   "AAAA",
 					Name: "user1",
 				},
 				profiles: profiles{
-					p: map[string]profile{
+					p: map[string]Profile{
 						"AAAA": {
 							ID:   "AAAA",
 							Name: "user1",
@@ -77,7 +79,7 @@ func TestConfigure(t *testing.T) {
 			want: Configuration{
 				Username: "user2",
 				profiles: profiles{
-					p:    map[string]profile{},
+					p:    map[string]Profile{},
 					path: filepath.Join(user2Path, profilesFile),
 				},
 				path:    user2Path,
@@ -118,7 +120,7 @@ func TestConfigure(t *testing.T) {
 
 			got, gotErr := Configure(opts...)
 
-			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(Configuration{}, profiles{}, profile{}, keyringItem{}, mockKeyring{}), cmpopts.IgnoreFields(Configuration{}, "u")); diff != "" {
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(Configuration{}, profiles{}, Profile{}, keyringItem{}, mockKeyring{}), cmpopts.IgnoreFields(Configuration{}, "u")); diff != "" {
 				t.Errorf("Configure() = unexpected result (-want +got)\n%s\n", diff)
 			}
 
@@ -146,7 +148,7 @@ func TestConfiguration_SetStorageKey(t *testing.T) {
 				k security.Key
 			}{
 				c: Configuration{
-					profile: profile{
+					profile: Profile{
 						ID: "AAAA",
 					},
 					keyringItem: keyringItem{},
@@ -165,7 +167,7 @@ func TestConfiguration_SetStorageKey(t *testing.T) {
 				k security.Key
 			}{
 				c: Configuration{
-					profile: profile{
+					profile: Profile{
 						ID: "AAAA",
 					},
 					keyringItem: keyringItem{
@@ -216,7 +218,7 @@ func TestConfiguration_SetKey(t *testing.T) {
 				k security.Key
 			}{
 				c: Configuration{
-					profile: profile{
+					profile: Profile{
 						ID: "AAAA",
 					},
 					keyringItem: keyringItem{},
@@ -235,7 +237,7 @@ func TestConfiguration_SetKey(t *testing.T) {
 				k security.Key
 			}{
 				c: Configuration{
-					profile: profile{
+					profile: Profile{
 						ID: "AAAA",
 					},
 					keyringItem: keyringItem{
@@ -273,23 +275,26 @@ func TestConfiguration_ExportImport(t *testing.T) {
 	var tests = []struct {
 		name  string
 		input struct {
-			c          Configuration
-			encryptKey []byte
-			decryptKey []byte
+			c               Configuration
+			encryptPassword []byte
+			decryptPassword []byte
+			legacy          bool
+			tamper          func(b []byte) []byte
 		}
-		want    export
+		want    ProfileExport
 		wantErr error
-		after   func() error
 	}{
 		{
 			name: "export/import successful",
 			input: struct {
-				c          Configuration
-				encryptKey []byte
-				decryptKey []byte
+				c               Configuration
+				encryptPassword []byte
+				decryptPassword []byte
+				legacy          bool
+				tamper          func(b []byte) []byte
 			}{
 				c: Configuration{
-					profile: profile{
+					profile: Profile{
 						ID: "AAAA",
 					},
 					keyringItem: keyringItem{
@@ -297,12 +302,12 @@ func TestConfiguration_ExportImport(t *testing.T) {
 						StorageKey: security.Key{Value: []byte(`test2`)},
 					},
 				},
-				encryptKey: []byte(`test`),
-				decryptKey: []byte(`test`),
+				encryptPassword: []byte(`test`),
+				decryptPassword: []byte(`test`),
 			},
-			want: export{
+			want: ProfileExport{
 				Version: exportVersion,
-				Profile: profile{
+				Profile: Profile{
 					ID: "AAAA",
 				},
 				KeyringItem: keyringItem{
@@ -314,12 +319,14 @@ func TestConfiguration_ExportImport(t *testing.T) {
 		{
 			name: "import fails",
 			input: struct {
-				c          Configuration
-				encryptKey []byte
-				decryptKey []byte
+				c               Configuration
+				encryptPassword []byte
+				decryptPassword []byte
+				legacy          bool
+				tamper          func(b []byte) []byte
 			}{
 				c: Configuration{
-					profile: profile{
+					profile: Profile{
 						ID: "AAAA",
 					},
 					keyringItem: keyringItem{
@@ -327,11 +334,96 @@ func TestConfiguration_ExportImport(t *testing.T) {
 						StorageKey: security.Key{Value: []byte(`test2`)},
 					},
 				},
-				encryptKey: []byte(`test`),
-				decryptKey: []byte(`wrong`),
+				encryptPassword: []byte(`test`),
+				decryptPassword: []byte(`wrong`),
 			},
 			wantErr: security.ErrInvalidKey,
 		},
+		{
+			name: "import fails with modified header",
+			input: struct {
+				c               Configuration
+				encryptPassword []byte
+				decryptPassword []byte
+				legacy          bool
+				tamper          func(b []byte) []byte
+			}{
+				c: Configuration{
+					profile: Profile{
+						ID: "AAAA",
+					},
+					keyringItem: keyringItem{
+						Key:        security.Key{Value: []byte(`test1`)},
+						StorageKey: security.Key{Value: []byte(`test2`)},
+					},
+				},
+				encryptPassword: []byte(`test`),
+				decryptPassword: []byte(`test`),
+				tamper: func(b []byte) []byte {
+					return bytes.Replace(b, []byte(`{"format"`), []byte(`{ "format"`), 1)
+				},
+			},
+			wantErr: security.ErrInvalidKey,
+		},
+		{
+			name: "import fails with too high parameters",
+			input: struct {
+				c               Configuration
+				encryptPassword []byte
+				decryptPassword []byte
+				legacy          bool
+				tamper          func(b []byte) []byte
+			}{
+				c: Configuration{
+					profile: Profile{
+						ID: "AAAA",
+					},
+					keyringItem: keyringItem{
+						Key:        security.Key{Value: []byte(`test1`)},
+						StorageKey: security.Key{Value: []byte(`test2`)},
+					},
+				},
+				encryptPassword: []byte(`test`),
+				decryptPassword: []byte(`test`),
+				tamper: func(b []byte) []byte {
+					return bytes.Replace(b, []byte(`"memory":8192`), []byte(`"memory":4294967295`), 1)
+				},
+			},
+			wantErr: security.ErrInvalidParams,
+		},
+		{
+			name: "import legacy (format 1) export",
+			input: struct {
+				c               Configuration
+				encryptPassword []byte
+				decryptPassword []byte
+				legacy          bool
+				tamper          func(b []byte) []byte
+			}{
+				c: Configuration{
+					profile: Profile{
+						ID: "AAAA",
+					},
+					keyringItem: keyringItem{
+						Key:        security.Key{Value: []byte(`test1`)},
+						StorageKey: security.Key{Value: []byte(`test2`)},
+					},
+				},
+				encryptPassword: []byte(`test`),
+				decryptPassword: []byte(`test`),
+				legacy:          true,
+			},
+			want: ProfileExport{
+				Version: exportVersion,
+				Profile: Profile{
+					ID: "AAAA",
+				},
+				KeyringItem: keyringItem{
+					Key:        security.Key{Value: []byte(`test1`)},
+					StorageKey: security.Key{Value: []byte(`test2`)},
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -339,13 +431,27 @@ func TestConfiguration_ExportImport(t *testing.T) {
 			exportedPath := filepath.Join(user1Path, "export.sec")
 			t.Cleanup(func() {
 				_ = os.RemoveAll(exportedPath)
+				exportParams = originalExportParams
 			})
+			exportParams = security.Params{Time: 1, Memory: 8 * 1024, Threads: 1}
 
-			eKey, _ := security.NewSHA256FromPassword(test.input.encryptKey)
-			dKey, _ := security.NewSHA256FromPassword(test.input.decryptKey)
-
-			_ = test.input.c.Export(exportedPath, eKey)
-			got, gotErr := Import(exportedPath, dKey)
+			if test.input.legacy {
+				if err := legacyExport(test.input.c, exportedPath, test.input.encryptPassword); err != nil {
+					t.Fatalf("unexpected error in test: %v\n", err)
+				}
+			} else {
+				_ = test.input.c.Export(exportedPath, test.input.encryptPassword)
+			}
+			if test.input.tamper != nil {
+				b, err := os.ReadFile(exportedPath)
+				if err != nil {
+					t.Fatalf("unexpected error in test: %v\n", err)
+				}
+				if err := os.WriteFile(exportedPath, test.input.tamper(b), 0600); err != nil {
+					t.Fatalf("unexpected error in test: %v\n", err)
+				}
+			}
+			got, gotErr := Import(exportedPath, test.input.decryptPassword)
 
 			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(keyringItem{})); diff != "" {
 				t.Errorf("Export()/Import() = unexpected result (-want +got)\n%s\n", diff)
@@ -358,6 +464,28 @@ func TestConfiguration_ExportImport(t *testing.T) {
 	}
 }
 
+// legacyExport exports the configuration in format 1, encrypted with
+// an unsalted SHA256 of the password.
+func legacyExport(c Configuration, dst string, password []byte) error {
+	b, err := gob.Encode(ProfileExport{
+		Version:     exportVersion,
+		KeyringItem: c.keyringItem,
+		Profile:     c.profile,
+	})
+	if err != nil {
+		return err
+	}
+	key, err := security.NewSHA256FromPassword(password)
+	if err != nil {
+		return err
+	}
+	encrypted, err := security.Encrypt(b, key)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, encrypted, 0600)
+}
+
 func TestConfiguration_NewProfile(t *testing.T) {
 	var tests = []struct {
 		name  string
@@ -367,7 +495,7 @@ func TestConfiguration_NewProfile(t *testing.T) {
 		}
 		want struct {
 			config  Configuration
-			profile profile
+			profile Profile
 		}
 		wantErr error
 		before  func() error
@@ -382,16 +510,16 @@ func TestConfiguration_NewProfile(t *testing.T) {
 			},
 			want: struct {
 				config  Configuration
-				profile profile
+				profile Profile
 			}{
 				config: Configuration{
 					ProfileID: "CCCC",
-					profile: profile{
+					profile: Profile{
 						ID:   "CCCC",
 						Name: "user3",
 					},
 					profiles: profiles{
-						p: map[string]profile{
+						p: map[string]Profile{
 							"CCCC": {
 								ID:   "CCCC",
 								Name: "user3",
@@ -405,7 +533,7 @@ func TestConfiguration_NewProfile(t *testing.T) {
 					},
 					keyring: &mockKeyring{},
 				},
-				profile: profile{
+				profile: Profile{
 					ID:   "CCCC",
 					Name: "user3",
 				},
@@ -428,16 +556,16 @@ func TestConfiguration_NewProfile(t *testing.T) {
 			},
 			want: struct {
 				config  Configuration
-				profile profile
+				profile Profile
 			}{
 				config: Configuration{
 					ProfileID: "CCCC",
-					profile: profile{
+					profile: Profile{
 						ID:   "CCCC",
 						Name: "user3",
 					},
 					profiles: profiles{
-						p: map[string]profile{
+						p: map[string]Profile{
 							"CCCC": {
 								ID:   "CCCC",
 								Name: "user3",
@@ -451,7 +579,7 @@ func TestConfiguration_NewProfile(t *testing.T) {
 					},
 					keyring: &mockKeyring{},
 				},
-				profile: profile{
+				profile: Profile{
 					ID:   "CCCC",
 					Name: "user3",
 				},
@@ -484,11 +612,11 @@ func TestConfiguration_NewProfile(t *testing.T) {
 
 			gotProfile, gotErr := gotConfig.NewProfile(test.input.name, test.input.password)
 
-			if diff := cmp.Diff(test.want.config, gotConfig, cmp.AllowUnexported(Configuration{}, profiles{}, profile{}, keyringItem{}, mockKeyring{}), cmpopts.IgnoreFields(mockKeyring{}, "data"), cmpopts.IgnoreFields(keyringItem{}, "Key", "StorageKey")); diff != "" {
+			if diff := cmp.Diff(test.want.config, gotConfig, cmp.AllowUnexported(Configuration{}, profiles{}, Profile{}, keyringItem{}, mockKeyring{}), cmpopts.IgnoreFields(mockKeyring{}, "data"), cmpopts.IgnoreFields(keyringItem{}, "Key", "StorageKey")); diff != "" {
 				t.Errorf("NewProfile() = unexpected result (-want +got)\n%s\n", diff)
 			}
 
-			if diff := cmp.Diff(test.want.profile, gotProfile, cmp.AllowUnexported(profile{})); diff != "" {
+			if diff := cmp.Diff(test.want.profile, gotProfile, cmp.AllowUnexported(Profile{})); diff != "" {
 				t.Errorf("NewProfile() = unexpected result (-want +got)\n%s\n", diff)
 			}
 
@@ -528,4 +656,145 @@ func (m *mockKeyring) Set(service, user, password string) error {
 	return nil
 }
 
-var originalUUID = newUUID
+func (m *mockKeyring) Delete(service, user string) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	if _, ok := m.data[user]; !ok {
+		return ErrNotFound
+	}
+	delete(m.data, user)
+	return nil
+}
+
+var (
+	originalUUID         = newUUID
+	originalExportParams = exportParams
+)
+
+func TestConfiguration_FindProfile(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   string
+		want    Profile
+		wantErr error
+	}{
+		{
+			name:  "find by ID",
+			input: "AAAA",
+			want:  Profile{ID: "AAAA", Name: "user1"},
+		},
+		{
+			name:  "find by name",
+			input: "user2",
+			want:  Profile{ID: "BBBB", Name: "user2"},
+		},
+		{
+			name:    "not found",
+			input:   "user4",
+			wantErr: ErrProfileNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := Configuration{
+				profiles: profiles{
+					p: map[string]Profile{
+						"AAAA": {ID: "AAAA", Name: "user1"},
+						"BBBB": {ID: "BBBB", Name: "user2"},
+					},
+				},
+			}
+
+			got, gotErr := c.FindProfile(test.input)
+
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(Profile{})); diff != "" {
+				t.Errorf("FindProfile() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("FindProfile() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestConfiguration_DeleteProfile(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   string
+		want    Configuration
+		wantErr error
+	}{
+		{
+			name:  "delete current profile",
+			input: "CCCC",
+			want: Configuration{
+				profiles: profiles{
+					p:    map[string]Profile{},
+					path: filepath.Join(user3Path, profilesFile),
+				},
+				path:    user3Path,
+				keyring: &mockKeyring{data: map[string]string{}},
+			},
+		},
+		{
+			name:  "profile does not exist",
+			input: "DDDD",
+			want: Configuration{
+				ProfileID: "CCCC",
+				profile:   Profile{ID: "CCCC", Name: "user3"},
+				profiles: profiles{
+					p:    map[string]Profile{"CCCC": {ID: "CCCC", Name: "user3"}},
+					path: filepath.Join(user3Path, profilesFile),
+				},
+				path:        user3Path,
+				storagePath: filepath.Join(user3Path, "collections", "CCCC"+storageFileSuffix),
+				keyring:     &mockKeyring{data: map[string]string{"CCCC": "{}"}},
+			},
+			wantErr: ErrProfileNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Cleanup(func() {
+				_ = os.RemoveAll(user3Path)
+			})
+
+			c := Configuration{
+				ProfileID: "CCCC",
+				profile:   Profile{ID: "CCCC", Name: "user3"},
+				profiles: profiles{
+					p:    map[string]Profile{"CCCC": {ID: "CCCC", Name: "user3"}},
+					path: filepath.Join(user3Path, profilesFile),
+				},
+				path:        user3Path,
+				storagePath: filepath.Join(user3Path, "collections", "CCCC"+storageFileSuffix),
+				keyring:     &mockKeyring{data: map[string]string{"CCCC": "{}"}},
+			}
+			if err := os.MkdirAll(filepath.Join(user3Path, "collections"), 0700); err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+			if err := os.WriteFile(c.storagePath, []byte(`data`), 0600); err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+
+			gotErr := c.DeleteProfile(test.input)
+
+			if diff := cmp.Diff(test.want, c, cmp.AllowUnexported(Configuration{}, profiles{}, Profile{}, keyringItem{}, mockKeyring{})); diff != "" {
+				t.Errorf("DeleteProfile() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("DeleteProfile() = unexpected error (-want +got)\n%s\n", diff)
+			}
+
+			if _, err := os.Stat(c.CollectionPath("CCCC")); test.wantErr == nil && err == nil {
+				t.Errorf("DeleteProfile() = collection file was not removed\n")
+			}
+		})
+	}
+}
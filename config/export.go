@@ -1,8 +1,13 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"strings"
 
+	"github.com/KarlGW/secman/internal/security"
 	"github.com/KarlGW/secman/version"
 )
 
@@ -10,16 +15,123 @@ var (
 	// exportVersion is the major version of the CLI used to
 	// create the export.
 	exportVersion = strings.Split(version.Version(), ".")[0]
+	// exportParams are the argon2id parameters used for deriving
+	// the key of export files from a password.
+	exportParams = security.Params{Time: 3, Memory: 128 * 1024, Threads: 4}
 )
 
-// export is an exported profile and keys.
-type export struct {
-	Profile     profile
+var (
+	// ErrInvalidExport is returned when an export file cannot be read.
+	ErrInvalidExport = errors.New("invalid export file")
+)
+
+const (
+	// exportFormat is the current format of export files. Files without
+	// a header are of format 1, where the key is an unsalted SHA256
+	// of the password. From format 2 the header is authenticated as
+	// additional data of the encryption.
+	exportFormat = 2
+)
+
+var (
+	// exportMagic is the start of the header of export files.
+	exportMagic = []byte("SECMAN-EXPORT/")
+)
+
+// ProfileExport is an exported profile and keys, and optionally
+// the encrypted collection of the profile.
+type ProfileExport struct {
+	Profile     Profile
 	KeyringItem keyringItem
 	Version     string
+	// Collection is the collection file of the profile, encrypted
+	// with the storage key. Empty if not exported.
+	Collection []byte
 }
 
 // Valid returns true if the exported file is valid.
-func (e export) Valid() bool {
+func (e ProfileExport) Valid() bool {
 	return len(e.Profile.ID) > 0 && e.KeyringItem.Valid()
 }
+
+// ExportOptions contains options for exporting a profile.
+type ExportOptions struct {
+	Collection bool
+}
+
+// ExportOption is a function that sets ExportOptions.
+type ExportOption func(o *ExportOptions)
+
+// WithCollection sets that the collection of the profile should be
+// included in the export.
+func WithCollection() ExportOption {
+	return func(o *ExportOptions) {
+		o.Collection = true
+	}
+}
+
+// exportHeader is the header of an export file. It contains what
+// is needed to derive the key of the file from a password.
+type exportHeader struct {
+	Format int             `json:"format"`
+	Salt   []byte          `json:"salt"`
+	Params security.Params `json:"params"`
+	// raw is the header as read from the file.
+	raw []byte
+}
+
+// encode the header.
+// Format: SECMAN-EXPORT/<format> <json>\n
+func (h exportHeader) encode() []byte {
+	b, _ := json.Marshal(h)
+	return []byte(fmt.Sprintf("%s%d %s\n", exportMagic, h.Format, b))
+}
+
+// decodeExportHeader decodes the header from the provided data and returns
+// it together with the remaining data. If the data has no header, a header
+// of format 1 is returned.
+func decodeExportHeader(b []byte) (exportHeader, []byte, error) {
+	if !bytes.HasPrefix(b, exportMagic) {
+		return exportHeader{Format: 1}, b, nil
+	}
+	line, rest, ok := bytes.Cut(b, []byte("\n"))
+	if !ok {
+		return exportHeader{}, nil, ErrInvalidExport
+	}
+	_, encoded, ok := bytes.Cut(line, []byte(" "))
+	if !ok {
+		return exportHeader{}, nil, ErrInvalidExport
+	}
+
+	var header exportHeader
+	if err := json.Unmarshal(encoded, &header); err != nil {
+		return exportHeader{}, nil, fmt.Errorf("%w: %w", ErrInvalidExport, err)
+	}
+	if header.Format > exportFormat {
+		return exportHeader{}, nil, fmt.Errorf("%w: unsupported format %d", ErrInvalidExport, header.Format)
+	}
+	if err := header.Params.Validate(); err != nil {
+		return exportHeader{}, nil, fmt.Errorf("%w: %w", ErrInvalidExport, err)
+	}
+	header.raw = b[:len(line)+1]
+	return header, rest, nil
+}
+
+// exportKey derives the key of an export file from the password
+// and header. The parameters of the header are validated when the
+// header is decoded.
+func exportKey(password []byte, header exportHeader) ([]byte, error) {
+	if header.Format < 2 {
+		return security.NewSHA256FromPassword(password)
+	}
+	return security.DeriveKey(password, header.Salt, header.Params)
+}
+
+// decryptExport decrypts the data of an export file with the key. From
+// format 2 the header is authenticated.
+func decryptExport(b, key []byte, header exportHeader) ([]byte, error) {
+	if header.Format < 2 {
+		return security.Decrypt(b, key)
+	}
+	return security.DecryptWithAdditionalData(b, key, header.raw)
+}
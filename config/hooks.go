@@ -0,0 +1,31 @@
+package config
+
+import "errors"
+
+// Hook is an executable that is run before or after operations that
+// change the secrets of a profile.
+type Hook struct {
+	Name string `yaml:"name"`
+	// Stage is pre or post.
+	Stage string `yaml:"stage"`
+	// Operations the hook is run for. If empty the hook is run
+	// for all operations.
+	Operations []string `yaml:"operations,omitempty"`
+	Command    string   `yaml:"command"`
+	Args       []string `yaml:"args,omitempty"`
+}
+
+// Hooks returns the hooks of the current profile.
+func (c Configuration) Hooks() []Hook {
+	return c.profile.Hooks
+}
+
+// SetHooks sets the hooks of the current profile.
+func (c *Configuration) SetHooks(hooks []Hook) error {
+	if len(c.profile.ID) == 0 {
+		return errors.New("no profile set")
+	}
+	c.profile.Hooks = hooks
+	c.profiles.p[c.profile.ID] = c.profile
+	return c.Save()
+}
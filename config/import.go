@@ -4,22 +4,32 @@ import (
 	"os"
 
 	"github.com/KarlGW/secman/internal/gob"
-	"github.com/KarlGW/secman/internal/security"
 )
 
-// Import importable configuration and profile from file.
-func Import(src string, key []byte) (export, error) {
+// Import importable configuration and profile from file. The key of the
+// file is derived from the provided password. Files of earlier formats
+// are read according to their format.
+func Import(src string, password []byte) (ProfileExport, error) {
 	b, err := os.ReadFile(src)
 	if err != nil {
-		return export{}, err
+		return ProfileExport{}, err
 	}
-	decrypted, err := security.Decrypt(b, key)
+	header, b, err := decodeExportHeader(b)
 	if err != nil {
-		return export{}, err
+		return ProfileExport{}, err
 	}
-	var exported export
+	key, err := exportKey(password, header)
+	if err != nil {
+		return ProfileExport{}, err
+	}
+
+	decrypted, err := decryptExport(b, key, header)
+	if err != nil {
+		return ProfileExport{}, err
+	}
+	var exported ProfileExport
 	if err := gob.Decode(decrypted, &exported); err != nil {
-		return export{}, err
+		return ProfileExport{}, err
 	}
 	return exported, nil
 }
@@ -11,10 +11,11 @@ var (
 	ErrNotFound = kr.ErrNotFound
 )
 
-// keyringer is the interface that wraps around method Get and set.
+// keyringer is the interface that wraps around method Get, Set and Delete.
 type keyringer interface {
 	Get(string, string) (string, error)
 	Set(string, string, string) error
+	Delete(string, string) error
 }
 
 // keyring satisfies keyringer.
@@ -30,6 +31,11 @@ func (k keyring) Set(service, user, password string) error {
 	return kr.Set(service, user, password)
 }
 
+// Delete value from keyring.
+func (k keyring) Delete(service, user string) error {
+	return kr.Delete(service, user)
+}
+
 // keyringItem contains a key (password) for encryption of secrets and
 // a storageKey for encrypting the secret collection file.
 type keyringItem struct {
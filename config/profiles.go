@@ -5,23 +5,34 @@ import (
 	"errors"
 	"io"
 	"os"
+	"time"
 
 	"github.com/KarlGW/secman/internal/filesystem"
+	"github.com/KarlGW/secman/internal/security"
 	"github.com/google/uuid"
 	"gopkg.in/yaml.v3"
 )
 
-// profile contains information of a user profile.
-type profile struct {
+// Profile contains information of a user profile.
+type Profile struct {
 	ID          string `yaml:"id"`
 	Name        string `yaml:"name"`
 	DisplayName string `yaml:"displayName,omitempty"`
 	Description string `yaml:"description,omitempty"`
+	// KDF contains the argon2id parameters for deriving the key
+	// from the password. If not set, the default parameters are used.
+	KDF security.Params `yaml:"kdf,omitempty"`
+	// ClipboardTimeout is the duration before the clipboard is cleared
+	// after a secret value has been copied to it. If not set, the
+	// default timeout is used. 0 disables clearing.
+	ClipboardTimeout *time.Duration `yaml:"clipboardTimeout,omitempty"`
+	// Hooks are run before and after operations that change secrets.
+	Hooks []Hook `yaml:"hooks,omitempty"`
 }
 
 // profile contains profiles.
 type profiles struct {
-	p    map[string]profile
+	p    map[string]Profile
 	path string
 }
 
@@ -51,7 +62,7 @@ func (p *profiles) Load() error {
 	}
 
 	if p.p == nil {
-		p.p = make(map[string]profile)
+		p.p = make(map[string]Profile)
 	}
 
 	b, err := io.ReadAll(file)
@@ -77,22 +88,22 @@ func (p profiles) Save() error {
 }
 
 // Get a profile.
-func (p profiles) Get(id string) profile {
+func (p profiles) Get(id string) Profile {
 	return p.p[id]
 }
 
 // newProfile creates and adds a new profile.
-func (p *profiles) newProfile(name string) (profile, error) {
+func (p *profiles) newProfile(name string) (Profile, error) {
 	if p.p == nil {
-		p.p = make(map[string]profile)
+		p.p = make(map[string]Profile)
 	}
 
-	_profile := profile{
+	_profile := Profile{
 		ID:   newUUID(),
 		Name: name,
 	}
 	if _, ok := p.p[_profile.ID]; ok {
-		return profile{}, errors.New("a profile with that ID already exists")
+		return Profile{}, errors.New("a profile with that ID already exists")
 	}
 	p.p[_profile.ID] = _profile
 	return p.p[_profile.ID], nil
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// apiTokensSuffix is the suffix of the keyring user of the API tokens
+// of a profile.
+const apiTokensSuffix = ":api-tokens"
+
+// APIToken is a token of a client of the API server. Only the hash
+// of the token is stored.
+type APIToken struct {
+	Name     string    `json:"name"`
+	Hash     string    `json:"hash"`
+	ReadOnly bool      `json:"readOnly,omitempty"`
+	Created  time.Time `json:"created"`
+}
+
+// APITokens returns the API tokens of the current profile from
+// the keyring.
+func (c Configuration) APITokens() ([]APIToken, error) {
+	val, err := c.keyring.Get(application, c.profile.ID+apiTokensSuffix)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var tokens []APIToken
+	if err := json.Unmarshal([]byte(val), &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// SetAPITokens sets the API tokens of the current profile to the
+// keyring. The entry is removed if there are no tokens.
+func (c Configuration) SetAPITokens(tokens []APIToken) error {
+	if len(tokens) == 0 {
+		if err := c.keyring.Delete(application, c.profile.ID+apiTokensSuffix); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		return nil
+	}
+	b, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+	return c.keyring.Set(application, c.profile.ID+apiTokensSuffix, string(b))
+}
@@ -0,0 +1,112 @@
+// Package credential provides credential helpers for git, docker and
+// other tools, backed by credential secrets.
+package credential
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"slices"
+
+	"github.com/KarlGW/secman/secret"
+)
+
+const (
+	// TagURL is the tag containing the URL of a credential secret.
+	TagURL = "url"
+)
+
+// Handler is the interface that wraps around the methods of
+// secret.Handler used for credentials.
+type Handler interface {
+	ListSecrets() (secret.Secrets, error)
+	GetSecretByName(name string) (secret.Secret, error)
+	AddSecret(name, value string, options ...secret.SecretOption) (secret.Secret, error)
+	UpdateSecretByID(id string, options ...secret.SecretOption) (secret.Secret, error)
+	DeleteSecretByID(id string) error
+}
+
+// Credential contains the fields of the value of a credential secret.
+type Credential struct {
+	URL      string `json:"url,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// Entry is a credential and the secret it is stored in.
+type Entry struct {
+	Secret     secret.Secret
+	Credential Credential
+}
+
+// List the credentials of the credential secrets with the provided
+// label. If label is empty all credential secrets are listed. Secrets
+// with values that are not structured are skipped. If the URL field
+// is not set the URL tag of the secret is used.
+func List(handler Handler, label string) ([]Entry, error) {
+	secrets, err := handler.ListSecrets()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, s := range secrets {
+		if s.Type != secret.TypeCredential || (len(label) > 0 && !slices.Contains(s.Labels, label)) {
+			continue
+		}
+		fields, err := s.Fields()
+		if err != nil {
+			if errors.Is(err, secret.ErrNotStructured) {
+				continue
+			}
+			return nil, err
+		}
+		c := Credential{
+			URL:      fields["url"],
+			Username: fields["username"],
+			Password: fields["password"],
+		}
+		if len(c.URL) == 0 {
+			c.URL = s.Tags[TagURL]
+		}
+		entries = append(entries, Entry{Secret: s, Credential: c})
+	}
+	return entries, nil
+}
+
+// Save the credential to the credential secret with the provided name.
+// The secret is created with the label if it does not exist.
+func Save(handler Handler, name string, c Credential, label string) error {
+	value, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	s, err := handler.GetSecretByName(name)
+	if err != nil && !errors.Is(err, secret.ErrSecretNotFound) {
+		return err
+	}
+	if errors.Is(err, secret.ErrSecretNotFound) {
+		_, err = handler.AddSecret(
+			name,
+			string(value),
+			secret.WithType(secret.TypeCredential),
+			secret.WithLabels(label),
+			secret.WithTags(map[string]string{TagURL: c.URL}),
+		)
+		return err
+	}
+
+	tags := maps.Clone(s.Tags)
+	if tags == nil {
+		tags = make(map[string]string)
+	}
+	tags[TagURL] = c.URL
+	_, err = handler.UpdateSecretByID(
+		s.ID,
+		secret.WithValue(value),
+		secret.WithType(secret.TypeCredential),
+		secret.WithTags(tags),
+	)
+	return err
+}
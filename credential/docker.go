@@ -0,0 +1,112 @@
+package credential
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/KarlGW/secman/secret"
+)
+
+const (
+	// LabelDocker is the label of credential secrets stored by the
+	// docker credential helper.
+	LabelDocker = "docker"
+)
+
+var (
+	// ErrCredentialsNotFound is returned when no credentials are found
+	// for a server URL. The message is the one expected by docker.
+	ErrCredentialsNotFound = errors.New("credentials not found in native keychain")
+)
+
+// Docker is the credentials of the docker credential helper protocol.
+type Docker struct {
+	ServerURL string `json:"ServerURL"`
+	Username  string `json:"Username"`
+	Secret    string `json:"Secret"`
+}
+
+// DockerGet returns the credentials stored by the docker credential
+// helper for the server URL. Credentials with the exact server URL are
+// preferred over those with the same host.
+func DockerGet(handler Handler, serverURL string) (Docker, error) {
+	entries, err := List(handler, LabelDocker)
+	if err != nil {
+		return Docker{}, err
+	}
+
+	var match *Entry
+	for i, entry := range entries {
+		if entry.Credential.URL == serverURL {
+			match = &entries[i]
+			break
+		}
+		if match == nil && dockerHost(entry.Credential.URL) == dockerHost(serverURL) {
+			match = &entries[i]
+		}
+	}
+	if match == nil {
+		return Docker{}, ErrCredentialsNotFound
+	}
+	return Docker{ServerURL: serverURL, Username: match.Credential.Username, Secret: match.Credential.Password}, nil
+}
+
+// DockerStore stores the credentials as a credential secret.
+func DockerStore(handler Handler, d Docker) error {
+	if len(d.ServerURL) == 0 {
+		return errors.New("a server URL must be provided")
+	}
+	return Save(handler, "docker:"+d.ServerURL, Credential{URL: d.ServerURL, Username: d.Username, Password: d.Secret}, LabelDocker)
+}
+
+// DockerErase deletes the credentials stored for the server URL.
+func DockerErase(handler Handler, serverURL string) error {
+	entries, err := List(handler, LabelDocker)
+	if err != nil {
+		return err
+	}
+	var found bool
+	var hookErrs []error
+	for _, entry := range entries {
+		if entry.Credential.URL != serverURL {
+			continue
+		}
+		if err := handler.DeleteSecretByID(entry.Secret.ID); err != nil {
+			// The secret is deleted even if a post-hook fails.
+			if !errors.Is(err, secret.ErrPostHook) {
+				return err
+			}
+			hookErrs = append(hookErrs, err)
+		}
+		found = true
+	}
+	if !found {
+		return ErrCredentialsNotFound
+	}
+	return errors.Join(hookErrs...)
+}
+
+// DockerList returns the server URLs and usernames of the credentials
+// stored by the docker credential helper.
+func DockerList(handler Handler) (map[string]string, error) {
+	entries, err := List(handler, LabelDocker)
+	if err != nil {
+		return nil, err
+	}
+	list := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		list[entry.Credential.URL] = entry.Credential.Username
+	}
+	return list, nil
+}
+
+// dockerHost returns the host of a server URL, which may be provided
+// with or without scheme and path.
+func dockerHost(serverURL string) string {
+	host := serverURL
+	if _, rest, ok := strings.Cut(host, "://"); ok {
+		host = rest
+	}
+	host, _, _ = strings.Cut(host, "/")
+	return strings.ToLower(host)
+}
@@ -0,0 +1,76 @@
+package credential
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestDocker(t *testing.T) {
+	handler := setupHandler(t)
+	for _, d := range []Docker{
+		{ServerURL: "https://index.docker.io/v1/", Username: "user", Secret: "docker-token"},
+		{ServerURL: "ghcr.io", Username: "octocat", Secret: "ghcr-token"},
+	} {
+		if err := DockerStore(handler, d); err != nil {
+			t.Fatalf("DockerStore() = unexpected error: %v\n", err)
+		}
+	}
+	if err := Save(handler, "gitlab", Credential{URL: "https://gitlab.example.com", Username: "user", Password: "password"}, LabelGit); err != nil {
+		t.Fatalf("Save() = unexpected error: %v\n", err)
+	}
+
+	var tests = []struct {
+		name    string
+		input   string
+		want    Docker
+		wantErr error
+	}{
+		{
+			name:  "server URL",
+			input: "https://index.docker.io/v1/",
+			want:  Docker{ServerURL: "https://index.docker.io/v1/", Username: "user", Secret: "docker-token"},
+		},
+		{
+			name:  "host",
+			input: "https://ghcr.io",
+			want:  Docker{ServerURL: "https://ghcr.io", Username: "octocat", Secret: "ghcr-token"},
+		},
+		{
+			name:    "not found",
+			input:   "registry.example.com",
+			wantErr: ErrCredentialsNotFound,
+		},
+		{
+			name:    "host of credential not stored by docker",
+			input:   "gitlab.example.com",
+			wantErr: ErrCredentialsNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := DockerGet(handler, test.input)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("DockerGet() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("DockerGet() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+
+	t.Run("erase and list", func(t *testing.T) {
+		if err := DockerErase(handler, "ghcr.io"); err != nil {
+			t.Fatalf("DockerErase() = unexpected error: %v\n", err)
+		}
+		got, _ := DockerList(handler)
+
+		if diff := cmp.Diff(map[string]string{"https://index.docker.io/v1/": "user"}, got); diff != "" {
+			t.Errorf("DockerList() = unexpected result (-want +got)\n%s\n", diff)
+		}
+	})
+}
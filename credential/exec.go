@@ -0,0 +1,135 @@
+package credential
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/KarlGW/secman/secret"
+)
+
+// Fields of structured secrets used for exec credentials. Each field
+// can be provided under any of its names.
+var (
+	fieldAccessKeyID     = []string{"access_key_id", "aws_access_key_id", "AccessKeyId"}
+	fieldSecretAccessKey = []string{"secret_access_key", "aws_secret_access_key", "SecretAccessKey"}
+	fieldSessionToken    = []string{"session_token", "aws_session_token", "SessionToken"}
+	fieldExpiration      = []string{"expiration", "Expiration", "expirationTimestamp"}
+	fieldToken           = []string{"token"}
+	fieldClientCert      = []string{"client_certificate_data", "clientCertificateData"}
+	fieldClientKey       = []string{"client_key_data", "clientKeyData"}
+)
+
+// Kubernetes API versions of ExecCredential.
+const (
+	KubernetesV1      = "client.authentication.k8s.io/v1"
+	KubernetesV1beta1 = "client.authentication.k8s.io/v1beta1"
+)
+
+// AWS is the credentials expected by AWS SDKs from a credential_process.
+type AWS struct {
+	Version         int    `json:"Version"`
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	SessionToken    string `json:"SessionToken,omitempty"`
+	Expiration      string `json:"Expiration,omitempty"`
+}
+
+// NewAWS creates AWS credentials from the fields of a structured
+// secret. The fields access_key_id and secret_access_key are required,
+// session_token and expiration (RFC3339) are optional.
+func NewAWS(s secret.Secret) (AWS, error) {
+	fields, err := s.Fields()
+	if err != nil {
+		return AWS{}, err
+	}
+	aws := AWS{
+		Version:         1,
+		AccessKeyID:     field(fields, fieldAccessKeyID),
+		SecretAccessKey: field(fields, fieldSecretAccessKey),
+		SessionToken:    field(fields, fieldSessionToken),
+	}
+	if len(aws.AccessKeyID) == 0 || len(aws.SecretAccessKey) == 0 {
+		return AWS{}, fmt.Errorf("%w: access_key_id and secret_access_key in %s", secret.ErrFieldNotFound, s.Name)
+	}
+	if aws.Expiration, err = expiration(fields); err != nil {
+		return AWS{}, err
+	}
+	return aws, nil
+}
+
+// ExecCredential is the Kubernetes ExecCredential expected from
+// a client-go credential plugin.
+type ExecCredential struct {
+	APIVersion string               `json:"apiVersion"`
+	Kind       string               `json:"kind"`
+	Status     ExecCredentialStatus `json:"status"`
+}
+
+// ExecCredentialStatus is the status of an ExecCredential.
+type ExecCredentialStatus struct {
+	Token                 string `json:"token,omitempty"`
+	ClientCertificateData string `json:"clientCertificateData,omitempty"`
+	ClientKeyData         string `json:"clientKeyData,omitempty"`
+	ExpirationTimestamp   string `json:"expirationTimestamp,omitempty"`
+}
+
+// NewExecCredential creates a Kubernetes ExecCredential from a secret.
+// The fields of a structured secret are token, client_certificate_data,
+// client_key_data and expiration (RFC3339). If the value of the secret
+// is not structured it is used as the token.
+func NewExecCredential(s secret.Secret, apiVersion string) (ExecCredential, error) {
+	if apiVersion != KubernetesV1 && apiVersion != KubernetesV1beta1 {
+		return ExecCredential{}, fmt.Errorf("unsupported API version: %s", apiVersion)
+	}
+	credential := ExecCredential{APIVersion: apiVersion, Kind: "ExecCredential"}
+
+	fields, err := s.Fields()
+	if errors.Is(err, secret.ErrNotStructured) {
+		token, err := s.Decrypt()
+		if err != nil {
+			return ExecCredential{}, err
+		}
+		credential.Status.Token = string(token)
+		return credential, nil
+	}
+	if err != nil {
+		return ExecCredential{}, err
+	}
+
+	credential.Status = ExecCredentialStatus{
+		Token:                 field(fields, fieldToken),
+		ClientCertificateData: field(fields, fieldClientCert),
+		ClientKeyData:         field(fields, fieldClientKey),
+	}
+	if len(credential.Status.Token) == 0 && (len(credential.Status.ClientCertificateData) == 0 || len(credential.Status.ClientKeyData) == 0) {
+		return ExecCredential{}, fmt.Errorf("%w: token or client_certificate_data and client_key_data in %s", secret.ErrFieldNotFound, s.Name)
+	}
+	if credential.Status.ExpirationTimestamp, err = expiration(fields); err != nil {
+		return ExecCredential{}, err
+	}
+	return credential, nil
+}
+
+// field returns the value of the first of the names found in fields.
+func field(fields map[string]string, names []string) string {
+	for _, name := range names {
+		if value, ok := fields[name]; ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// expiration returns the expiration field formatted as RFC3339.
+func expiration(fields map[string]string) (string, error) {
+	value := field(fields, fieldExpiration)
+	if len(value) == 0 {
+		return "", nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return "", fmt.Errorf("invalid expiration: %w", err)
+	}
+	return t.UTC().Format(time.RFC3339), nil
+}
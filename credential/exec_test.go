@@ -0,0 +1,110 @@
+package credential
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/KarlGW/secman/secret"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestNewAWS(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   string
+		want    AWS
+		wantErr error
+	}{
+		{
+			name:  "all fields",
+			input: `{"access_key_id":"AKID","secret_access_key":"SAK","session_token":"ST","expiration":"2023-08-01T12:00:00+02:00"}`,
+			want:  AWS{Version: 1, AccessKeyID: "AKID", SecretAccessKey: "SAK", SessionToken: "ST", Expiration: "2023-08-01T10:00:00Z"},
+		},
+		{
+			name:  "AWS field names",
+			input: `{"AccessKeyId":"AKID","SecretAccessKey":"SAK"}`,
+			want:  AWS{Version: 1, AccessKeyID: "AKID", SecretAccessKey: "SAK"},
+		},
+		{
+			name:    "missing fields",
+			input:   `{"access_key_id":"AKID"}`,
+			wantErr: secret.ErrFieldNotFound,
+		},
+		{
+			name:    "not structured",
+			input:   `value`,
+			wantErr: secret.ErrNotStructured,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := NewAWS(newTestSecret(t, test.input))
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("NewAWS() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("NewAWS() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestNewExecCredential(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   string
+		want    ExecCredential
+		wantErr error
+	}{
+		{
+			name:  "token",
+			input: `{"token":"abc","expiration":"2023-08-01T10:00:00Z"}`,
+			want: ExecCredential{
+				APIVersion: KubernetesV1,
+				Kind:       "ExecCredential",
+				Status:     ExecCredentialStatus{Token: "abc", ExpirationTimestamp: "2023-08-01T10:00:00Z"},
+			},
+		},
+		{
+			name:  "not structured",
+			input: `abc`,
+			want: ExecCredential{
+				APIVersion: KubernetesV1,
+				Kind:       "ExecCredential",
+				Status:     ExecCredentialStatus{Token: "abc"},
+			},
+		},
+		{
+			name:    "missing fields",
+			input:   `{"client_key_data":"key"}`,
+			wantErr: secret.ErrFieldNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := NewExecCredential(newTestSecret(t, test.input), KubernetesV1)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("NewExecCredential() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("NewExecCredential() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func newTestSecret(t *testing.T, value string) secret.Secret {
+	t.Helper()
+	s, err := secret.NewSecret("secret", value, bytes.Repeat([]byte{1}, secret.KeyLength))
+	if err != nil {
+		t.Fatalf("NewSecret() = unexpected error: %v\n", err)
+	}
+	return s
+}
@@ -0,0 +1,197 @@
+package credential
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/KarlGW/secman/secret"
+)
+
+const (
+	// LabelGit is the label of credential secrets stored by the
+	// git credential helper.
+	LabelGit = "git"
+)
+
+// Git is a credential description of the git credential helper
+// protocol.
+type Git struct {
+	Protocol string
+	Host     string
+	Path     string
+	Username string
+	Password string
+}
+
+// ReadGit reads a credential description from r. The description
+// consists of lines of key=value pairs ended by an empty line or EOF.
+// Unknown keys are ignored.
+func ReadGit(r io.Reader) (Git, error) {
+	var g Git
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if len(line) == 0 {
+			break
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return Git{}, fmt.Errorf("invalid line in credential description: %q", line)
+		}
+		switch key {
+		case "protocol":
+			g.Protocol = value
+		case "host":
+			g.Host = value
+		case "path":
+			g.Path = value
+		case "username":
+			g.Username = value
+		case "password":
+			g.Password = value
+		case "url":
+			u, err := url.Parse(value)
+			if err != nil {
+				return Git{}, err
+			}
+			g.Protocol, g.Host, g.Path = u.Scheme, u.Host, strings.TrimPrefix(u.Path, "/")
+			if u.User != nil {
+				g.Username = u.User.Username()
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Git{}, err
+	}
+	return g, nil
+}
+
+// Write the username and password of the credential description to w.
+func (g Git) Write(w io.Writer) error {
+	var b strings.Builder
+	if len(g.Username) > 0 {
+		b.WriteString("username=" + g.Username + "\n")
+	}
+	if len(g.Password) > 0 {
+		b.WriteString("password=" + g.Password + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// URL returns the URL of the credential description without
+// the username.
+func (g Git) URL() string {
+	u := url.URL{Scheme: g.Protocol, Host: g.Host}
+	if len(g.Path) > 0 {
+		u.Path = "/" + g.Path
+	}
+	return u.String()
+}
+
+// name returns the name of the secret for the credential description.
+func (g Git) name() string {
+	u := url.URL{Scheme: g.Protocol, Host: g.Host}
+	if len(g.Username) > 0 {
+		u.User = url.User(g.Username)
+	}
+	if len(g.Path) > 0 {
+		u.Path = "/" + g.Path
+	}
+	return "git:" + u.String()
+}
+
+// GitGet returns the credential that best matches the credential
+// description. A credential matches if the protocol and host are
+// equal, and the path of the description is within the path of
+// the credential. If the description has a username it must be
+// equal. The credential with the longest path is the best match.
+// Returns false if no credential matches.
+func GitGet(handler Handler, g Git) (Git, bool, error) {
+	entries, err := gitMatches(handler, g)
+	if err != nil || len(entries) == 0 {
+		return Git{}, false, err
+	}
+
+	best, bestPath := entries[0], -1
+	for _, entry := range entries {
+		u, _ := url.Parse(entry.Credential.URL)
+		if l := len(strings.Trim(u.Path, "/")); l > bestPath {
+			best, bestPath = entry, l
+		}
+	}
+	if len(best.Credential.Username) > 0 {
+		g.Username = best.Credential.Username
+	}
+	g.Password = best.Credential.Password
+	return g, true, nil
+}
+
+// GitStore stores the credential description as a credential secret.
+func GitStore(handler Handler, g Git) error {
+	if len(g.Protocol) == 0 || len(g.Host) == 0 || len(g.Password) == 0 {
+		return fmt.Errorf("protocol, host and password must be provided")
+	}
+	return Save(handler, g.name(), Credential{URL: g.URL(), Username: g.Username, Password: g.Password}, LabelGit)
+}
+
+// GitErase deletes the credential secrets matching the credential
+// description. If the description has a password, only credentials
+// with that password are deleted.
+func GitErase(handler Handler, g Git) error {
+	entries, err := gitMatches(handler, g)
+	if err != nil {
+		return err
+	}
+	var hookErrs []error
+	for _, entry := range entries {
+		if len(g.Password) > 0 && entry.Credential.Password != g.Password {
+			continue
+		}
+		if err := handler.DeleteSecretByID(entry.Secret.ID); err != nil {
+			// The secret is deleted even if a post-hook fails.
+			if !errors.Is(err, secret.ErrPostHook) {
+				return err
+			}
+			hookErrs = append(hookErrs, err)
+		}
+	}
+	return errors.Join(hookErrs...)
+}
+
+// gitMatches returns the credentials matching the credential description.
+func gitMatches(handler Handler, g Git) ([]Entry, error) {
+	entries, err := List(handler, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []Entry
+	for _, entry := range entries {
+		if gitMatch(g, entry.Credential) {
+			matches = append(matches, entry)
+		}
+	}
+	return matches, nil
+}
+
+// gitMatch reports whether the credential matches the credential
+// description.
+func gitMatch(g Git, c Credential) bool {
+	u, err := url.Parse(c.URL)
+	if err != nil || len(u.Host) == 0 {
+		return false
+	}
+	if u.Scheme != g.Protocol || !strings.EqualFold(u.Host, g.Host) {
+		return false
+	}
+	if len(g.Username) > 0 && len(c.Username) > 0 && c.Username != g.Username {
+		return false
+	}
+	path, want := strings.Trim(u.Path, "/"), strings.Trim(g.Path, "/")
+	return len(path) == 0 || want == path || strings.HasPrefix(want, path+"/")
+}
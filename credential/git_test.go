@@ -0,0 +1,126 @@
+package credential
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/KarlGW/secman/internal/security"
+	"github.com/KarlGW/secman/secret"
+	"github.com/KarlGW/secman/storage"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadGit(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   string
+		want    Git
+		wantErr bool
+	}{
+		{
+			name:  "attributes",
+			input: "protocol=https\nhost=example.com\npath=org/repo.git\nusername=user\npassword=pass\n\n",
+			want:  Git{Protocol: "https", Host: "example.com", Path: "org/repo.git", Username: "user", Password: "pass"},
+		},
+		{
+			name:  "url",
+			input: "url=https://user@example.com:8443/org/repo.git\nwwwauth[]=Basic\n",
+			want:  Git{Protocol: "https", Host: "example.com:8443", Path: "org/repo.git", Username: "user"},
+		},
+		{
+			name:    "invalid line",
+			input:   "protocol\n",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := ReadGit(strings.NewReader(test.input))
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("ReadGit() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if test.wantErr != (gotErr != nil) {
+				t.Errorf("ReadGit() = unexpected error: %v\n", gotErr)
+			}
+		})
+	}
+}
+
+func TestGit(t *testing.T) {
+	handler := setupHandler(t)
+	for _, g := range []Git{
+		{Protocol: "https", Host: "example.com", Username: "user", Password: "host-token"},
+		{Protocol: "https", Host: "example.com", Path: "org", Username: "user", Password: "org-token"},
+	} {
+		if err := GitStore(handler, g); err != nil {
+			t.Fatalf("GitStore() = unexpected error: %v\n", err)
+		}
+	}
+
+	var tests = []struct {
+		name   string
+		input  Git
+		want   Git
+		wantOK bool
+	}{
+		{
+			name:   "host",
+			input:  Git{Protocol: "https", Host: "example.com"},
+			want:   Git{Protocol: "https", Host: "example.com", Username: "user", Password: "host-token"},
+			wantOK: true,
+		},
+		{
+			name:   "longest path",
+			input:  Git{Protocol: "https", Host: "example.com", Path: "org/repo.git"},
+			want:   Git{Protocol: "https", Host: "example.com", Path: "org/repo.git", Username: "user", Password: "org-token"},
+			wantOK: true,
+		},
+		{
+			name:  "other username",
+			input: Git{Protocol: "https", Host: "example.com", Username: "other"},
+		},
+		{
+			name:  "other protocol",
+			input: Git{Protocol: "http", Host: "example.com"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotOK, _ := GitGet(handler, test.input)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("GitGet() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if test.wantOK != gotOK {
+				t.Errorf("GitGet() = unexpected result, want: %v, got: %v\n", test.wantOK, gotOK)
+			}
+		})
+	}
+
+	t.Run("erase", func(t *testing.T) {
+		if err := GitErase(handler, Git{Protocol: "https", Host: "example.com", Path: "org", Password: "org-token"}); err != nil {
+			t.Fatalf("GitErase() = unexpected error: %v\n", err)
+		}
+		got, _, _ := GitGet(handler, Git{Protocol: "https", Host: "example.com", Path: "org"})
+
+		if diff := cmp.Diff("host-token", got.Password); diff != "" {
+			t.Errorf("GitErase() = unexpected result (-want +got)\n%s\n", diff)
+		}
+	})
+}
+
+func setupHandler(t *testing.T) *secret.Handler {
+	t.Helper()
+	key := security.Key{Value: bytes.Repeat([]byte{1}, secret.KeyLength)}
+	handler, err := secret.NewHandler("profile", key, key, storage.NewMemory(nil))
+	if err != nil {
+		t.Fatalf("NewHandler() = unexpected error: %v\n", err)
+	}
+	return handler
+}
@@ -0,0 +1,196 @@
+// Package env formats secrets as environment variables for shells
+// and files.
+package env
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/KarlGW/secman/secret"
+)
+
+// Tag is the tag of a secret that sets the name of its
+// environment variable.
+const Tag = "env"
+
+var (
+	// ErrDuplicateName is returned when several secrets have the
+	// same environment variable name.
+	ErrDuplicateName = errors.New("duplicate environment variable name")
+)
+
+// Format is the output format of environment variables.
+type Format string
+
+const (
+	// FormatPOSIX formats as export statements for POSIX shells.
+	FormatPOSIX Format = "posix"
+	// FormatPowerShell formats as PowerShell $env: assignments.
+	FormatPowerShell Format = "powershell"
+	// FormatDotenv formats as a dotenv file.
+	FormatDotenv Format = "dotenv"
+	// FormatJSON formats as a flat JSON object.
+	FormatJSON Format = "json"
+)
+
+// Formats contains all formats.
+var Formats = []Format{FormatPOSIX, FormatPowerShell, FormatDotenv, FormatJSON}
+
+// ParseFormat parses the provided format.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("invalid format: %s", s)
+}
+
+// Var is an environment variable.
+type Var struct {
+	Name  string
+	Value string
+}
+
+// Name returns the name of the environment variable of a secret. It is
+// the value of the tag env if set, otherwise the sanitised name of the
+// secret: upper case with characters other than letters, digits and
+// underscores replaced by underscores, and prefixed with an underscore
+// if it starts with a digit.
+func Name(s secret.Secret) string {
+	if name, ok := s.Tags[Tag]; ok && len(name) > 0 {
+		return name
+	}
+	return Sanitise(s.Name)
+}
+
+// CheckNames returns an error if several of the secrets have the same
+// environment variable name. The error lists the names and the
+// conflicting secrets.
+func CheckNames(secrets []secret.Secret) error {
+	var names []string
+	secretsByName := make(map[string][]string)
+	for _, s := range secrets {
+		name := Name(s)
+		if _, ok := secretsByName[name]; !ok {
+			names = append(names, name)
+		}
+		secretsByName[name] = append(secretsByName[name], s.Name)
+	}
+
+	var conflicts []string
+	for _, name := range names {
+		if len(secretsByName[name]) > 1 {
+			conflicts = append(conflicts, name+" ("+strings.Join(secretsByName[name], ", ")+")")
+		}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%w: %s", ErrDuplicateName, strings.Join(conflicts, "; "))
+	}
+	return nil
+}
+
+// invalidChars matches characters that are invalid in
+// environment variable names.
+var invalidChars = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// Sanitise the provided string into a valid environment
+// variable name.
+func Sanitise(s string) string {
+	name := invalidChars.ReplaceAllString(strings.ToUpper(s), "_")
+	if len(name) == 0 || unicode.IsDigit(rune(name[0])) {
+		name = "_" + name
+	}
+	return name
+}
+
+// validName matches valid environment variable names.
+var validName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Write the environment variables in the provided format to w.
+func Write(w io.Writer, format Format, vars []Var) error {
+	for _, v := range vars {
+		if !validName.MatchString(v.Name) {
+			return fmt.Errorf("invalid environment variable name: %q", v.Name)
+		}
+	}
+
+	if format == FormatJSON {
+		m := make(map[string]string, len(vars))
+		for _, v := range vars {
+			m[v.Name] = v.Value
+		}
+		b, err := json.MarshalIndent(m, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	}
+
+	var line func(v Var) string
+	switch format {
+	case FormatPOSIX:
+		line = func(v Var) string {
+			return "export " + v.Name + "=" + quotePOSIX(v.Value)
+		}
+	case FormatPowerShell:
+		line = func(v Var) string {
+			return "$env:" + v.Name + " = " + quotePowerShell(v.Value)
+		}
+	case FormatDotenv:
+		line = func(v Var) string {
+			return v.Name + "=" + quoteDotenv(v.Value)
+		}
+	default:
+		return fmt.Errorf("invalid format: %s", format)
+	}
+
+	for _, v := range vars {
+		if _, err := fmt.Fprintln(w, line(v)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// quotePOSIX quotes the value in single quotes, where nothing is
+// interpreted. Single quotes are ended, escaped and reopened.
+func quotePOSIX(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// quotePowerShell quotes the value in single quotes, where nothing
+// is interpreted. Single quotes (including the typographic variants
+// PowerShell accepts) are escaped by doubling them.
+func quotePowerShell(s string) string {
+	var b strings.Builder
+	b.WriteByte('\'')
+	for _, r := range s {
+		switch r {
+		case '\'', '\u2018', '\u2019', '\u201A', '\u201B':
+			b.WriteRune(r)
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
+// quoteDotenv quotes the value in double quotes, with backslashes,
+// double quotes, dollar signs and line breaks escaped.
+func quoteDotenv(s string) string {
+	r := strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		`$`, `\$`,
+		"\n", `\n`,
+		"\r", `\r`,
+	)
+	return `"` + r.Replace(s) + `"`
+}
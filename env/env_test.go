@@ -0,0 +1,131 @@
+package env
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/KarlGW/secman/secret"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestName(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input secret.Secret
+		want  string
+	}{
+		{
+			name:  "from tag",
+			input: secret.Secret{Name: "db-password", Tags: map[string]string{"env": "DB_PASSWORD"}},
+			want:  "DB_PASSWORD",
+		},
+		{
+			name:  "from name",
+			input: secret.Secret{Name: "db-password.prod"},
+			want:  "DB_PASSWORD_PROD",
+		},
+		{
+			name:  "from name starting with digit",
+			input: secret.Secret{Name: "1password"},
+			want:  "_1PASSWORD",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Name(test.input)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Name() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestCheckNames(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   []secret.Secret
+		wantErr error
+	}{
+		{
+			name: "unique names",
+			input: []secret.Secret{
+				{Name: "db-password"},
+				{Name: "api-key", Tags: map[string]string{"env": "KEY"}},
+			},
+		},
+		{
+			name: "sanitised names",
+			input: []secret.Secret{
+				{Name: "db-password"},
+				{Name: "db.password"},
+			},
+			wantErr: ErrDuplicateName,
+		},
+		{
+			name: "tag and name",
+			input: []secret.Secret{
+				{Name: "api-key"},
+				{Name: "key", Tags: map[string]string{"env": "API_KEY"}},
+			},
+			wantErr: ErrDuplicateName,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotErr := CheckNames(test.input)
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("CheckNames() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input Format
+		want  string
+	}{
+		{
+			name:  "posix",
+			input: FormatPOSIX,
+			want:  "export A='it'\\''s $HOME'\nexport B='line1\nline2'\n",
+		},
+		{
+			name:  "powershell",
+			input: FormatPowerShell,
+			want:  "$env:A = 'it''s $HOME'\n$env:B = 'line1\nline2'\n",
+		},
+		{
+			name:  "dotenv",
+			input: FormatDotenv,
+			want:  "A=\"it's \\$HOME\"\nB=\"line1\\nline2\"\n",
+		},
+		{
+			name:  "json",
+			input: FormatJSON,
+			want:  "{\n  \"A\": \"it's $HOME\",\n  \"B\": \"line1\\nline2\"\n}\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, test.input, []Var{
+				{Name: "A", Value: "it's $HOME"},
+				{Name: "B", Value: "line1\nline2"},
+			}); err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+
+			if diff := cmp.Diff(test.want, buf.String()); diff != "" {
+				t.Errorf("Write() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
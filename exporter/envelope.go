@@ -0,0 +1,126 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/KarlGW/secman/internal/security"
+)
+
+var (
+	// ErrInvalidEnvelope is returned when an envelope cannot be parsed.
+	ErrInvalidEnvelope = errors.New("invalid envelope")
+	// ErrInvalidPassword is returned when an envelope cannot be
+	// decrypted with the password, or its headers have been modified.
+	ErrInvalidPassword = errors.New("invalid password")
+)
+
+const (
+	// EnvelopeType is the PEM type of a password encrypted envelope.
+	EnvelopeType = "SECMAN ENCRYPTED EXPORT"
+	// envelopeKDF is the key derivation function of envelopes.
+	envelopeKDF = "argon2id"
+	// envelopeCipher is the cipher of envelopes.
+	envelopeCipher = "AES-256-GCM"
+)
+
+// envelopeParams are the argon2id parameters for the keys of envelopes.
+var envelopeParams = security.Params{Time: 3, Memory: 128 * 1024, Threads: 4}
+
+// envelopeHeaders are the headers of envelopes, in the order they are
+// authenticated.
+var envelopeHeaders = []string{"Format", "KDF", "Params", "Salt", "Cipher"}
+
+// Seal the data in a password encrypted envelope. The envelope is
+// a PEM block with the headers:
+//
+//   - Format: the format of the data
+//   - KDF: argon2id
+//   - Params: the argon2id parameters (m=<memory>,t=<time>,p=<threads>)
+//   - Salt: the base64 encoded salt
+//   - Cipher: AES-256-GCM
+//
+// The content is the 12 byte nonce followed by the encrypted data. The
+// headers are authenticated as additional data of the encryption.
+func Seal(data, password []byte, format Format) ([]byte, error) {
+	key, err := security.NewKeyFromPassword(password, security.WithParams(envelopeParams))
+	if err != nil {
+		return nil, err
+	}
+	headers := map[string]string{
+		"Format": string(format),
+		"KDF":    envelopeKDF,
+		"Params": key.Params.String(),
+		"Salt":   base64.StdEncoding.EncodeToString(key.Salt),
+		"Cipher": envelopeCipher,
+	}
+	encrypted, err := security.EncryptWithAdditionalData(data, key.Value, additionalData(headers))
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type:    EnvelopeType,
+		Headers: headers,
+		Bytes:   encrypted,
+	}), nil
+}
+
+// Open a password encrypted envelope and return the data and its format.
+func Open(b, password []byte) ([]byte, Format, error) {
+	block, _ := pem.Decode(b)
+	if block == nil || block.Type != EnvelopeType {
+		return nil, "", ErrInvalidEnvelope
+	}
+	if block.Headers["KDF"] != envelopeKDF || block.Headers["Cipher"] != envelopeCipher {
+		return nil, "", fmt.Errorf("%w: unsupported key derivation function or cipher", ErrInvalidEnvelope)
+	}
+	var params security.Params
+	if _, err := fmt.Sscanf(block.Headers["Params"], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return nil, "", fmt.Errorf("%w: %w", ErrInvalidEnvelope, err)
+	}
+	if err := params.Validate(); err != nil {
+		return nil, "", fmt.Errorf("%w: %w", ErrInvalidEnvelope, err)
+	}
+	salt, err := base64.StdEncoding.DecodeString(block.Headers["Salt"])
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %w", ErrInvalidEnvelope, err)
+	}
+
+	key, err := security.DeriveKey(password, salt, params)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %w", ErrInvalidEnvelope, err)
+	}
+	decrypted, err := security.DecryptWithAdditionalData(block.Bytes, key, additionalData(block.Headers))
+	if err != nil {
+		return nil, "", ErrInvalidPassword
+	}
+	return decrypted, Format(block.Headers["Format"]), nil
+}
+
+// additionalData returns the headers of an envelope encoded for
+// authentication.
+func additionalData(headers map[string]string) []byte {
+	var buf bytes.Buffer
+	for _, name := range envelopeHeaders {
+		buf.WriteString(name + ": " + headers[name] + "\n")
+	}
+	return buf.Bytes()
+}
+
+// SealAge encrypts the data to the age recipients with the age command.
+// The output is ASCII armored.
+func SealAge(data []byte, recipients []string) ([]byte, error) {
+	args := []string{"--encrypt", "--armor"}
+	for _, r := range recipients {
+		args = append(args, "--recipient", r)
+	}
+	cmd := exec.Command("age", args...)
+	cmd.Stdin, cmd.Stderr = bytes.NewReader(data), os.Stderr
+	return cmd.Output()
+}
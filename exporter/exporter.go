@@ -0,0 +1,134 @@
+// Package exporter exports decrypted secrets to portable formats
+// and seals them in encrypted envelopes.
+package exporter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KarlGW/secman/secret"
+)
+
+var (
+	// ErrInvalidFormat is returned when the format is not supported.
+	ErrInvalidFormat = errors.New("invalid export format")
+)
+
+// Format is the format of an export.
+type Format string
+
+const (
+	// FormatJSON is a JSON array of records.
+	FormatJSON Format = "json"
+	// FormatCSV is CSV with a header and a row per record.
+	FormatCSV Format = "csv"
+	// FormatKeePassXML is the XML format of KeePass.
+	FormatKeePassXML Format = "keepass-xml"
+)
+
+// Formats contains the supported formats.
+var Formats = []Format{
+	FormatJSON,
+	FormatCSV,
+	FormatKeePassXML,
+}
+
+// ParseFormat parses a format.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrInvalidFormat, s)
+}
+
+// Record is an exported secret with its decrypted value.
+type Record struct {
+	Name        string            `json:"name"`
+	DisplayName string            `json:"displayName,omitempty"`
+	Type        string            `json:"type"`
+	Labels      []string          `json:"labels,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Value       string            `json:"value"`
+	Created     time.Time         `json:"created"`
+	Updated     time.Time         `json:"updated"`
+}
+
+// NewRecords decrypts the secrets and creates records of them.
+func NewRecords(secrets []secret.Secret) ([]Record, error) {
+	records := make([]Record, 0, len(secrets))
+	for _, s := range secrets {
+		decrypted, err := s.Decrypt()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.Name, err)
+		}
+		records = append(records, Record{
+			Name:        s.Name,
+			DisplayName: s.DisplayName,
+			Type:        s.Type.String(),
+			Labels:      s.Labels,
+			Tags:        s.Tags,
+			Value:       string(decrypted),
+			Created:     s.Created,
+			Updated:     s.Updated,
+		})
+	}
+	return records, nil
+}
+
+// Write the records to w in the provided format.
+func Write(w io.Writer, format Format, records []Record) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case FormatCSV:
+		return writeCSV(w, records)
+	case FormatKeePassXML:
+		return writeKeePassXML(w, records)
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidFormat, format)
+}
+
+// csvHeader is the header of the CSV format.
+var csvHeader = []string{"name", "display_name", "type", "labels", "tags", "value", "created", "updated"}
+
+// writeCSV writes the records as CSV. Labels are separated with
+// semicolons, and tags are written as key=value separated with
+// semicolons.
+func writeCSV(w io.Writer, records []Record) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, r := range records {
+		tags := make([]string, 0, len(r.Tags))
+		for k, v := range r.Tags {
+			tags = append(tags, k+"="+v)
+		}
+		sort.Strings(tags)
+
+		if err := writer.Write([]string{
+			r.Name,
+			r.DisplayName,
+			r.Type,
+			strings.Join(r.Labels, ";"),
+			strings.Join(tags, ";"),
+			r.Value,
+			r.Created.UTC().Format(time.RFC3339),
+			r.Updated.UTC().Format(time.RFC3339),
+		}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,203 @@
+package exporter
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/KarlGW/secman/importer"
+	"github.com/KarlGW/secman/internal/security"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+var testRecords = []Record{
+	{
+		Name:    "github",
+		Type:    "credential",
+		Labels:  []string{"work/dev", "git"},
+		Tags:    map[string]string{"env": "prod", "team": "a"},
+		Value:   `{"password":"pass","pin":"1234","url":"https://github.com","username":"user"}`,
+		Created: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Updated: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+	},
+	{
+		Name:        "note",
+		DisplayName: "Note",
+		Type:        "note",
+		Value:       "line 1\nline 2",
+		Created:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Updated:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	},
+}
+
+func TestWrite(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   Format
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "csv",
+			input: FormatCSV,
+			want: "name,display_name,type,labels,tags,value,created,updated\n" +
+				"github,,credential,work/dev;git,env=prod;team=a,\"{\"\"password\"\":\"\"pass\"\",\"\"pin\"\":\"\"1234\"\",\"\"url\"\":\"\"https://github.com\"\",\"\"username\"\":\"\"user\"\"}\",2024-01-01T00:00:00Z,2024-01-02T00:00:00Z\n" +
+				"note,Note,note,,,\"line 1\nline 2\",2024-01-01T00:00:00Z,2024-01-01T00:00:00Z\n",
+		},
+		{
+			name:    "invalid",
+			input:   Format("xml"),
+			wantErr: ErrInvalidFormat,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			gotErr := Write(&buf, test.input, testRecords)
+
+			if diff := cmp.Diff(test.want, buf.String()); diff != "" {
+				t.Errorf("Write() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Write() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestWrite_KeePassXML(t *testing.T) {
+	records := append(testRecords, Record{
+		Name:  "config",
+		Type:  "generic",
+		Value: `{"url":"https://example.com"}`,
+	})
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatKeePassXML, records); err != nil {
+		t.Fatalf("Write() = unexpected error: %v\n", err)
+	}
+
+	got, err := importer.ReadKeePassXML(&buf)
+	if err != nil {
+		t.Fatalf("ReadKeePassXML() = unexpected error: %v\n", err)
+	}
+
+	want := []importer.Entry{
+		{Name: "note", Notes: "line 1\nline 2"},
+		{Name: "config", Password: `{"url":"https://example.com"}`},
+		{
+			Name:     "github",
+			URL:      "https://github.com",
+			Username: "user",
+			Password: "pass",
+			Labels:   []string{"work/dev"},
+			Fields:   map[string]string{"env": "prod", "team": "a"},
+			Hidden:   map[string]string{"pin": "1234"},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Write() = unexpected result (-want +got)\n%s\n", diff)
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	originalParams := envelopeParams
+	envelopeParams = security.Params{Time: 1, Memory: 8 * 1024, Threads: 1}
+	t.Cleanup(func() {
+		envelopeParams = originalParams
+	})
+
+	sealed, err := Seal([]byte("data"), []byte("password"), FormatJSON)
+	if err != nil {
+		t.Fatalf("Seal() = unexpected error: %v\n", err)
+	}
+
+	var tests = []struct {
+		name  string
+		input struct {
+			envelope []byte
+			password []byte
+		}
+		want       []byte
+		wantFormat Format
+		wantErr    error
+	}{
+		{
+			name: "open",
+			input: struct {
+				envelope []byte
+				password []byte
+			}{
+				envelope: sealed,
+				password: []byte("password"),
+			},
+			want:       []byte("data"),
+			wantFormat: FormatJSON,
+		},
+		{
+			name: "invalid password",
+			input: struct {
+				envelope []byte
+				password []byte
+			}{
+				envelope: sealed,
+				password: []byte("wrong"),
+			},
+			wantErr: ErrInvalidPassword,
+		},
+		{
+			name: "modified format",
+			input: struct {
+				envelope []byte
+				password []byte
+			}{
+				envelope: bytes.Replace(sealed, []byte("Format: json"), []byte("Format: csv"), 1),
+				password: []byte("password"),
+			},
+			wantErr: ErrInvalidPassword,
+		},
+		{
+			name: "too high parameters",
+			input: struct {
+				envelope []byte
+				password []byte
+			}{
+				envelope: bytes.Replace(sealed, []byte("m=8192"), []byte("m=4294967295"), 1),
+				password: []byte("password"),
+			},
+			wantErr: ErrInvalidEnvelope,
+		},
+		{
+			name: "invalid envelope",
+			input: struct {
+				envelope []byte
+				password []byte
+			}{
+				envelope: []byte("data"),
+				password: []byte("password"),
+			},
+			wantErr: ErrInvalidEnvelope,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotFormat, gotErr := Open(test.input.envelope, test.input.password)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Open() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if test.wantFormat != gotFormat {
+				t.Errorf("Open() = unexpected format, want: %s, got: %s\n", test.wantFormat, gotFormat)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Open() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
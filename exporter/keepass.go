@@ -0,0 +1,180 @@
+package exporter
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/KarlGW/secman/secret"
+	"github.com/google/uuid"
+)
+
+// keepassRoot is the name of the root group.
+const keepassRoot = "secman"
+
+// keepassFile is the XML format of KeePass.
+type keepassFile struct {
+	XMLName xml.Name `xml:"KeePassFile"`
+	Meta    struct {
+		Generator string `xml:"Generator"`
+	} `xml:"Meta"`
+	Root struct {
+		Group *keepassGroup `xml:"Group"`
+	} `xml:"Root"`
+}
+
+// keepassGroup is a group of a KeePass database.
+type keepassGroup struct {
+	UUID    string          `xml:"UUID"`
+	Name    string          `xml:"Name"`
+	Entries []keepassEntry  `xml:"Entry"`
+	Groups  []*keepassGroup `xml:"Group"`
+}
+
+// keepassEntry is an entry of a KeePass database.
+type keepassEntry struct {
+	UUID    string          `xml:"UUID"`
+	Tags    string          `xml:"Tags,omitempty"`
+	Strings []keepassString `xml:"String"`
+}
+
+// keepassString is a string of an entry.
+type keepassString struct {
+	Key   string `xml:"Key"`
+	Value struct {
+		Value           string `xml:",chardata"`
+		ProtectInMemory string `xml:"ProtectInMemory,attr,omitempty"`
+	} `xml:"Value"`
+}
+
+// writeKeePassXML writes the records in the XML format of KeePass.
+// The first label of a record is used as its group. The fields of
+// structured values of credentials are mapped to the standard strings of KeePass,
+// with other fields as protected strings. Tags are written as strings,
+// and labels as tags.
+func writeKeePassXML(w io.Writer, records []Record) error {
+	var file keepassFile
+	file.Meta.Generator = keepassRoot
+	root := &keepassGroup{UUID: keepassUUID(), Name: keepassRoot}
+	file.Root.Group = root
+
+	for _, r := range records {
+		group := root
+		if len(r.Labels) > 0 {
+			for _, name := range strings.Split(r.Labels[0], "/") {
+				if len(name) > 0 {
+					group = group.group(name)
+				}
+			}
+		}
+		group.Entries = append(group.Entries, keepassEntryOf(r))
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	if err := enc.Encode(file); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// group returns the subgroup with the name, and creates it
+// if it does not exist.
+func (g *keepassGroup) group(name string) *keepassGroup {
+	for _, sub := range g.Groups {
+		if sub.Name == name {
+			return sub
+		}
+	}
+	sub := &keepassGroup{UUID: keepassUUID(), Name: name}
+	g.Groups = append(g.Groups, sub)
+	return sub
+}
+
+// keepassEntryOf creates an entry of the record.
+func keepassEntryOf(r Record) keepassEntry {
+	standard := map[string]string{"Title": r.Name}
+	var protected map[string]string
+
+	fields := make(map[string]string)
+	if r.Type == secret.TypeCredential.String() && json.Unmarshal([]byte(r.Value), &fields) == nil {
+		for k, v := range fields {
+			switch k {
+			case "url":
+				standard["URL"] = v
+			case "username":
+				standard["UserName"] = v
+			case "password":
+				standard["Password"] = v
+			case "notes":
+				standard["Notes"] = v
+			default:
+				protected = setString(protected, k, v)
+			}
+		}
+	} else if r.Type == secret.TypeNote.String() {
+		standard["Notes"] = r.Value
+	} else {
+		standard["Password"] = r.Value
+	}
+
+	entry := keepassEntry{
+		UUID: keepassUUID(),
+		Tags: strings.Join(r.Labels, ";"),
+	}
+	for _, key := range []string{"Title", "UserName", "Password", "URL", "Notes"} {
+		entry.Strings = append(entry.Strings, newKeepassString(key, standard[key], key == "Password"))
+	}
+	for _, key := range sortedKeys(protected) {
+		entry.Strings = append(entry.Strings, newKeepassString(key, protected[key], true))
+	}
+	for _, key := range sortedKeys(r.Tags) {
+		if _, ok := standard[key]; ok || protected[key] != "" {
+			continue
+		}
+		entry.Strings = append(entry.Strings, newKeepassString(key, r.Tags[key], false))
+	}
+	return entry
+}
+
+// newKeepassString creates a string of an entry.
+func newKeepassString(key, value string, protect bool) keepassString {
+	s := keepassString{Key: key}
+	s.Value.Value = value
+	if protect {
+		s.Value.ProtectInMemory = "True"
+	}
+	return s
+}
+
+// setString sets the string in the map, and creates the map if needed.
+func setString(m map[string]string, key, value string) map[string]string {
+	if m == nil {
+		m = make(map[string]string)
+	}
+	m[key] = value
+	return m
+}
+
+// sortedKeys returns the keys of the map in sorted order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// keepassUUID returns a new UUID in the format of KeePass.
+func keepassUUID() string {
+	id := uuid.New()
+	return base64.StdEncoding.EncodeToString(id[:])
+}
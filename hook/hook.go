@@ -0,0 +1,192 @@
+// Package hook runs the hooks of a profile before and after operations
+// that change secrets.
+package hook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"time"
+
+	"github.com/KarlGW/secman/config"
+	"github.com/KarlGW/secman/secret"
+)
+
+const (
+	// StagePre is the stage of hooks that are run before an operation.
+	// A failing pre-hook aborts the operation.
+	StagePre = "pre"
+	// StagePost is the stage of hooks that are run after an operation
+	// has been saved.
+	StagePost = "post"
+)
+
+const (
+	// defaultTimeout is the default time a hook is allowed to run.
+	defaultTimeout = 30 * time.Second
+)
+
+// Operations contains the operations hooks can be run for.
+var Operations = []secret.Operation{
+	secret.OperationCreate,
+	secret.OperationUpdate,
+	secret.OperationDelete,
+	secret.OperationRotate,
+	secret.OperationSync,
+}
+
+// Event is the event a hook receives as JSON on stdin. It never
+// contains the value of the secret.
+type Event struct {
+	Operation secret.Operation  `json:"operation"`
+	Stage     string            `json:"stage"`
+	ProfileID string            `json:"profileId"`
+	ID        string            `json:"id,omitempty"`
+	Name      string            `json:"name,omitempty"`
+	Type      string            `json:"type,omitempty"`
+	Labels    []string          `json:"labels,omitempty"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	Time      time.Time         `json:"time"`
+}
+
+// Runner runs the hooks of a profile. It implements secret.Hooks.
+type Runner struct {
+	profileID string
+	hooks     []config.Hook
+	stdout    io.Writer
+	stderr    io.Writer
+	timeout   time.Duration
+}
+
+// Options contains options for a Runner.
+type Options struct {
+	Stdout  io.Writer
+	Stderr  io.Writer
+	Timeout time.Duration
+}
+
+// Option is a function that sets Options.
+type Option func(o *Options)
+
+// NewRunner creates and returns a new Runner for the hooks of
+// the profile.
+func NewRunner(profileID string, hooks []config.Hook, options ...Option) *Runner {
+	opts := Options{
+		Stdout:  os.Stderr,
+		Stderr:  os.Stderr,
+		Timeout: defaultTimeout,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	return &Runner{
+		profileID: profileID,
+		hooks:     hooks,
+		stdout:    opts.Stdout,
+		stderr:    opts.Stderr,
+		timeout:   opts.Timeout,
+	}
+}
+
+// Pre runs the pre-hooks of the operation. It stops at the first
+// failing hook.
+func (r Runner) Pre(op secret.Operation, s secret.Secret) error {
+	return r.run(StagePre, op, s)
+}
+
+// Post runs the post-hooks of the operation. It stops at the first
+// failing hook.
+func (r Runner) Post(op secret.Operation, s secret.Secret) error {
+	return r.run(StagePost, op, s)
+}
+
+// run runs the hooks of the stage and operation in order, with
+// the event as JSON on stdin.
+func (r Runner) run(stage string, op secret.Operation, s secret.Secret) error {
+	var event []byte
+	for _, hook := range r.hooks {
+		if hook.Stage != stage || (len(hook.Operations) > 0 && !slices.Contains(hook.Operations, string(op))) {
+			continue
+		}
+		if event == nil {
+			var err error
+			if event, err = json.Marshal(r.event(stage, op, s)); err != nil {
+				return err
+			}
+		}
+		if err := r.runHook(hook, event); err != nil {
+			return fmt.Errorf("%s: %w", hook.Name, err)
+		}
+	}
+	return nil
+}
+
+// runHook runs the hook with the event on stdin.
+func (r Runner) runHook(hook config.Hook, event []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, hook.Command, hook.Args...)
+	cmd.Stdin = bytes.NewReader(event)
+	cmd.Stdout, cmd.Stderr = r.stdout, r.stderr
+	return cmd.Run()
+}
+
+// event creates the event of the operation.
+func (r Runner) event(stage string, op secret.Operation, s secret.Secret) Event {
+	event := Event{
+		Operation: op,
+		Stage:     stage,
+		ProfileID: r.profileID,
+		ID:        s.ID,
+		Name:      s.Name,
+		Labels:    s.Labels,
+		Tags:      s.Tags,
+		Time:      time.Now(),
+	}
+	if s.Valid() {
+		event.Type = s.Type.String()
+	}
+	return event
+}
+
+// ValidateHook validates the stage and operations of the hook.
+func ValidateHook(hook config.Hook) error {
+	if len(hook.Name) == 0 || len(hook.Command) == 0 {
+		return errors.New("a hook must have a name and a command")
+	}
+	if hook.Stage != StagePre && hook.Stage != StagePost {
+		return fmt.Errorf("invalid stage %q, must be %s or %s", hook.Stage, StagePre, StagePost)
+	}
+	for _, op := range hook.Operations {
+		if !slices.Contains(Operations, secret.Operation(op)) {
+			return fmt.Errorf("invalid operation %q", op)
+		}
+	}
+	return nil
+}
+
+// WithOutput sets the writers of the output of the hooks. Defaults
+// to stderr for both, to not mix the output with the output of
+// commands.
+func WithOutput(stdout, stderr io.Writer) Option {
+	return func(o *Options) {
+		o.Stdout = stdout
+		o.Stderr = stderr
+	}
+}
+
+// WithTimeout sets the time a hook is allowed to run. Defaults
+// to 30 seconds.
+func WithTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = d
+	}
+}
@@ -0,0 +1,101 @@
+package hook
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"testing"
+
+	"github.com/KarlGW/secman/config"
+	"github.com/KarlGW/secman/secret"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestRunner(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	var tests = []struct {
+		name  string
+		input struct {
+			hooks []config.Hook
+			stage string
+			op    secret.Operation
+		}
+		want    []Event
+		wantErr bool
+	}{
+		{
+			name: "run matching hooks",
+			input: struct {
+				hooks []config.Hook
+				stage string
+				op    secret.Operation
+			}{
+				hooks: []config.Hook{
+					{Name: "all", Stage: StagePre, Command: "cat"},
+					{Name: "rotate", Stage: StagePre, Operations: []string{"rotate"}, Command: "cat"},
+					{Name: "post", Stage: StagePost, Command: "cat"},
+				},
+				stage: StagePre,
+				op:    secret.OperationCreate,
+			},
+			want: []Event{
+				{Operation: secret.OperationCreate, Stage: StagePre, ProfileID: "profile", ID: "1", Name: "secret", Type: "generic"},
+			},
+		},
+		{
+			name: "failing hook",
+			input: struct {
+				hooks []config.Hook
+				stage string
+				op    secret.Operation
+			}{
+				hooks: []config.Hook{
+					{Name: "fail", Stage: StagePost, Command: "sh", Args: []string{"-c", "exit 1"}},
+					{Name: "post", Stage: StagePost, Command: "cat"},
+				},
+				stage: StagePost,
+				op:    secret.OperationDelete,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			runner := NewRunner("profile", test.input.hooks, WithOutput(&stdout, &stdout))
+			s := secret.Secret{ID: "1", Name: "secret", Value: []byte("value")}
+
+			var gotErr error
+			if test.input.stage == StagePre {
+				gotErr = runner.Pre(test.input.op, s)
+			} else {
+				gotErr = runner.Post(test.input.op, s)
+			}
+
+			var got []Event
+			decoder := json.NewDecoder(&stdout)
+			for decoder.More() {
+				var event Event
+				if err := decoder.Decode(&event); err != nil {
+					t.Fatalf("unexpected error in test: %v\n", err)
+				}
+				got = append(got, event)
+			}
+
+			if diff := cmp.Diff(test.want, got, cmpopts.IgnoreFields(Event{}, "Time")); diff != "" {
+				t.Errorf("run() = unexpected result (-want +got)\n%s\n", diff)
+			}
+			if bytes.Contains(stdout.Bytes(), []byte("value")) {
+				t.Errorf("run() = event contains the value of the secret\n")
+			}
+			if test.wantErr != (gotErr != nil) {
+				t.Errorf("run() = unexpected error: %v\n", gotErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Bitwarden item types.
+const (
+	bitwardenLogin      = 1
+	bitwardenSecureNote = 2
+	bitwardenCard       = 3
+	bitwardenIdentity   = 4
+)
+
+// Bitwarden custom field types.
+const (
+	bitwardenFieldText    = 0
+	bitwardenFieldHidden  = 1
+	bitwardenFieldBoolean = 2
+)
+
+// bitwardenExport is the JSON export of Bitwarden.
+type bitwardenExport struct {
+	Encrypted   bool              `json:"encrypted"`
+	Folders     []bitwardenFolder `json:"folders"`
+	Collections []bitwardenFolder `json:"collections"`
+	Items       []bitwardenItem   `json:"items"`
+}
+
+// bitwardenFolder is a folder or a collection of a Bitwarden export.
+type bitwardenFolder struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// bitwardenItem is an item of a Bitwarden export.
+type bitwardenItem struct {
+	Type          int      `json:"type"`
+	Name          string   `json:"name"`
+	Notes         *string  `json:"notes"`
+	FolderID      *string  `json:"folderId"`
+	CollectionIDs []string `json:"collectionIds"`
+	Fields        []struct {
+		Name  string  `json:"name"`
+		Value *string `json:"value"`
+		Type  int     `json:"type"`
+	} `json:"fields"`
+	Login *struct {
+		Username *string `json:"username"`
+		Password *string `json:"password"`
+		TOTP     *string `json:"totp"`
+		URIs     []struct {
+			URI *string `json:"uri"`
+		} `json:"uris"`
+	} `json:"login"`
+	Card     map[string]any `json:"card"`
+	Identity map[string]any `json:"identity"`
+}
+
+// ReadBitwardenJSON reads the entries of an unencrypted Bitwarden JSON
+// export. Folders and collections are used as labels. Text and boolean
+// custom fields are used as fields, hidden custom fields, the TOTP and
+// the details of cards and identities are used as hidden fields.
+func ReadBitwardenJSON(r io.Reader) ([]Entry, error) {
+	var export bitwardenExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, err
+	}
+	if export.Encrypted {
+		return nil, ErrEncryptedExport
+	}
+
+	folders := make(map[string]string, len(export.Folders)+len(export.Collections))
+	for _, f := range append(export.Folders, export.Collections...) {
+		folders[f.ID] = f.Name
+	}
+
+	entries := make([]Entry, 0, len(export.Items))
+	for _, item := range export.Items {
+		entry := Entry{
+			Name:  item.Name,
+			Notes: deref(item.Notes),
+		}
+		if item.FolderID != nil && len(folders[*item.FolderID]) > 0 {
+			entry.Labels = append(entry.Labels, folders[*item.FolderID])
+		}
+		for _, id := range item.CollectionIDs {
+			if len(folders[id]) > 0 {
+				entry.Labels = append(entry.Labels, folders[id])
+			}
+		}
+
+		for _, field := range item.Fields {
+			value := deref(field.Value)
+			if len(field.Name) == 0 || len(value) == 0 {
+				continue
+			}
+			switch field.Type {
+			case bitwardenFieldText, bitwardenFieldBoolean:
+				entry.Fields = setField(entry.Fields, field.Name, value)
+			case bitwardenFieldHidden:
+				entry.Hidden = setField(entry.Hidden, field.Name, value)
+			}
+		}
+
+		switch item.Type {
+		case bitwardenLogin:
+			if item.Login == nil {
+				break
+			}
+			entry.Username = deref(item.Login.Username)
+			entry.Password = deref(item.Login.Password)
+			if totp := deref(item.Login.TOTP); len(totp) > 0 {
+				entry.Hidden = setField(entry.Hidden, "totp", totp)
+			}
+			if len(item.Login.URIs) > 0 {
+				entry.URL = deref(item.Login.URIs[0].URI)
+			}
+		case bitwardenCard:
+			entry.Hidden = setFields(entry.Hidden, item.Card)
+		case bitwardenIdentity:
+			entry.Hidden = setFields(entry.Hidden, item.Identity)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// setFields sets the non-empty values as fields in the map.
+func setFields(fields map[string]string, values map[string]any) map[string]string {
+	for k, v := range values {
+		if v == nil {
+			continue
+		}
+		if s := fmt.Sprint(v); len(s) > 0 {
+			fields = setField(fields, k, s)
+		}
+	}
+	return fields
+}
+
+// deref returns the value of s, or an empty string if s is nil.
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,151 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+)
+
+// column sets the value of a column to the entry.
+type column func(entry *Entry, value string)
+
+// ignore is a column that is not imported.
+var ignore column = func(entry *Entry, value string) {}
+
+// onePasswordColumns are the columns of a 1Password CSV export.
+var onePasswordColumns = map[string]column{
+	"title":          setName,
+	"name":           setName,
+	"url":            setURL,
+	"urls":           setURL,
+	"website":        setURL,
+	"login_uri":      setURL,
+	"username":       setUsername,
+	"login_username": setUsername,
+	"password":       setPassword,
+	"login_password": setPassword,
+	"notes":          setNotes,
+	"notesplain":     setNotes,
+	"otpauth":        setHidden("otp"),
+	"vault":          addLabels,
+	"tags":           addLabels,
+	"favorite":       ignore,
+	"archived":       ignore,
+}
+
+// lastPassColumns are the columns of a LastPass CSV export.
+var lastPassColumns = map[string]column{
+	"name":     setName,
+	"url":      setLastPassURL,
+	"username": setUsername,
+	"password": setPassword,
+	"extra":    setNotes,
+	"totp":     setHidden("totp"),
+	"grouping": setLastPassGrouping,
+	"fav":      ignore,
+}
+
+// Read1PasswordCSV reads the entries of a 1Password CSV export. The
+// vault and tags are used as labels, and the columns that are not
+// known are used as fields.
+func Read1PasswordCSV(r io.Reader) ([]Entry, error) {
+	return readCSV(r, onePasswordColumns)
+}
+
+// ReadLastPassCSV reads the entries of a LastPass CSV export. The
+// grouping (folder) is used as label, and the columns that are not
+// known are used as fields.
+func ReadLastPassCSV(r io.Reader) ([]Entry, error) {
+	return readCSV(r, lastPassColumns)
+}
+
+// readCSV reads the entries of a CSV export with a header. The columns
+// are matched case-insensitively.
+func readCSV(r io.Reader, columns map[string]column) ([]Entry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	setters := make([]column, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		if c, ok := columns[strings.ToLower(name)]; ok {
+			setters[i] = c
+		} else {
+			setters[i] = setCustom(name)
+		}
+	}
+
+	var entries []Entry
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		var entry Entry
+		for i, value := range record {
+			if i < len(setters) && len(value) > 0 {
+				setters[i](&entry, value)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func setName(entry *Entry, value string)     { entry.Name = value }
+func setURL(entry *Entry, value string)      { entry.URL = value }
+func setUsername(entry *Entry, value string) { entry.Username = value }
+func setPassword(entry *Entry, value string) { entry.Password = value }
+func setNotes(entry *Entry, value string)    { entry.Notes = value }
+
+// addLabels adds the comma or semicolon separated labels.
+func addLabels(entry *Entry, value string) {
+	for _, label := range strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ';'
+	}) {
+		if label = strings.TrimSpace(label); len(label) > 0 {
+			entry.Labels = append(entry.Labels, label)
+		}
+	}
+}
+
+// setHidden returns a column that sets a hidden field.
+func setHidden(key string) column {
+	return func(entry *Entry, value string) {
+		entry.Hidden = setField(entry.Hidden, key, value)
+	}
+}
+
+// setCustom returns a column that sets a field.
+func setCustom(key string) column {
+	return func(entry *Entry, value string) {
+		entry.Fields = setField(entry.Fields, key, value)
+	}
+}
+
+// setLastPassURL sets the URL. LastPass uses the URL http://sn
+// for secure notes.
+func setLastPassURL(entry *Entry, value string) {
+	if value != "http://sn" {
+		entry.URL = value
+	}
+}
+
+// setLastPassGrouping sets the grouping as label. Nested groupings
+// are separated with backslashes by LastPass, and with slashes in
+// the label.
+func setLastPassGrouping(entry *Entry, value string) {
+	entry.Labels = append(entry.Labels, strings.ReplaceAll(value, `\`, "/"))
+}
@@ -0,0 +1,294 @@
+// Package importer imports secrets from the exports of other
+// password managers.
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/KarlGW/secman/secret"
+)
+
+var (
+	// ErrInvalidFormat is returned when the format is not supported.
+	ErrInvalidFormat = errors.New("invalid import format")
+	// ErrInvalidPolicy is returned when the duplicate policy is not supported.
+	ErrInvalidPolicy = errors.New("invalid duplicate policy")
+	// ErrEncryptedExport is returned when the export is encrypted.
+	ErrEncryptedExport = errors.New("encrypted exports are not supported")
+)
+
+// Format is the format of an export.
+type Format string
+
+const (
+	// FormatKeePassXML is the XML export of KeePass.
+	FormatKeePassXML Format = "keepass-xml"
+	// FormatBitwardenJSON is the unencrypted JSON export of Bitwarden.
+	FormatBitwardenJSON Format = "bitwarden-json"
+	// Format1PasswordCSV is the CSV export of 1Password.
+	Format1PasswordCSV Format = "1password-csv"
+	// FormatLastPassCSV is the CSV export of LastPass.
+	FormatLastPassCSV Format = "lastpass-csv"
+	// FormatPassStore is a pass password store directory.
+	FormatPassStore Format = "pass-store"
+)
+
+// Formats contains the supported formats.
+var Formats = []Format{
+	FormatKeePassXML,
+	FormatBitwardenJSON,
+	Format1PasswordCSV,
+	FormatLastPassCSV,
+	FormatPassStore,
+}
+
+// ParseFormat parses a format.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrInvalidFormat, s)
+}
+
+// Policy is the policy for entries with the same name as an
+// existing secret.
+type Policy string
+
+const (
+	// PolicySkip skips duplicate entries.
+	PolicySkip Policy = "skip"
+	// PolicyRename imports duplicate entries with a numbered suffix.
+	PolicyRename Policy = "rename"
+)
+
+// ParsePolicy parses a policy.
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(s); p {
+	case PolicySkip, PolicyRename:
+		return p, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrInvalidPolicy, s)
+}
+
+// Entry is an entry of an export.
+type Entry struct {
+	Name     string
+	URL      string
+	Username string
+	Password string
+	Notes    string
+	// Labels are the folders (or groups) of the entry.
+	Labels []string
+	// Fields are custom fields. They are stored as tags.
+	Fields map[string]string
+	// Hidden are hidden custom fields. They are stored in the
+	// encrypted value.
+	Hidden map[string]string
+}
+
+// Read the export at path in the provided format.
+func Read(format Format, path string) ([]Entry, error) {
+	if format == FormatPassStore {
+		return ReadPassStore(path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch format {
+	case FormatKeePassXML:
+		return ReadKeePassXML(f)
+	case FormatBitwardenJSON:
+		return ReadBitwardenJSON(f)
+	case Format1PasswordCSV:
+		return Read1PasswordCSV(f)
+	case FormatLastPassCSV:
+		return ReadLastPassCSV(f)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrInvalidFormat, format)
+}
+
+// secret returns the value and options of the secret for the entry.
+// Entries with a URL or username are credentials with a structured value.
+// Entries with only notes are notes, and entries with only a password
+// are generic secrets.
+func (e Entry) secret() (string, []secret.SecretOption, error) {
+	var options []secret.SecretOption
+	if len(e.Labels) > 0 {
+		options = append(options, secret.WithLabels(e.Labels...))
+	}
+	if len(e.Fields) > 0 {
+		options = append(options, secret.WithTags(e.Fields))
+	}
+
+	credential := len(e.URL) > 0 || len(e.Username) > 0
+	switch {
+	case credential:
+		options = append(options, secret.WithType(secret.TypeCredential))
+	case len(e.Hidden) == 0 && len(e.Password) == 0:
+		options = append(options, secret.WithType(secret.TypeNote))
+		return e.Notes, options, nil
+	case len(e.Hidden) == 0 && len(e.Notes) == 0:
+		options = append(options, secret.WithType(secret.TypeGeneric))
+		return e.Password, options, nil
+	default:
+		options = append(options, secret.WithType(secret.TypeGeneric))
+	}
+
+	fields := maps.Clone(e.Hidden)
+	if fields == nil {
+		fields = make(map[string]string)
+	}
+	for k, v := range map[string]string{
+		"url":      e.URL,
+		"username": e.Username,
+		"password": e.Password,
+		"notes":    e.Notes,
+	} {
+		if len(v) > 0 {
+			fields[k] = v
+		}
+	}
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(b), options, nil
+}
+
+// Handler is the interface that wraps around the methods of
+// secret.Handler used for imports.
+type Handler interface {
+	GetSecretByName(name string) (secret.Secret, error)
+	AddSecret(name, value string, options ...secret.SecretOption) (secret.Secret, error)
+}
+
+// Rename is an entry imported with a new name.
+type Rename struct {
+	From string
+	To   string
+}
+
+// Skip is an entry that was not imported.
+type Skip struct {
+	Name   string
+	Reason string
+}
+
+// Result contains the result of an import.
+type Result struct {
+	Imported []string
+	Renamed  []Rename
+	Skipped  []Skip
+}
+
+// Options contains options for imports.
+type Options struct {
+	Policy Policy
+}
+
+// Option is a function that sets options for imports.
+type Option func(o *Options)
+
+// Import the entries as secrets with the handler. Entries with the
+// same name as an existing secret are skipped or renamed depending
+// on the policy. Entries without a value are skipped. Entries are
+// imported even if a post-hook fails, and the failures are returned
+// after all entries.
+func Import(handler Handler, entries []Entry, options ...Option) (Result, error) {
+	opts := Options{
+		Policy: PolicySkip,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	var result Result
+	var hookErrs []error
+	for _, entry := range entries {
+		name := strings.TrimSpace(entry.Name)
+		if len(name) == 0 {
+			name = "untitled"
+		}
+		value, secretOptions, err := entry.secret()
+		if err != nil {
+			return result, err
+		}
+		if len(value) == 0 {
+			result.Skipped = append(result.Skipped, Skip{Name: name, Reason: "no value"})
+			continue
+		}
+
+		exists, err := nameExists(handler, name)
+		if err != nil {
+			return result, err
+		}
+		if exists {
+			if opts.Policy != PolicyRename {
+				result.Skipped = append(result.Skipped, Skip{Name: name, Reason: "duplicate"})
+				continue
+			}
+			renamed, err := uniqueName(handler, name)
+			if err != nil {
+				return result, err
+			}
+			result.Renamed = append(result.Renamed, Rename{From: name, To: renamed})
+			name = renamed
+		}
+
+		if _, err := handler.AddSecret(name, value, secretOptions...); err != nil {
+			// The secret is saved even if a post-hook fails.
+			if !errors.Is(err, secret.ErrPostHook) {
+				return result, fmt.Errorf("importing %s: %w", name, err)
+			}
+			hookErrs = append(hookErrs, fmt.Errorf("importing %s: %w", name, err))
+		}
+		result.Imported = append(result.Imported, name)
+	}
+	return result, errors.Join(hookErrs...)
+}
+
+// WithPolicy sets the policy for duplicate entries.
+func WithPolicy(policy Policy) Option {
+	return func(o *Options) {
+		o.Policy = policy
+	}
+}
+
+// nameExists reports whether a secret with the name exists.
+func nameExists(handler Handler, name string) (bool, error) {
+	_, err := handler.GetSecretByName(name)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, secret.ErrSecretNotFound) {
+		return false, nil
+	}
+	return false, err
+}
+
+// uniqueName returns the name with the first numbered suffix
+// that is not used by a secret.
+func uniqueName(handler Handler, name string) (string, error) {
+	for i := 2; ; i++ {
+		candidate := name + "-" + strconv.Itoa(i)
+		exists, err := nameExists(handler, candidate)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package importer
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KarlGW/secman/internal/security"
+	"github.com/KarlGW/secman/secret"
+	"github.com/KarlGW/secman/storage"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestReadKeePassXML(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   string
+		want    []Entry
+		wantErr error
+	}{
+		{
+			name: "groups and custom strings",
+			input: `<KeePassFile><Root><Group><Name>Database</Name>
+<Entry><String><Key>Title</Key><Value>root</Value></String><String><Key>Password</Key><Value ProtectInMemory="True">pass</Value></String></Entry>
+<Group><Name>Work</Name><Group><Name>Servers</Name>
+<Entry>
+<String><Key>Title</Key><Value>db</Value></String>
+<String><Key>UserName</Key><Value>admin</Value></String>
+<String><Key>Password</Key><Value ProtectInMemory="True">secret</Value></String>
+<String><Key>URL</Key><Value>https://db.example.com</Value></String>
+<String><Key>Notes</Key><Value>note</Value></String>
+<String><Key>env</Key><Value>prod</Value></String>
+<String><Key>pin</Key><Value ProtectInMemory="True">1234</Value></String>
+<History><Entry><String><Key>Title</Key><Value>old</Value></String></Entry></History>
+</Entry>
+</Group></Group></Group></Root></KeePassFile>`,
+			want: []Entry{
+				{Name: "root", Password: "pass"},
+				{
+					Name:     "db",
+					URL:      "https://db.example.com",
+					Username: "admin",
+					Password: "secret",
+					Notes:    "note",
+					Labels:   []string{"Work/Servers"},
+					Fields:   map[string]string{"env": "prod"},
+					Hidden:   map[string]string{"pin": "1234"},
+				},
+			},
+		},
+		{
+			name:    "protected values",
+			input:   `<KeePassFile><Root><Group><Entry><String><Key>Password</Key><Value Protected="True">abc=</Value></String></Entry></Group></Root></KeePassFile>`,
+			wantErr: ErrEncryptedExport,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := ReadKeePassXML(strings.NewReader(test.input))
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("ReadKeePassXML() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("ReadKeePassXML() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestReadBitwardenJSON(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   string
+		want    []Entry
+		wantErr error
+	}{
+		{
+			name: "items",
+			input: `{
+  "encrypted": false,
+  "folders": [{"id": "f1", "name": "Work"}],
+  "items": [
+    {
+      "type": 1, "name": "github", "folderId": "f1", "notes": null,
+      "fields": [{"name": "env", "value": "prod", "type": 0}, {"name": "pin", "value": "1234", "type": 1}],
+      "login": {"username": "user", "password": "pass", "totp": "otpauth://totp/x", "uris": [{"uri": "https://github.com"}]}
+    },
+    {"type": 2, "name": "note", "folderId": null, "notes": "text", "secureNote": {"type": 0}},
+    {"type": 3, "name": "card", "card": {"number": "4111", "code": "123", "brand": null}}
+  ]
+}`,
+			want: []Entry{
+				{
+					Name:     "github",
+					URL:      "https://github.com",
+					Username: "user",
+					Password: "pass",
+					Labels:   []string{"Work"},
+					Fields:   map[string]string{"env": "prod"},
+					Hidden:   map[string]string{"pin": "1234", "totp": "otpauth://totp/x"},
+				},
+				{Name: "note", Notes: "text"},
+				{Name: "card", Hidden: map[string]string{"number": "4111", "code": "123"}},
+			},
+		},
+		{
+			name:    "encrypted",
+			input:   `{"encrypted": true, "items": []}`,
+			wantErr: ErrEncryptedExport,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := ReadBitwardenJSON(strings.NewReader(test.input))
+
+			if diff := cmp.Diff(test.want, got, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("ReadBitwardenJSON() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("ReadBitwardenJSON() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			format Format
+			data   string
+		}
+		want []Entry
+	}{
+		{
+			name: "1password",
+			input: struct {
+				format Format
+				data   string
+			}{
+				format: Format1PasswordCSV,
+				data:   "\ufeffTitle,Url,Username,Password,OTPAuth,Favorite,Archived,Tags,Notes,Env\ngithub,https://github.com,user,pass,otpauth://totp/x,true,false,\"work,dev\",note,prod\n",
+			},
+			want: []Entry{
+				{
+					Name:     "github",
+					URL:      "https://github.com",
+					Username: "user",
+					Password: "pass",
+					Notes:    "note",
+					Labels:   []string{"work", "dev"},
+					Fields:   map[string]string{"Env": "prod"},
+					Hidden:   map[string]string{"otp": "otpauth://totp/x"},
+				},
+			},
+		},
+		{
+			name: "lastpass",
+			input: struct {
+				format Format
+				data   string
+			}{
+				format: FormatLastPassCSV,
+				data:   "url,username,password,totp,extra,name,grouping,fav\nhttps://github.com,user,pass,,,github,Work\\Dev,0\nhttp://sn,,,,secret note,note,,1\n",
+			},
+			want: []Entry{
+				{
+					Name:     "github",
+					URL:      "https://github.com",
+					Username: "user",
+					Password: "pass",
+					Labels:   []string{"Work/Dev"},
+				},
+				{Name: "note", Notes: "secret note"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []Entry
+			var gotErr error
+			if test.input.format == Format1PasswordCSV {
+				got, gotErr = Read1PasswordCSV(strings.NewReader(test.input.data))
+			} else {
+				got, gotErr = ReadLastPassCSV(strings.NewReader(test.input.data))
+			}
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("readCSV() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if gotErr != nil {
+				t.Errorf("readCSV() = unexpected error: %v\n", gotErr)
+			}
+		})
+	}
+}
+
+func TestReadPassStore(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"email/github.gpg": "pass\nlogin: user\nurl: https://github.com\nenv: prod\notpauth://totp/x\nsome note\n",
+		"wifi.gpg":         "wifi-pass\n",
+		".git/config":      "ignored",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	decrypt = os.ReadFile
+	t.Cleanup(func() {
+		decrypt = gpgDecrypt
+	})
+
+	got, gotErr := ReadPassStore(dir)
+	want := []Entry{
+		{
+			Name:     "github",
+			URL:      "https://github.com",
+			Username: "user",
+			Password: "pass",
+			Notes:    "some note",
+			Labels:   []string{"email"},
+			Fields:   map[string]string{"env": "prod"},
+			Hidden:   map[string]string{"otp": "otpauth://totp/x"},
+		},
+		{Name: "wifi", Password: "wifi-pass"},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ReadPassStore() = unexpected result (-want +got)\n%s\n", diff)
+	}
+
+	if gotErr != nil {
+		t.Errorf("ReadPassStore() = unexpected error: %v\n", gotErr)
+	}
+}
+
+func TestImport(t *testing.T) {
+	entries := []Entry{
+		{Name: "github", URL: "https://github.com", Username: "user", Password: "pass", Labels: []string{"work"}, Fields: map[string]string{"env": "prod"}},
+		{Name: "note", Notes: "text"},
+		{Name: "existing", Password: "other"},
+		{Name: "empty"},
+	}
+
+	var tests = []struct {
+		name  string
+		input Policy
+		want  Result
+	}{
+		{
+			name:  "skip",
+			input: PolicySkip,
+			want: Result{
+				Imported: []string{"github", "note"},
+				Skipped:  []Skip{{Name: "existing", Reason: "duplicate"}, {Name: "empty", Reason: "no value"}},
+			},
+		},
+		{
+			name:  "rename",
+			input: PolicyRename,
+			want: Result{
+				Imported: []string{"github", "note", "existing-3"},
+				Renamed:  []Rename{{From: "existing", To: "existing-3"}},
+				Skipped:  []Skip{{Name: "empty", Reason: "no value"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := setupHandler(t)
+			for _, name := range []string{"existing", "existing-2"} {
+				if _, err := handler.AddSecret(name, "value"); err != nil {
+					t.Fatalf("AddSecret() = unexpected error: %v\n", err)
+				}
+			}
+
+			got, gotErr := Import(handler, entries, WithPolicy(test.input))
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Import() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if gotErr != nil {
+				t.Errorf("Import() = unexpected error: %v\n", gotErr)
+			}
+		})
+	}
+}
+
+func TestImport_Secret(t *testing.T) {
+	handler := setupHandler(t)
+	entries := []Entry{
+		{Name: "github", URL: "https://github.com", Username: "user", Password: "pass", Labels: []string{"work"}, Fields: map[string]string{"env": "prod"}, Hidden: map[string]string{"pin": "1234"}},
+	}
+	if _, err := Import(handler, entries); err != nil {
+		t.Fatalf("Import() = unexpected error: %v\n", err)
+	}
+
+	s, err := handler.GetSecretByName("github")
+	if err != nil {
+		t.Fatalf("GetSecretByName() = unexpected error: %v\n", err)
+	}
+	fields, err := s.Fields()
+	if err != nil {
+		t.Fatalf("Fields() = unexpected error: %v\n", err)
+	}
+
+	want := map[string]string{"url": "https://github.com", "username": "user", "password": "pass", "pin": "1234"}
+	if diff := cmp.Diff(want, fields); diff != "" {
+		t.Errorf("Import() = unexpected fields (-want +got)\n%s\n", diff)
+	}
+	if s.Type != secret.TypeCredential || !cmp.Equal(s.Labels, []string{"work"}) || s.Tags["env"] != "prod" {
+		t.Errorf("Import() = unexpected metadata: type %s, labels %v, tags %v\n", s.Type, s.Labels, s.Tags)
+	}
+}
+
+func setupHandler(t *testing.T) *secret.Handler {
+	t.Helper()
+	key := security.Key{Value: bytes.Repeat([]byte{1}, secret.KeyLength)}
+	handler, err := secret.NewHandler("profile", key, key, storage.NewMemory(nil))
+	if err != nil {
+		t.Fatalf("NewHandler() = unexpected error: %v\n", err)
+	}
+	return handler
+}
@@ -0,0 +1,112 @@
+package importer
+
+import (
+	"encoding/xml"
+	"io"
+	"path"
+	"strings"
+)
+
+// keepassFile is the XML export of KeePass.
+type keepassFile struct {
+	Root struct {
+		Groups []keepassGroup `xml:"Group"`
+	} `xml:"Root"`
+}
+
+// keepassGroup is a group of a KeePass database.
+type keepassGroup struct {
+	Name    string         `xml:"Name"`
+	Entries []keepassEntry `xml:"Entry"`
+	Groups  []keepassGroup `xml:"Group"`
+}
+
+// keepassEntry is an entry of a KeePass database. The history of
+// an entry is not imported.
+type keepassEntry struct {
+	Strings []struct {
+		Key   string `xml:"Key"`
+		Value struct {
+			Value           string `xml:",chardata"`
+			Protected       string `xml:"Protected,attr"`
+			ProtectInMemory string `xml:"ProtectInMemory,attr"`
+		} `xml:"Value"`
+	} `xml:"String"`
+}
+
+// ReadKeePassXML reads the entries of a KeePass XML export. The groups
+// below the root group are used as labels. Custom strings are used as
+// fields, or as hidden fields if they are protected.
+func ReadKeePassXML(r io.Reader) ([]Entry, error) {
+	var file keepassFile
+	if err := xml.NewDecoder(r).Decode(&file); err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, root := range file.Root.Groups {
+		e, err := keepassEntries(root, "")
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e...)
+	}
+	return entries, nil
+}
+
+// keepassEntries returns the entries of the group and its subgroups.
+func keepassEntries(group keepassGroup, folder string) ([]Entry, error) {
+	var entries []Entry
+	for _, ke := range group.Entries {
+		entry := Entry{}
+		if len(folder) > 0 {
+			entry.Labels = []string{folder}
+		}
+		for _, s := range ke.Strings {
+			if strings.EqualFold(s.Value.Protected, "true") {
+				return nil, ErrEncryptedExport
+			}
+			value := s.Value.Value
+			switch s.Key {
+			case "Title":
+				entry.Name = value
+			case "UserName":
+				entry.Username = value
+			case "Password":
+				entry.Password = value
+			case "URL":
+				entry.URL = value
+			case "Notes":
+				entry.Notes = value
+			default:
+				if len(value) == 0 {
+					continue
+				}
+				if strings.EqualFold(s.Value.ProtectInMemory, "true") {
+					entry.Hidden = setField(entry.Hidden, s.Key, value)
+				} else {
+					entry.Fields = setField(entry.Fields, s.Key, value)
+				}
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	for _, g := range group.Groups {
+		e, err := keepassEntries(g, path.Join(folder, g.Name))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e...)
+	}
+	return entries, nil
+}
+
+// setField sets the field in the map, and creates the map if needed.
+func setField(fields map[string]string, key, value string) map[string]string {
+	if fields == nil {
+		fields = make(map[string]string)
+	}
+	fields[key] = value
+	return fields
+}
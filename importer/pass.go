@@ -0,0 +1,101 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// decrypt decrypts a file of a pass password store.
+var decrypt = gpgDecrypt
+
+// gpgDecrypt decrypts the file with gpg.
+func gpgDecrypt(name string) ([]byte, error) {
+	cmd := exec.Command("gpg", "--quiet", "--yes", "--decrypt", name)
+	cmd.Stdin, cmd.Stderr = os.Stdin, os.Stderr
+	return cmd.Output()
+}
+
+// ReadPassStore reads the entries of a pass password store. The files
+// are decrypted with gpg. The directory of an entry is used as label.
+// The first line of a file is the password, and the following lines
+// in the format key: value are used as fields. The keys login, username
+// and user set the username, and url and website set the URL. Other
+// lines are used as notes.
+func ReadPassStore(dir string) ([]Entry, error) {
+	var entries []Entry
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(p) != ".gpg" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		b, err := decrypt(p)
+		if err != nil {
+			return err
+		}
+		entry := parsePass(b)
+		rel = filepath.ToSlash(strings.TrimSuffix(rel, ".gpg"))
+		entry.Name = path.Base(rel)
+		if folder := path.Dir(rel); folder != "." {
+			entry.Labels = []string{folder}
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// parsePass parses the decrypted content of a pass file.
+func parsePass(b []byte) Entry {
+	var entry Entry
+	var notes []string
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for i := 0; scanner.Scan(); i++ {
+		line := scanner.Text()
+		if i == 0 {
+			entry.Password = line
+			continue
+		}
+		if strings.HasPrefix(line, "otpauth://") {
+			entry.Hidden = setField(entry.Hidden, "otp", line)
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || len(key) == 0 || strings.ContainsAny(key, " \t") || len(value) == 0 {
+			notes = append(notes, line)
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "login", "username", "user":
+			entry.Username = value
+		case "url", "website":
+			entry.URL = value
+		default:
+			entry.Fields = setField(entry.Fields, key, value)
+		}
+	}
+	entry.Notes = strings.TrimSpace(strings.Join(notes, "\n"))
+	return entry
+}
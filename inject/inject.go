@@ -0,0 +1,64 @@
+// Package inject renders templates with references to secrets.
+package inject
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"text/template"
+
+	"github.com/KarlGW/secman/secret"
+)
+
+// Resolver is the interface that wraps around method GetSecretByReference.
+type Resolver interface {
+	GetSecretByReference(ref string) (secret.Secret, error)
+}
+
+// Render parses the provided template text and renders it to w. The template
+// is a Go text/template with the following functions:
+//
+//	secret <reference>          the decrypted value of a secret
+//	field <reference> <field>   the value of a field of a structured secret
+//	base64 <value>              the base64 encoding of a value
+//
+// A reference is a name or ID, or one of @id:<id>, @name:<name> or
+// @label:<label>. References that cannot be resolved, and missing map
+// keys, fail the rendering.
+func Render(w io.Writer, name, text string, resolver Resolver) error {
+	tmpl, err := template.New(name).
+		Option("missingkey=error").
+		Funcs(funcs(resolver)).
+		Parse(text)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, nil)
+}
+
+// funcs returns the template functions for the resolver.
+func funcs(resolver Resolver) template.FuncMap {
+	return template.FuncMap{
+		"secret": func(ref string) (string, error) {
+			s, err := resolver.GetSecretByReference(ref)
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", ref, err)
+			}
+			decrypted, err := s.Decrypt()
+			if err != nil {
+				return "", err
+			}
+			return string(decrypted), nil
+		},
+		"field": func(ref, name string) (string, error) {
+			s, err := resolver.GetSecretByReference(ref)
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", ref, err)
+			}
+			return s.Field(name)
+		},
+		"base64": func(value string) string {
+			return base64.StdEncoding.EncodeToString([]byte(value))
+		},
+	}
+}
@@ -0,0 +1,90 @@
+package inject
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/KarlGW/secman/internal/security"
+	"github.com/KarlGW/secman/secret"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestRender(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "render secret",
+			input: `password: {{ secret "db" }}`,
+			want:  `password: value`,
+		},
+		{
+			name:  "render field",
+			input: `username: {{ field "credential" "username" }}`,
+			want:  `username: user`,
+		},
+		{
+			name:  "render base64",
+			input: `tls: {{ base64 (secret "db") }}`,
+			want:  `tls: dmFsdWU=`,
+		},
+		{
+			name:    "missing secret",
+			input:   `password: {{ secret "missing" }}`,
+			wantErr: secret.ErrSecretNotFound,
+		},
+		{
+			name:    "missing field",
+			input:   `username: {{ field "credential" "missing" }}`,
+			wantErr: secret.ErrFieldNotFound,
+		},
+		{
+			name:    "field on unstructured secret",
+			input:   `username: {{ field "db" "username" }}`,
+			wantErr: secret.ErrNotStructured,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			gotErr := Render(&buf, "test", test.input, _testResolver)
+			got := buf.String()
+			if gotErr != nil {
+				got = ""
+			}
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Render() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Render() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+type mockResolver map[string]secret.Secret
+
+func (m mockResolver) GetSecretByReference(ref string) (secret.Secret, error) {
+	s, ok := m[ref]
+	if !ok {
+		return secret.Secret{}, secret.ErrSecretNotFound
+	}
+	return s, nil
+}
+
+var (
+	_testKey, _        = security.NewKey()
+	_testSecret, _     = secret.NewSecret("db", "value", _testKey.Value)
+	_testCredential, _ = secret.NewSecret("credential", `{"username":"user","password":"pass"}`, _testKey.Value)
+	_testResolver      = mockResolver{
+		"db":         _testSecret,
+		"credential": _testCredential,
+	}
+)
@@ -0,0 +1,69 @@
+package clipboard
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"time"
+
+	"github.com/atotto/clipboard"
+)
+
+const (
+	// DefaultTimeout is the default duration before the clipboard
+	// is cleared.
+	DefaultTimeout = 45 * time.Second
+	// keyLength is the length of the random key of a sum.
+	keyLength = 32
+	// SumLength is the length of a sum, the key followed by the MAC.
+	SumLength = keyLength + sha256.Size
+)
+
+// ErrInvalidSum is returned when a sum has an invalid length.
+var ErrInvalidSum = errors.New("invalid sum")
+
+var (
+	// readAll reads from the clipboard.
+	readAll = clipboard.ReadAll
+	// writeAll writes to the clipboard.
+	writeAll = clipboard.WriteAll
+)
+
+// Sum returns a random key followed by the HMAC-SHA256 of the value
+// with the key. A new key is generated for every sum, so sums of the
+// same value differ.
+func Sum(value string) ([]byte, error) {
+	key := make([]byte, keyLength)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return append(key, mac(key, value)...), nil
+}
+
+// ClearIfMatch clears the clipboard if its content matches the
+// provided sum. Returns true if the clipboard was cleared.
+func ClearIfMatch(sum []byte) (bool, error) {
+	if len(sum) != SumLength {
+		return false, ErrInvalidSum
+	}
+	value, err := readAll()
+	if err != nil {
+		return false, err
+	}
+	key, expected := sum[:keyLength], sum[keyLength:]
+	if !hmac.Equal(mac(key, value), expected) {
+		return false, nil
+	}
+	if err := writeAll(""); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// mac returns the HMAC-SHA256 of the value with the key.
+func mac(key []byte, value string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(value))
+	return h.Sum(nil)
+}
@@ -0,0 +1,84 @@
+package clipboard
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClearIfMatch(t *testing.T) {
+	sum, err := Sum("secret")
+	if err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+
+	var tests = []struct {
+		name  string
+		input struct {
+			content string
+			sum     []byte
+		}
+		want        bool
+		wantContent string
+	}{
+		{
+			name: "clipboard holds value",
+			input: struct {
+				content string
+				sum     []byte
+			}{
+				content: "secret",
+				sum:     sum,
+			},
+			want:        true,
+			wantContent: "",
+		},
+		{
+			name: "clipboard holds other value",
+			input: struct {
+				content string
+				sum     []byte
+			}{
+				content: "other",
+				sum:     sum,
+			},
+			want:        false,
+			wantContent: "other",
+		},
+		{
+			name: "invalid sum",
+			input: struct {
+				content string
+				sum     []byte
+			}{
+				content: "secret",
+				sum:     sum[:SumLength-1],
+			},
+			want:        false,
+			wantContent: "secret",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			content := test.input.content
+			readAll = func() (string, error) {
+				return content, nil
+			}
+			writeAll = func(text string) error {
+				content = text
+				return nil
+			}
+
+			got, _ := ClearIfMatch(test.input.sum)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("ClearIfMatch() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantContent, content); diff != "" {
+				t.Errorf("ClearIfMatch() = unexpected clipboard content (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
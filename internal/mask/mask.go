@@ -0,0 +1,99 @@
+// Package mask contains a writer that masks values written to it.
+package mask
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"sync"
+)
+
+// Mask is the replacement for masked values.
+const Mask = "********"
+
+// Writer masks values written to it before writing to the underlying
+// writer. Data that could be the start of a value is held back until
+// it can be determined, or until the writer is flushed.
+type Writer struct {
+	w       io.Writer
+	values  [][]byte
+	maxLen  int
+	pending []byte
+	mu      sync.Mutex
+}
+
+// NewWriter creates and returns a new Writer that masks the provided
+// values. Empty values are ignored.
+func NewWriter(w io.Writer, values ...[]byte) *Writer {
+	var vals [][]byte
+	var maxLen int
+	for _, v := range values {
+		if len(v) == 0 {
+			continue
+		}
+		vals = append(vals, v)
+		maxLen = max(maxLen, len(v))
+	}
+	// Match longer values first, in case a value contains another.
+	slices.SortFunc(vals, func(a, b []byte) int {
+		return len(b) - len(a)
+	})
+	return &Writer{w: w, values: vals, maxLen: maxLen}
+}
+
+// Write masks and writes the data. The returned count is the length of
+// the provided data when successful.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.values) == 0 {
+		return w.w.Write(p)
+	}
+
+	buf := append(w.pending, p...)
+	var out bytes.Buffer
+	i := 0
+scan:
+	for i < len(buf) {
+		for _, v := range w.values {
+			if bytes.HasPrefix(buf[i:], v) {
+				out.WriteString(Mask)
+				i += len(v)
+				continue scan
+			}
+		}
+		if len(buf)-i < w.maxLen && w.partial(buf[i:]) {
+			break
+		}
+		out.WriteByte(buf[i])
+		i++
+	}
+	w.pending = slices.Clone(buf[i:])
+
+	if _, err := w.w.Write(out.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush writes held back data to the underlying writer.
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.pending) == 0 {
+		return nil
+	}
+	_, err := w.w.Write(w.pending)
+	w.pending = nil
+	return err
+}
+
+// partial returns true if the data is the start of any of the values.
+func (w *Writer) partial(b []byte) bool {
+	for _, v := range w.values {
+		if len(b) < len(v) && bytes.HasPrefix(v, b) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package mask
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriter(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			values [][]byte
+			writes []string
+		}
+		want string
+	}{
+		{
+			name: "mask value",
+			input: struct {
+				values [][]byte
+				writes []string
+			}{
+				values: [][]byte{[]byte("secret")},
+				writes: []string{"the secret is here\n"},
+			},
+			want: "the " + Mask + " is here\n",
+		},
+		{
+			name: "mask value split across writes",
+			input: struct {
+				values [][]byte
+				writes []string
+			}{
+				values: [][]byte{[]byte("secret")},
+				writes: []string{"the sec", "ret is here, se", "cond sec"},
+			},
+			want: "the " + Mask + " is here, second sec",
+		},
+		{
+			name: "mask several values",
+			input: struct {
+				values [][]byte
+				writes []string
+			}{
+				values: [][]byte{[]byte("pass"), []byte("password")},
+				writes: []string{"password and pass"},
+			},
+			want: Mask + " and " + Mask,
+		},
+		{
+			name: "no values",
+			input: struct {
+				values [][]byte
+				writes []string
+			}{
+				writes: []string{"the secret is here\n"},
+			},
+			want: "the secret is here\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := NewWriter(&buf, test.input.values...)
+			for _, s := range test.input.writes {
+				if _, err := w.Write([]byte(s)); err != nil {
+					t.Fatalf("unexpected error in test: %v\n", err)
+				}
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+
+			if diff := cmp.Diff(test.want, buf.String()); diff != "" {
+				t.Errorf("Write() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package process
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// Spawn starts the current executable with the provided arguments as a
+// process detached from the current session and returns its process ID.
+// The provided input, if any, is written to the stdin of the process.
+func Spawn(input []byte, args ...string) (int, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return 0, err
+	}
+	null, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer null.Close()
+
+	cmd := exec.Command(executable, args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = null, null, null
+	cmd.SysProcAttr = detached()
+	var stdin io.WriteCloser
+	if input != nil {
+		cmd.Stdin = nil
+		if stdin, err = cmd.StdinPipe(); err != nil {
+			return 0, err
+		}
+	}
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	if stdin != nil {
+		_, err := stdin.Write(input)
+		if err := errors.Join(err, stdin.Close()); err != nil {
+			cmd.Process.Kill()
+			return 0, err
+		}
+	}
+	pid := cmd.Process.Pid
+	return pid, cmd.Process.Release()
+}
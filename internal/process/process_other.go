@@ -0,0 +1,10 @@
+//go:build !unix
+
+package process
+
+import "syscall"
+
+// detached returns process attributes for the process.
+func detached() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{}
+}
@@ -0,0 +1,11 @@
+//go:build unix
+
+package process
+
+import "syscall"
+
+// detached returns process attributes that detaches a process
+// from the current session.
+func detached() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
@@ -11,10 +11,9 @@ import (
 	"crypto/subtle"
 	"encoding/base64"
 	"errors"
+	"fmt"
 	"io"
 	"strconv"
-
-	"golang.org/x/crypto/argon2"
 )
 
 var (
@@ -39,6 +38,13 @@ var (
 
 // Encrypt data with 256-bit AES-GCM encryption using the given key.
 func Encrypt(b []byte, key []byte) ([]byte, error) {
+	return EncryptWithAdditionalData(b, key, nil)
+}
+
+// EncryptWithAdditionalData encrypts data with 256-bit AES-GCM encryption
+// using the given key. The additional data is authenticated but not
+// encrypted, and must be provided to decrypt the data.
+func EncryptWithAdditionalData(b, key, additionalData []byte) ([]byte, error) {
 	if len(key) != KeyLength {
 		return nil, ErrInvalidKeyLength
 	}
@@ -58,11 +64,17 @@ func Encrypt(b []byte, key []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	return gcm.Seal(nonce, nonce, b, nil), nil
+	return gcm.Seal(nonce, nonce, b, additionalData), nil
 }
 
 // Decrypt data encrypted with 256-bit AES-GCM encryption using the given key.
 func Decrypt(b []byte, key []byte) ([]byte, error) {
+	return DecryptWithAdditionalData(b, key, nil)
+}
+
+// DecryptWithAdditionalData decrypts data encrypted with 256-bit AES-GCM
+// encryption using the given key and additional data.
+func DecryptWithAdditionalData(b, key, additionalData []byte) ([]byte, error) {
 	if len(key) != KeyLength {
 		return nil, ErrInvalidKeyLength
 	}
@@ -85,7 +97,7 @@ func Decrypt(b []byte, key []byte) ([]byte, error) {
 		nil,
 		b[:gcm.NonceSize()],
 		b[gcm.NonceSize():],
-		nil,
+		additionalData,
 	)
 	if err != nil {
 		return nil, ErrInvalidKey
@@ -106,25 +118,57 @@ func NewKey() (Key, error) {
 	}
 
 	return Key{
-		Value: idKey(b, salt, KeyLength),
-		Salt:  salt,
+		Value:   idKey(b, salt, KeyLength, LegacyParams),
+		Salt:    salt,
+		Version: KeyVersion,
+		Params:  LegacyParams,
 	}, nil
 }
 
+// KeyOptions contains options for creating a key.
+type KeyOptions struct {
+	Params Params
+}
+
+// KeyOption is a function that sets KeyOptions.
+type KeyOption func(o *KeyOptions)
+
 // NewKeyFromPassword creates a new key from the provided password
-// using argon2id.
-func NewKeyFromPassword(password []byte) (Key, error) {
+// using argon2id. If no parameters are provided, DefaultParams
+// are used.
+func NewKeyFromPassword(password []byte, options ...KeyOption) (Key, error) {
+	opts := KeyOptions{
+		Params: DefaultParams,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+	if err := opts.Params.Validate(); err != nil {
+		return Key{}, err
+	}
+
 	salt, err := generateBytes(HashLength)
 	if err != nil {
 		return Key{}, err
 	}
 
 	return Key{
-		Value: idKey(password, salt, KeyLength),
-		Salt:  salt,
+		Value:   idKey(password, salt, KeyLength, opts.Params),
+		Salt:    salt,
+		Version: KeyVersion,
+		Params:  opts.Params,
 	}, nil
 }
 
+// WithParams sets the argon2id parameters for creating a key.
+func WithParams(params Params) KeyOption {
+	return func(o *KeyOptions) {
+		if !params.IsZero() {
+			o.Params = params
+		}
+	}
+}
+
 // NewSHA256FromPassword creates a new SHA256 from the provided
 // password.
 func NewSHA256FromPassword(password []byte) ([]byte, error) {
@@ -138,7 +182,7 @@ func NewSHA256FromPassword(password []byte) ([]byte, error) {
 // ComparePasswordAndKey compares the provided password with
 // the provided key.
 func ComparePasswordAndKey(password []byte, key Key) bool {
-	hash := idKey(password, key.Salt, KeyLength)
+	hash := idKey(password, key.Salt, KeyLength, key.KDFParams())
 	if len(hash) != len(key.Value) {
 		return false
 	}
@@ -146,13 +190,30 @@ func ComparePasswordAndKey(password []byte, key Key) bool {
 }
 
 // Key contains a hashed value and the salt used to hash
-// it.
+// it, together with the version and argon2id parameters
+// used to derive it.
 type Key struct {
 	Value, Salt []byte
+	// Version of the key. Keys without a version are
+	// of version 1.
+	Version uint8 `json:",omitempty"`
+	// Params used to derive the key. Only used for keys
+	// of version 2 and above.
+	Params Params
+}
+
+// KDFParams returns the argon2id parameters used to derive
+// the key.
+func (k Key) KDFParams() Params {
+	if k.Version < KeyVersion2 || k.Params.IsZero() {
+		return LegacyParams
+	}
+	return k.Params
 }
 
 // Encode the key for persistance.
-// Format: base64(salt)$base64(hash)
+// Format (version 1): base64(salt)$base64(hash)
+// Format (version 2): $argon2id$v=2$m=<memory>,t=<time>,p=<threads>$base64(salt)$base64(hash)
 func (k Key) Encode() []byte {
 	encodedSalt := make([]byte, base64.StdEncoding.EncodedLen(len(k.Salt)))
 	base64.StdEncoding.Encode(encodedSalt, k.Salt)
@@ -160,14 +221,34 @@ func (k Key) Encode() []byte {
 	encodedHash := make([]byte, base64.StdEncoding.EncodedLen(len(k.Value)))
 	base64.StdEncoding.Encode(encodedHash, k.Value)
 
-	return concatenate(encodedSalt, delimiter, encodedHash)
+	if k.Version < KeyVersion2 {
+		return concatenate(encodedSalt, delimiter, encodedHash)
+	}
+
+	header := fmt.Sprintf("$argon2id$v=%d$%s", k.Version, k.KDFParams())
+	return concatenate([]byte(header), delimiter, encodedSalt, delimiter, encodedHash)
 }
 
 // Decodes the provided bytes into a Key.
-// Format: base64(salt)$base64(hash)
+// Format (version 1): base64(salt)$base64(hash)
+// Format (version 2): $argon2id$v=2$m=<memory>,t=<time>,p=<threads>$base64(salt)$base64(hash)
 func (k *Key) Decode(b []byte) error {
 	parts := bytes.Split(b, delimiter)
-	if len(parts) != 2 {
+	switch len(parts) {
+	case 2:
+		k.Version, k.Params = KeyVersion1, Params{}
+	case 6:
+		if len(parts[0]) != 0 || string(parts[1]) != "argon2id" {
+			return errors.New("invalid data")
+		}
+		if _, err := fmt.Sscanf(string(parts[2]), "v=%d", &k.Version); err != nil {
+			return fmt.Errorf("invalid data: %w", err)
+		}
+		if _, err := fmt.Sscanf(string(parts[3]), "m=%d,t=%d,p=%d", &k.Params.Memory, &k.Params.Time, &k.Params.Threads); err != nil {
+			return fmt.Errorf("invalid data: %w", err)
+		}
+		parts = parts[4:]
+	default:
 		return errors.New("invalid data")
 	}
 	encodedSalt := parts[0]
@@ -213,10 +294,3 @@ func concatenate[S ~[]E, E any](elems ...S) S {
 	}
 	return result
 }
-
-// idKey is a convenience function that returns a key
-// using argon2id with set values for time, memory and
-// key length.
-func idKey(b, s []byte, l uint32) []byte {
-	return argon2.IDKey(b, s, 1, 1<<15, 1, l)
-}
@@ -63,6 +63,165 @@ func TestEncryptDecrypt(t *testing.T) {
 	}
 }
 
+func TestEncryptDecryptWithAdditionalData(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			encryptData []byte
+			decryptData []byte
+		}
+		want    []byte
+		wantErr error
+	}{
+		{
+			name: "Encrypt and decrypt data",
+			input: struct {
+				encryptData []byte
+				decryptData []byte
+			}{
+				encryptData: []byte(`header`),
+				decryptData: []byte(`header`),
+			},
+			want: []byte(`data`),
+		},
+		{
+			name: "Encrypt and decrypt data - error, modified additional data",
+			input: struct {
+				encryptData []byte
+				decryptData []byte
+			}{
+				encryptData: []byte(`header`),
+				decryptData: []byte(`modified`),
+			},
+			wantErr: ErrInvalidKey,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			enc, _ := EncryptWithAdditionalData([]byte(`data`), _testKey1.Value, test.input.encryptData)
+			got, gotErr := DecryptWithAdditionalData(enc, _testKey1.Value, test.input.decryptData)
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("EncryptWithAdditionalData() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("EncryptWithAdditionalData() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestKey_EncodeDecode(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   Key
+		want    Key
+		wantErr error
+	}{
+		{
+			name: "version 1",
+			input: Key{
+				Value: []byte(`value`),
+				Salt:  []byte(`salt`),
+			},
+			want: Key{
+				Value:   []byte(`value`),
+				Salt:    []byte(`salt`),
+				Version: KeyVersion1,
+			},
+		},
+		{
+			name: "version 2",
+			input: Key{
+				Value:   []byte(`value`),
+				Salt:    []byte(`salt`),
+				Version: KeyVersion2,
+				Params:  Params{Time: 2, Memory: 65536, Threads: 4},
+			},
+			want: Key{
+				Value:   []byte(`value`),
+				Salt:    []byte(`salt`),
+				Version: KeyVersion2,
+				Params:  Params{Time: 2, Memory: 65536, Threads: 4},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got Key
+			gotErr := got.Decode(test.input.Encode())
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Encode()/Decode() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Encode()/Decode() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestComparePasswordAndKey(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			password []byte
+			key      Key
+		}
+		want bool
+	}{
+		{
+			name: "valid password",
+			input: struct {
+				password []byte
+				key      Key
+			}{
+				password: []byte("key"),
+				key:      _testKey1,
+			},
+			want: true,
+		},
+		{
+			name: "valid password - legacy key",
+			input: struct {
+				password []byte
+				key      Key
+			}{
+				password: []byte("key"),
+				key: Key{
+					Value: idKey([]byte("key"), []byte("salt"), KeyLength, LegacyParams),
+					Salt:  []byte("salt"),
+				},
+			},
+			want: true,
+		},
+		{
+			name: "invalid password",
+			input: struct {
+				password []byte
+				key      Key
+			}{
+				password: []byte("wrongkey"),
+				key:      _testKey1,
+			},
+			want: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := ComparePasswordAndKey(test.input.password, test.input.key)
+
+			if test.want != got {
+				t.Errorf("ComparePasswordAndKey() = unexpected result, want: %v, got: %v\n", test.want, got)
+			}
+		})
+	}
+}
+
 var (
 	_testKey1, _ = NewKeyFromPassword([]byte("key"))
 	_testKey2, _ = NewKeyFromPassword([]byte("wrongkey"))
@@ -0,0 +1,161 @@
+package security
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	// KeyVersion1 is the version of keys derived with the fixed
+	// parameters of LegacyParams, which are not stored with the key.
+	KeyVersion1 uint8 = 1
+	// KeyVersion2 is the version of keys derived with parameters
+	// that are stored with the key.
+	KeyVersion2 uint8 = 2
+	// KeyVersion is the current version of keys.
+	KeyVersion = KeyVersion2
+)
+
+var (
+	// LegacyParams are the argon2id parameters used for keys
+	// of version 1.
+	LegacyParams = Params{Time: 1, Memory: 32 * 1024, Threads: 1}
+	// DefaultParams are the default argon2id parameters for new keys.
+	DefaultParams = Params{Time: 3, Memory: 64 * 1024, Threads: 4}
+	// MinParams are the lowest parameters accepted.
+	MinParams = Params{Time: 1, Memory: 8 * 1024, Threads: 1}
+	// MaxParams are the highest parameters accepted. They bound the
+	// resources used for parameters read from untrusted sources.
+	MaxParams = Params{Time: 256, Memory: 2 * 1024 * 1024, Threads: 64}
+)
+
+// ErrInvalidParams is returned when the provided argon2id parameters
+// are invalid.
+var ErrInvalidParams = errors.New("invalid key derivation parameters")
+
+// Params contains the parameters for argon2id key derivation.
+type Params struct {
+	// Time is the number of passes over the memory.
+	Time uint32 `json:"time,omitempty" yaml:"time,omitempty"`
+	// Memory is the size of the memory in KiB.
+	Memory uint32 `json:"memory,omitempty" yaml:"memory,omitempty"`
+	// Threads is the number of threads.
+	Threads uint8 `json:"threads,omitempty" yaml:"threads,omitempty"`
+}
+
+// IsZero returns true if no parameters are set.
+func (p Params) IsZero() bool {
+	return p == Params{}
+}
+
+// Validate the parameters.
+func (p Params) Validate() error {
+	if p.Time < MinParams.Time || p.Memory < MinParams.Memory || p.Threads < MinParams.Threads {
+		return fmt.Errorf("%w: time must be at least %d, memory at least %d KiB and threads at least %d", ErrInvalidParams, MinParams.Time, MinParams.Memory, MinParams.Threads)
+	}
+	if p.Time > MaxParams.Time || p.Memory > MaxParams.Memory || p.Threads > MaxParams.Threads {
+		return fmt.Errorf("%w: time must be at most %d, memory at most %d KiB and threads at most %d", ErrInvalidParams, MaxParams.Time, MaxParams.Memory, MaxParams.Threads)
+	}
+	return nil
+}
+
+// String returns the string representation of the parameters.
+func (p Params) String() string {
+	return fmt.Sprintf("m=%d,t=%d,p=%d", p.Memory, p.Time, p.Threads)
+}
+
+// DeriveKey derives a key from the password with the provided salt
+// and parameters.
+func DeriveKey(password, salt []byte, params Params) ([]byte, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	if len(salt) < HashLength {
+		return nil, errors.New("salt must be at least " + strconv.Itoa(HashLength) + " bytes")
+	}
+	return idKey(password, salt, KeyLength, params), nil
+}
+
+// CalibrateOptions contains options for Calibrate.
+type CalibrateOptions struct {
+	Threads   uint8
+	MaxMemory uint32
+}
+
+// CalibrateOption is a function that sets CalibrateOptions.
+type CalibrateOption func(o *CalibrateOptions)
+
+// Calibrate returns the parameters that derives a key in approximately
+// the target duration on the current machine. The memory is raised first
+// up to the maximum memory, after which the time (passes) is raised. The
+// parameters never exceed the highest accepted parameters.
+func Calibrate(target time.Duration, options ...CalibrateOption) Params {
+	opts := CalibrateOptions{
+		Threads:   uint8(min(runtime.NumCPU(), int(DefaultParams.Threads))),
+		MaxMemory: 1024 * 1024,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	opts.MaxMemory = min(opts.MaxMemory, MaxParams.Memory)
+
+	password, salt := []byte("calibrate"), make([]byte, HashLength)
+	params := Params{Time: 1, Memory: MinParams.Memory, Threads: min(max(opts.Threads, 1), MaxParams.Threads)}
+	for {
+		elapsed := measure(password, salt, params)
+		if elapsed >= target || params.Time >= MaxParams.Time {
+			break
+		}
+		if params.Memory*2 <= opts.MaxMemory {
+			params.Memory *= 2
+			continue
+		}
+		// Estimate the remaining passes from the time of a single pass.
+		// A pass can be measured faster than the clock resolution.
+		pass := max(elapsed/time.Duration(params.Time), time.Nanosecond)
+		passes := uint32(min(target/pass, time.Duration(MaxParams.Time)))
+		if passes <= params.Time {
+			params.Time++
+		} else {
+			params.Time = min(passes, MaxParams.Time)
+		}
+		if measure(password, salt, params) >= target {
+			break
+		}
+	}
+	return params
+}
+
+// measure returns the time it takes to derive a key with the
+// provided parameters.
+func measure(password, salt []byte, params Params) time.Duration {
+	start := time.Now()
+	idKey(password, salt, KeyLength, params)
+	return max(time.Since(start), time.Nanosecond)
+}
+
+// WithThreads sets the number of threads for calibration.
+func WithThreads(threads uint8) CalibrateOption {
+	return func(o *CalibrateOptions) {
+		o.Threads = threads
+	}
+}
+
+// WithMaxMemory sets the maximum memory in KiB for calibration.
+func WithMaxMemory(memory uint32) CalibrateOption {
+	return func(o *CalibrateOptions) {
+		o.MaxMemory = memory
+	}
+}
+
+// idKey is a convenience function that returns a key
+// using argon2id with the provided parameters.
+func idKey(b, s []byte, l uint32, params Params) []byte {
+	return argon2.IDKey(b, s, params.Time, params.Memory, params.Threads, l)
+}
@@ -0,0 +1,92 @@
+package security
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCalibrate(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			target  time.Duration
+			options []CalibrateOption
+		}
+		want struct {
+			threads   uint8
+			maxMemory uint32
+		}
+	}{
+		{
+			name: "calibrate",
+			input: struct {
+				target  time.Duration
+				options []CalibrateOption
+			}{
+				target:  time.Millisecond,
+				options: []CalibrateOption{WithThreads(1), WithMaxMemory(16 * 1024)},
+			},
+			want: struct {
+				threads   uint8
+				maxMemory uint32
+			}{
+				threads:   1,
+				maxMemory: 16 * 1024,
+			},
+		},
+		{
+			name: "calibrate with passes",
+			input: struct {
+				target  time.Duration
+				options []CalibrateOption
+			}{
+				target:  20 * time.Millisecond,
+				options: []CalibrateOption{WithThreads(1), WithMaxMemory(MinParams.Memory)},
+			},
+			want: struct {
+				threads   uint8
+				maxMemory uint32
+			}{
+				threads:   1,
+				maxMemory: MinParams.Memory,
+			},
+		},
+		{
+			name: "calibrate with options above the highest parameters",
+			input: struct {
+				target  time.Duration
+				options []CalibrateOption
+			}{
+				target:  time.Millisecond,
+				options: []CalibrateOption{WithThreads(math.MaxUint8), WithMaxMemory(math.MaxUint32)},
+			},
+			want: struct {
+				threads   uint8
+				maxMemory uint32
+			}{
+				threads:   MaxParams.Threads,
+				maxMemory: MaxParams.Memory,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Calibrate(test.input.target, test.input.options...)
+
+			if err := got.Validate(); err != nil {
+				t.Errorf("Calibrate() = invalid parameters: %v\n", err)
+			}
+			if got.Memory < MinParams.Memory || got.Memory > test.want.maxMemory {
+				t.Errorf("Calibrate() = unexpected memory, want between: %d and %d, got: %d\n", MinParams.Memory, test.want.maxMemory, got.Memory)
+			}
+			if got.Time < MinParams.Time || got.Time > MaxParams.Time {
+				t.Errorf("Calibrate() = unexpected time, want between: %d and %d, got: %d\n", MinParams.Time, MaxParams.Time, got.Time)
+			}
+			if test.want.threads != got.Threads {
+				t.Errorf("Calibrate() = unexpected threads, want: %d, got: %d\n", test.want.threads, got.Threads)
+			}
+		})
+	}
+}
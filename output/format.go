@@ -0,0 +1,169 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kind is the kind of an output format.
+type Kind string
+
+const (
+	// KindTable formats as a table with aligned columns.
+	KindTable Kind = "table"
+	// KindWide formats as a table with additional columns.
+	KindWide Kind = "wide"
+	// KindJSON formats as indented JSON.
+	KindJSON Kind = "json"
+	// KindYAML formats as YAML.
+	KindYAML Kind = "yaml"
+	// KindTemplate formats with a Go template.
+	KindTemplate Kind = "template"
+)
+
+// Kinds contains all kinds of output formats.
+var Kinds = []Kind{KindTable, KindWide, KindJSON, KindYAML, KindTemplate}
+
+// ErrNotTabular is returned when a value cannot be formatted as a table.
+var ErrNotTabular = errors.New("value cannot be formatted as a table")
+
+// Format is an output format.
+type Format struct {
+	Kind Kind
+	// Template is the Go template for KindTemplate.
+	Template string
+}
+
+// ParseFormat parses an output format. The format is one of table, wide,
+// json, yaml or template=<go-template>.
+func ParseFormat(s string) (Format, error) {
+	kind, tmpl, _ := strings.Cut(s, "=")
+	switch Kind(kind) {
+	case KindTable, KindWide, KindJSON, KindYAML:
+		if len(tmpl) > 0 {
+			return Format{}, fmt.Errorf("invalid output format: %s", s)
+		}
+		return Format{Kind: Kind(kind)}, nil
+	case KindTemplate:
+		if len(tmpl) == 0 {
+			return Format{}, errors.New("a template must be provided in the format template=<go-template>")
+		}
+		return Format{Kind: KindTemplate, Template: tmpl}, nil
+	}
+	return Format{}, fmt.Errorf("invalid output format: %s", s)
+}
+
+// Tabler is the interface that wraps around method Table.
+//
+// Table returns the header and rows of a table. If wide is true,
+// additional columns should be included.
+type Tabler interface {
+	Table(wide bool) ([]string, [][]string)
+}
+
+// Write the value in the provided format to w. For the table formats
+// the value must implement Tabler. For the template format, the template
+// is executed for each element if the value is a slice, otherwise for
+// the value.
+func Write(w io.Writer, format Format, v any) error {
+	switch format.Kind {
+	case KindTable, KindWide:
+		t, ok := v.(Tabler)
+		if !ok {
+			return ErrNotTabular
+		}
+		return writeTable(w, t, format.Kind == KindWide)
+	case KindJSON:
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	case KindYAML:
+		return writeYAML(w, v)
+	case KindTemplate:
+		return writeTemplate(w, format.Template, v)
+	}
+	return fmt.Errorf("invalid output format: %s", format.Kind)
+}
+
+// writeTable writes the table with aligned columns.
+func writeTable(w io.Writer, t Tabler, wide bool) error {
+	header, rows := t.Table(wide)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// writeYAML writes the value as YAML. The value is encoded to JSON
+// first, so that the field names and formatting of values are the
+// same as for JSON.
+func writeYAML(w io.Writer, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(b, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// blockStyle resets the style of the node and its children, so that
+// nodes decoded from JSON are encoded in block style.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, n := range node.Content {
+		blockStyle(n)
+	}
+}
+
+// writeTemplate executes the template for each element of the value if
+// it is a slice, otherwise for the value. Each execution is followed by
+// a newline.
+func writeTemplate(w io.Writer, text string, v any) error {
+	tmpl, err := template.New("output").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return err
+	}
+
+	items := []any{v}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice {
+		items = make([]any, rv.Len())
+		for i := range items {
+			items[i] = rv.Index(i).Interface()
+		}
+	}
+	for _, item := range items {
+		if err := tmpl.Execute(w, item); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestParseFormat(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   string
+		want    Format
+		wantErr bool
+	}{
+		{
+			name:  "table",
+			input: "table",
+			want:  Format{Kind: KindTable},
+		},
+		{
+			name:  "template",
+			input: "template={{.Name}}={{.Value}}",
+			want:  Format{Kind: KindTemplate, Template: "{{.Name}}={{.Value}}"},
+		},
+		{
+			name:    "template - empty",
+			input:   "template=",
+			wantErr: true,
+		},
+		{
+			name:    "invalid",
+			input:   "xml",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := ParseFormat(test.input)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("ParseFormat() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if test.wantErr != (gotErr != nil) {
+				t.Errorf("ParseFormat() = unexpected error: %v\n", gotErr)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			format Format
+			v      any
+		}
+		want    string
+		wantErr error
+	}{
+		{
+			name: "table",
+			input: struct {
+				format Format
+				v      any
+			}{
+				format: Format{Kind: KindTable},
+				v:      testItems{{Name: "first", Value: "a"}, {Name: "second-item", Value: "b"}},
+			},
+			want: "NAME         VALUE\nfirst        a\nsecond-item  b\n",
+		},
+		{
+			name: "wide",
+			input: struct {
+				format Format
+				v      any
+			}{
+				format: Format{Kind: KindWide},
+				v:      testItems{{Name: "first", Value: "a", Note: "note"}},
+			},
+			want: "NAME   VALUE  NOTE\nfirst  a      note\n",
+		},
+		{
+			name: "table - not tabular",
+			input: struct {
+				format Format
+				v      any
+			}{
+				format: Format{Kind: KindTable},
+				v:      testItem{Name: "first"},
+			},
+			wantErr: ErrNotTabular,
+		},
+		{
+			name: "json",
+			input: struct {
+				format Format
+				v      any
+			}{
+				format: Format{Kind: KindJSON},
+				v:      testItem{Name: "first", Value: "a"},
+			},
+			want: "{\n  \"name\": \"first\",\n  \"value\": \"a\"\n}\n",
+		},
+		{
+			name: "yaml",
+			input: struct {
+				format Format
+				v      any
+			}{
+				format: Format{Kind: KindYAML},
+				v:      testItems{{Name: "first", Value: "a", Note: "note"}},
+			},
+			want: "- name: first\n  value: a\n  note: note\n",
+		},
+		{
+			name: "template - slice",
+			input: struct {
+				format Format
+				v      any
+			}{
+				format: Format{Kind: KindTemplate, Template: "{{.Name}}={{.Value}}"},
+				v:      testItems{{Name: "first", Value: "a"}, {Name: "second", Value: "b"}},
+			},
+			want: "first=a\nsecond=b\n",
+		},
+		{
+			name: "template - value",
+			input: struct {
+				format Format
+				v      any
+			}{
+				format: Format{Kind: KindTemplate, Template: "{{.Name}}"},
+				v:      testItem{Name: "first"},
+			},
+			want: "first\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			gotErr := Write(&buf, test.input.format, test.input.v)
+
+			if diff := cmp.Diff(test.want, buf.String()); diff != "" {
+				t.Errorf("Write() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Write() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestWrite_TemplateError(t *testing.T) {
+	var buf bytes.Buffer
+	err := Write(&buf, Format{Kind: KindTemplate, Template: "{{.Missing}}"}, testItem{Name: "first"})
+	if err == nil || errors.Is(err, ErrNotTabular) {
+		t.Errorf("Write() = expected template error, got: %v\n", err)
+	}
+}
+
+type testItem struct {
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+	Note  string `json:"note,omitempty"`
+}
+
+type testItems []testItem
+
+func (t testItems) Table(wide bool) ([]string, [][]string) {
+	header := []string{"NAME", "VALUE"}
+	if wide {
+		header = append(header, "NOTE")
+	}
+	rows := make([][]string, 0, len(t))
+	for _, item := range t {
+		row := []string{item.Name, item.Value}
+		if wide {
+			row = append(row, item.Note)
+		}
+		rows = append(rows, row)
+	}
+	return header, rows
+}
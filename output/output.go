@@ -2,6 +2,7 @@ package output
 
 import (
 	"fmt"
+	"os"
 )
 
 const (
@@ -34,3 +35,9 @@ func PrintErrorln(a any) (int, error) {
 func PrintEmptyln() (int, error) {
 	return fmt.Println()
 }
+
+// Prompt prints to Stderr to keep prompts separate from
+// the output.
+func Prompt(a any) (int, error) {
+	return fmt.Fprint(os.Stderr, a)
+}
@@ -0,0 +1,97 @@
+package rpc
+
+import (
+	"context"
+	"crypto/tls"
+
+	"github.com/KarlGW/secman/rpc/secmanpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Client is a client of the gRPC service.
+type Client struct {
+	secmanpb.SecretsClient
+	conn *grpc.ClientConn
+}
+
+// ClientOptions contains options for a Client.
+type ClientOptions struct {
+	Token       string
+	TLSConfig   *tls.Config
+	DialOptions []grpc.DialOption
+}
+
+// ClientOption is a function that sets ClientOptions.
+type ClientOption func(o *ClientOptions)
+
+// Dial creates a client for the service at the target, like
+// unix:///path/to/grpc.sock or host:port. The connection is
+// established on the first request.
+func Dial(target string, options ...ClientOption) (*Client, error) {
+	opts := ClientOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	creds := insecure.NewCredentials()
+	if opts.TLSConfig != nil {
+		creds = credentials.NewTLS(opts.TLSConfig)
+	}
+	dialOpts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if len(opts.Token) > 0 {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(tokenCredentials(opts.Token)))
+	}
+	dialOpts = append(dialOpts, opts.DialOptions...)
+
+	conn, err := grpc.NewClient(target, dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		SecretsClient: secmanpb.NewSecretsClient(conn),
+		conn:          conn,
+	}, nil
+}
+
+// Close the connection of the client.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// WithToken sets the token sent with each request.
+func WithToken(token string) ClientOption {
+	return func(o *ClientOptions) {
+		o.Token = token
+	}
+}
+
+// WithClientTLSConfig sets the TLS configuration of the client.
+func WithClientTLSConfig(config *tls.Config) ClientOption {
+	return func(o *ClientOptions) {
+		o.TLSConfig = config
+	}
+}
+
+// WithDialOptions sets additional options for the connection,
+// like a dialer for in-memory connections in tests.
+func WithDialOptions(options ...grpc.DialOption) ClientOption {
+	return func(o *ClientOptions) {
+		o.DialOptions = append(o.DialOptions, options...)
+	}
+}
+
+// tokenCredentials sends a token as a bearer token with each request.
+type tokenCredentials string
+
+// GetRequestMetadata returns the authorization header of the token.
+func (t tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+// RequireTransportSecurity returns false, since the token is also
+// sent over Unix sockets without TLS.
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return false
+}
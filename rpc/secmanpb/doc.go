@@ -0,0 +1,5 @@
+// Package secmanpb contains the protobuf messages and the gRPC client
+// and server of the secman service, generated from secman.proto.
+package secmanpb
+
+//go:generate protoc --proto_path=../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative rpc/secmanpb/secman.proto
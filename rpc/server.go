@@ -0,0 +1,605 @@
+// Package rpc contains a gRPC server and client for the secrets of a
+// profile, served over a Unix socket or over TCP with mutual TLS.
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KarlGW/secman/rpc/secmanpb"
+	"github.com/KarlGW/secman/secret"
+	"github.com/KarlGW/secman/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	// errInvalidArgument is returned for invalid requests.
+	errInvalidArgument = errors.New("invalid argument")
+	// errWatcherBehind is returned when a watcher does not keep up
+	// with the events.
+	errWatcherBehind = errors.New("watcher fell behind, watch again")
+)
+
+const (
+	// watchBuffer is the number of events buffered for each watcher.
+	watchBuffer = 64
+	// WarningKey is the key of the trailer with a warning for a request,
+	// like a failed post-hook of a saved change.
+	WarningKey = "secman-warning"
+)
+
+// eventTypes maps the types of secret events to the types of events
+// of the service.
+var eventTypes = map[secret.EventType]secmanpb.Event_Type{
+	secret.EventAdded:   secmanpb.Event_TYPE_ADDED,
+	secret.EventUpdated: secmanpb.Event_TYPE_UPDATED,
+	secret.EventDeleted: secmanpb.Event_TYPE_DELETED,
+}
+
+// Handler is the interface that wraps around the methods of
+// secret.Handler used by the server.
+type Handler interface {
+	ListSecrets() (secret.Secrets, error)
+	SearchSecrets(query string) (secret.Secrets, error)
+	GetSecretByID(id string) (secret.Secret, error)
+	GetSecretByName(name string) (secret.Secret, error)
+	AddSecret(name, value string, options ...secret.SecretOption) (secret.Secret, error)
+	UpdateSecretByID(id string, options ...secret.SecretOption) (secret.Secret, error)
+	DeleteSecretByID(id string) error
+	Sync() error
+	Reload(loaded time.Time) (time.Time, error)
+	Updated() (time.Time, error)
+	Watch(ctx context.Context, options ...secret.WatchOption) (<-chan secret.Event, error)
+}
+
+// Server serves the secrets of a handler over gRPC. All requests
+// are served by the single handler, one at a time. The secrets are
+// reloaded before a request if they have been changed by another
+// process, like the CLI.
+type Server struct {
+	secmanpb.UnimplementedSecretsServer
+	handler       Handler
+	tokens        []server.Token
+	readOnly      bool
+	tlsConfig     *tls.Config
+	watchInterval time.Duration
+	// loaded is the time the storage was updated when the secrets
+	// were last loaded.
+	loaded   time.Time
+	mu       sync.Mutex
+	watchers map[chan *secmanpb.Event]struct{}
+	wmu      sync.Mutex
+	done     chan struct{}
+}
+
+// Options contains options for a Server.
+type Options struct {
+	Tokens        []server.Token
+	ReadOnly      bool
+	TLSConfig     *tls.Config
+	WatchInterval time.Duration
+}
+
+// Option is a function that sets Options.
+type Option func(o *Options)
+
+// New creates and returns a new Server.
+func New(handler Handler, options ...Option) *Server {
+	opts := Options{
+		WatchInterval: time.Second,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	return &Server{
+		handler:       handler,
+		tokens:        opts.Tokens,
+		readOnly:      opts.ReadOnly,
+		tlsConfig:     opts.TLSConfig,
+		watchInterval: opts.WatchInterval,
+		watchers:      make(map[chan *secmanpb.Event]struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+// GRPCServer creates a gRPC server with the service registered, and
+// the authentication and credentials of the server.
+func (s *Server) GRPCServer() *grpc.Server {
+	opts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(s.unaryInterceptor),
+		grpc.StreamInterceptor(s.streamInterceptor),
+	}
+	if s.tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(s.tlsConfig)))
+	}
+	gs := grpc.NewServer(opts...)
+	secmanpb.RegisterSecretsServer(gs, s)
+	return gs
+}
+
+// Serve the service on the listener until the context is cancelled.
+// The storage of the handler is watched for changes, made through the
+// server or by other processes, and they are sent to watchers.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events, err := s.handler.Watch(ctx, secret.WithInterval(s.watchInterval))
+	if err != nil {
+		return err
+	}
+	go func() {
+		for event := range events {
+			s.publish(event)
+		}
+	}()
+
+	gs := s.GRPCServer()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- gs.Serve(listener)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		s.Close()
+		gs.GracefulStop()
+		return nil
+	}
+}
+
+// Close ends all watches.
+func (s *Server) Close() {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
+	}
+}
+
+// ListSecrets lists the secrets, or searches them if a query is set.
+func (s *Server) ListSecrets(ctx context.Context, req *secmanpb.ListSecretsRequest) (*secmanpb.ListSecretsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reload(); err != nil {
+		return nil, toStatus(err)
+	}
+
+	var secrets secret.Secrets
+	var err error
+	if len(req.GetQuery()) > 0 {
+		secrets, err = s.handler.SearchSecrets(req.GetQuery())
+	} else {
+		secrets, err = s.handler.ListSecrets()
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	res := &secmanpb.ListSecretsResponse{Secrets: make([]*secmanpb.Secret, len(secrets))}
+	for i, sec := range secrets {
+		res.Secrets[i] = toProto(sec, nil)
+	}
+	return res, nil
+}
+
+// GetSecret gets a secret with its decrypted value.
+func (s *Server) GetSecret(ctx context.Context, req *secmanpb.GetSecretRequest) (*secmanpb.Secret, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reload(); err != nil {
+		return nil, toStatus(err)
+	}
+
+	sec, err := s.getSecret(req.GetId(), req.GetName())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	decrypted, err := sec.Decrypt()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProto(sec, decrypted), nil
+}
+
+// AddSecret adds a secret.
+func (s *Server) AddSecret(ctx context.Context, req *secmanpb.AddSecretRequest) (*secmanpb.Secret, error) {
+	if len(req.GetName()) == 0 || len(req.GetValue()) == 0 {
+		return nil, toStatus(fmt.Errorf("%w: a name and a value must be provided", errInvalidArgument))
+	}
+	options := []secret.SecretOption{
+		secret.WithDisplayName(req.GetDisplayName()),
+		secret.WithLabels(req.GetLabels()...),
+		secret.WithTags(req.GetTags()),
+	}
+	if len(req.GetType()) > 0 {
+		t, err := secret.ParseType(req.GetType())
+		if err != nil {
+			return nil, toStatus(fmt.Errorf("%w: %w", errInvalidArgument, err))
+		}
+		options = append(options, secret.WithType(t))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reload(); err != nil {
+		return nil, toStatus(err)
+	}
+
+	sec, err := s.handler.AddSecret(req.GetName(), string(req.GetValue()), options...)
+	if err := warnPostHook(ctx, err); err != nil {
+		return nil, toStatus(err)
+	}
+	s.saved()
+	return toProto(sec, nil), nil
+}
+
+// UpdateSecret updates the fields of a secret set in the update mask.
+// If no update mask is set, the fields that are set are updated.
+func (s *Server) UpdateSecret(ctx context.Context, req *secmanpb.UpdateSecretRequest) (*secmanpb.Secret, error) {
+	options, err := updateOptions(req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reload(); err != nil {
+		return nil, toStatus(err)
+	}
+
+	sec, err := s.getSecret(req.GetId(), req.GetName())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	sec, err = s.handler.UpdateSecretByID(sec.ID, options...)
+	if err := warnPostHook(ctx, err); err != nil {
+		return nil, toStatus(err)
+	}
+	s.saved()
+	return toProto(sec, nil), nil
+}
+
+// DeleteSecret deletes a secret.
+func (s *Server) DeleteSecret(ctx context.Context, req *secmanpb.DeleteSecretRequest) (*secmanpb.DeleteSecretResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reload(); err != nil {
+		return nil, toStatus(err)
+	}
+
+	sec, err := s.getSecret(req.GetId(), req.GetName())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if err := warnPostHook(ctx, s.handler.DeleteSecretByID(sec.ID)); err != nil {
+		return nil, toStatus(err)
+	}
+	s.saved()
+	return &secmanpb.DeleteSecretResponse{}, nil
+}
+
+// Sync syncs the secrets with the secondary storage of the handler.
+func (s *Server) Sync(ctx context.Context, req *secmanpb.SyncRequest) (*secmanpb.SyncResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reload(); err != nil {
+		return nil, toStatus(err)
+	}
+
+	if err := warnPostHook(ctx, s.handler.Sync()); err != nil {
+		return nil, toStatus(err)
+	}
+	s.saved()
+	return &secmanpb.SyncResponse{}, nil
+}
+
+// Watch streams events for changes of secrets until the stream or
+// the server is closed.
+func (s *Server) Watch(req *secmanpb.WatchRequest, stream secmanpb.Secrets_WatchServer) error {
+	events := s.subscribe()
+	defer s.unsubscribe(events)
+	// Send the headers to let the client know the watch is established.
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-s.done:
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, errWatcherBehind.Error())
+			}
+			if len(req.GetNames()) > 0 && !slices.Contains(req.GetNames(), event.GetName()) {
+				continue
+			}
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// reload reloads the secrets if the storage has been updated since
+// they were loaded.
+func (s *Server) reload() error {
+	loaded, err := s.handler.Reload(s.loaded)
+	if err != nil {
+		return err
+	}
+	s.loaded = loaded
+	return nil
+}
+
+// saved sets the time of the last load to the time the storage was
+// updated, after a change by the server, to not reload it.
+func (s *Server) saved() {
+	if updated, err := s.handler.Updated(); err == nil {
+		s.loaded = updated
+	}
+}
+
+// getSecret gets a secret by either id or name.
+func (s *Server) getSecret(id, name string) (secret.Secret, error) {
+	if len(id) > 0 {
+		return s.handler.GetSecretByID(id)
+	}
+	if len(name) > 0 {
+		return s.handler.GetSecretByName(name)
+	}
+	return secret.Secret{}, fmt.Errorf("%w: id or name must be provided", errInvalidArgument)
+}
+
+// subscribe adds a watcher.
+func (s *Server) subscribe() chan *secmanpb.Event {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	events := make(chan *secmanpb.Event, watchBuffer)
+	s.watchers[events] = struct{}{}
+	return events
+}
+
+// unsubscribe removes a watcher.
+func (s *Server) unsubscribe(events chan *secmanpb.Event) {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	if _, ok := s.watchers[events]; ok {
+		delete(s.watchers, events)
+		close(events)
+	}
+}
+
+// publish sends the event to all watchers. Watchers that do not
+// keep up are removed.
+func (s *Server) publish(e secret.Event) {
+	event := &secmanpb.Event{
+		Type: eventTypes[e.Type],
+		Id:   e.ID,
+		Name: e.Name,
+		Time: timestamppb.New(e.Time),
+	}
+
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	for events := range s.watchers {
+		select {
+		case events <- event:
+		default:
+			delete(s.watchers, events)
+			close(events)
+		}
+	}
+}
+
+// unaryInterceptor authorizes unary requests.
+func (s *Server) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := s.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// streamInterceptor authorizes stream requests.
+func (s *Server) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.authorize(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// authorize checks the bearer token of the request if the server has
+// tokens, and that writes are allowed.
+func (s *Server) authorize(ctx context.Context, method string) error {
+	var token server.Token
+	if len(s.tokens) > 0 {
+		var err error
+		if token, err = s.authenticate(ctx); err != nil {
+			return toStatus(err)
+		}
+	}
+	if (s.readOnly || token.ReadOnly) && isWrite(method) {
+		return toStatus(server.ErrReadOnly)
+	}
+	return nil
+}
+
+// authenticate returns the token of the bearer token of the request.
+func (s *Server) authenticate(ctx context.Context) (server.Token, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		bearer, ok := strings.CutPrefix(value, "Bearer ")
+		if !ok || len(bearer) == 0 {
+			continue
+		}
+		hash := server.HashToken(bearer)
+		for _, token := range s.tokens {
+			if subtle.ConstantTimeCompare([]byte(hash), []byte(token.Hash)) == 1 {
+				return token, nil
+			}
+		}
+	}
+	return server.Token{}, server.ErrUnauthorized
+}
+
+// isWrite returns true if the method changes secrets.
+func isWrite(method string) bool {
+	switch method {
+	case secmanpb.Secrets_AddSecret_FullMethodName,
+		secmanpb.Secrets_UpdateSecret_FullMethodName,
+		secmanpb.Secrets_DeleteSecret_FullMethodName,
+		secmanpb.Secrets_Sync_FullMethodName:
+		return true
+	}
+	return false
+}
+
+// updateOptions returns the secret options for the fields of the
+// update mask of the request.
+func updateOptions(req *secmanpb.UpdateSecretRequest) ([]secret.SecretOption, error) {
+	sec := req.GetSecret()
+	paths := req.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		if len(sec.GetValue()) > 0 {
+			paths = append(paths, "value")
+		}
+		if len(sec.GetDisplayName()) > 0 {
+			paths = append(paths, "display_name")
+		}
+		if len(sec.GetType()) > 0 {
+			paths = append(paths, "type")
+		}
+		if sec.GetLabels() != nil {
+			paths = append(paths, "labels")
+		}
+		if sec.GetTags() != nil {
+			paths = append(paths, "tags")
+		}
+	}
+
+	var options []secret.SecretOption
+	for _, path := range paths {
+		switch path {
+		case "value":
+			if len(sec.GetValue()) == 0 {
+				return nil, fmt.Errorf("%w: value must not be empty", errInvalidArgument)
+			}
+			options = append(options, secret.WithValue(sec.GetValue()))
+		case "display_name":
+			options = append(options, secret.WithDisplayName(sec.GetDisplayName()))
+		case "type":
+			t, err := secret.ParseType(sec.GetType())
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", errInvalidArgument, err)
+			}
+			options = append(options, secret.WithType(t))
+		case "labels":
+			options = append(options, secret.WithLabels(sec.GetLabels()...))
+		case "tags":
+			options = append(options, secret.WithTags(sec.GetTags()))
+		default:
+			return nil, fmt.Errorf("%w: unknown field %s in update mask", errInvalidArgument, path)
+		}
+	}
+	return options, nil
+}
+
+// toProto converts a secret to its protobuf message, with the
+// provided value.
+func toProto(sec secret.Secret, value []byte) *secmanpb.Secret {
+	return &secmanpb.Secret{
+		Id:          sec.ID,
+		Name:        sec.Name,
+		DisplayName: sec.DisplayName,
+		Type:        sec.Type.String(),
+		Labels:      sec.Labels,
+		Tags:        sec.Tags,
+		Created:     timestamppb.New(sec.Created),
+		Updated:     timestamppb.New(sec.Updated),
+		Value:       value,
+	}
+}
+
+// warnPostHook reports a failed post-hook as a warning in the trailer of
+// the response, since the change has been saved. Other errors are returned.
+func warnPostHook(ctx context.Context, err error) error {
+	if !errors.Is(err, secret.ErrPostHook) {
+		return err
+	}
+	_ = grpc.SetTrailer(ctx, metadata.Pairs(WarningKey, err.Error()))
+	return nil
+}
+
+// toStatus converts an error to a gRPC status error.
+func toStatus(err error) error {
+	code := codes.Internal
+	switch {
+	case errors.Is(err, server.ErrUnauthorized):
+		code = codes.Unauthenticated
+	case errors.Is(err, server.ErrReadOnly):
+		code = codes.PermissionDenied
+	case errors.Is(err, secret.ErrSecretNotFound):
+		code = codes.NotFound
+	case errors.Is(err, secret.ErrSecretAlreadyExists):
+		code = codes.AlreadyExists
+	case errors.Is(err, errInvalidArgument), errors.Is(err, secret.ErrInvalidQuery):
+		code = codes.InvalidArgument
+	}
+	return status.Error(code, err.Error())
+}
+
+// DefaultSocketPath returns the default path of the socket.
+func DefaultSocketPath() string {
+	return filepath.Join(filepath.Dir(server.DefaultSocketPath()), "grpc.sock")
+}
+
+// WithTokens sets the tokens of the clients. If no tokens are set,
+// requests are not authenticated.
+func WithTokens(tokens []server.Token) Option {
+	return func(o *Options) {
+		o.Tokens = tokens
+	}
+}
+
+// WithReadOnly makes the server serve only read requests.
+func WithReadOnly() Option {
+	return func(o *Options) {
+		o.ReadOnly = true
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of the server.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(o *Options) {
+		o.TLSConfig = config
+	}
+}
+
+// WithWatchInterval sets the interval between checks of the storage
+// for changes to send to watchers. Defaults to 1 second.
+func WithWatchInterval(d time.Duration) Option {
+	return func(o *Options) {
+		o.WatchInterval = d
+	}
+}
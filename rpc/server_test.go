@@ -0,0 +1,472 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/KarlGW/secman/internal/security"
+	"github.com/KarlGW/secman/rpc/secmanpb"
+	"github.com/KarlGW/secman/secret"
+	"github.com/KarlGW/secman/server"
+	"github.com/KarlGW/secman/storage"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func TestServer(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			token    string
+			readOnly bool
+			call     func(ctx context.Context, client *Client) error
+		}
+		want codes.Code
+	}{
+		{
+			name: "list secrets",
+			input: struct {
+				token    string
+				readOnly bool
+				call     func(ctx context.Context, client *Client) error
+			}{
+				token: "token",
+				call: func(ctx context.Context, client *Client) error {
+					res, err := client.ListSecrets(ctx, &secmanpb.ListSecretsRequest{})
+					if err != nil {
+						return err
+					}
+					if len(res.GetSecrets()) != 1 || res.GetSecrets()[0].GetValue() != nil {
+						return status.Error(codes.Unknown, "unexpected secrets")
+					}
+					return nil
+				},
+			},
+			want: codes.OK,
+		},
+		{
+			name: "get secret",
+			input: struct {
+				token    string
+				readOnly bool
+				call     func(ctx context.Context, client *Client) error
+			}{
+				token: "read-only-token",
+				call: func(ctx context.Context, client *Client) error {
+					res, err := client.GetSecret(ctx, &secmanpb.GetSecretRequest{
+						Identifier: &secmanpb.GetSecretRequest_Name{Name: "secret"},
+					})
+					if err != nil {
+						return err
+					}
+					if string(res.GetValue()) != "value" {
+						return status.Error(codes.Unknown, "unexpected value")
+					}
+					return nil
+				},
+			},
+			want: codes.OK,
+		},
+		{
+			name: "get secret - not found",
+			input: struct {
+				token    string
+				readOnly bool
+				call     func(ctx context.Context, client *Client) error
+			}{
+				token: "token",
+				call: func(ctx context.Context, client *Client) error {
+					_, err := client.GetSecret(ctx, &secmanpb.GetSecretRequest{
+						Identifier: &secmanpb.GetSecretRequest_Name{Name: "missing"},
+					})
+					return err
+				},
+			},
+			want: codes.NotFound,
+		},
+		{
+			name: "add secret - already exists",
+			input: struct {
+				token    string
+				readOnly bool
+				call     func(ctx context.Context, client *Client) error
+			}{
+				token: "token",
+				call: func(ctx context.Context, client *Client) error {
+					_, err := client.AddSecret(ctx, &secmanpb.AddSecretRequest{Name: "secret", Value: []byte("value")})
+					return err
+				},
+			},
+			want: codes.AlreadyExists,
+		},
+		{
+			name: "update secret - invalid update mask",
+			input: struct {
+				token    string
+				readOnly bool
+				call     func(ctx context.Context, client *Client) error
+			}{
+				token: "token",
+				call: func(ctx context.Context, client *Client) error {
+					_, err := client.UpdateSecret(ctx, &secmanpb.UpdateSecretRequest{
+						Identifier: &secmanpb.UpdateSecretRequest_Name{Name: "secret"},
+						Secret:     &secmanpb.Secret{},
+						UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"id"}},
+					})
+					return err
+				},
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			name: "update secret - clear labels with update mask",
+			input: struct {
+				token    string
+				readOnly bool
+				call     func(ctx context.Context, client *Client) error
+			}{
+				token: "token",
+				call: func(ctx context.Context, client *Client) error {
+					if _, err := client.UpdateSecret(ctx, &secmanpb.UpdateSecretRequest{
+						Identifier: &secmanpb.UpdateSecretRequest_Name{Name: "secret"},
+						Secret:     &secmanpb.Secret{Labels: []string{"prod"}},
+					}); err != nil {
+						return err
+					}
+					res, err := client.UpdateSecret(ctx, &secmanpb.UpdateSecretRequest{
+						Identifier: &secmanpb.UpdateSecretRequest_Name{Name: "secret"},
+						Secret:     &secmanpb.Secret{},
+						UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"labels"}},
+					})
+					if err != nil {
+						return err
+					}
+					if len(res.GetLabels()) != 0 {
+						return status.Error(codes.Unknown, "unexpected labels")
+					}
+					return nil
+				},
+			},
+			want: codes.OK,
+		},
+		{
+			name: "unauthenticated",
+			input: struct {
+				token    string
+				readOnly bool
+				call     func(ctx context.Context, client *Client) error
+			}{
+				token: "invalid",
+				call: func(ctx context.Context, client *Client) error {
+					_, err := client.ListSecrets(ctx, &secmanpb.ListSecretsRequest{})
+					return err
+				},
+			},
+			want: codes.Unauthenticated,
+		},
+		{
+			name: "write with read-only token",
+			input: struct {
+				token    string
+				readOnly bool
+				call     func(ctx context.Context, client *Client) error
+			}{
+				token: "read-only-token",
+				call: func(ctx context.Context, client *Client) error {
+					_, err := client.DeleteSecret(ctx, &secmanpb.DeleteSecretRequest{
+						Identifier: &secmanpb.DeleteSecretRequest_Name{Name: "secret"},
+					})
+					return err
+				},
+			},
+			want: codes.PermissionDenied,
+		},
+		{
+			name: "write to read-only server",
+			input: struct {
+				token    string
+				readOnly bool
+				call     func(ctx context.Context, client *Client) error
+			}{
+				token:    "token",
+				readOnly: true,
+				call: func(ctx context.Context, client *Client) error {
+					_, err := client.Sync(ctx, &secmanpb.SyncRequest{})
+					return err
+				},
+			},
+			want: codes.PermissionDenied,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := setupServer(t, storage.NewMemory(nil), test.input.token, test.input.readOnly)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			got := status.Code(test.input.call(ctx, client))
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("call() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestServer_Watch(t *testing.T) {
+	stg := storage.NewMemory(nil)
+	client := setupServer(t, stg, "token", false)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.Watch(ctx, &secmanpb.WatchRequest{Names: []string{"secret", "new"}})
+	if err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+	// Make sure the watch is established before changing secrets.
+	if _, err := stream.Header(); err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+	// Changes made by another process, like the CLI.
+	other, err := secret.NewHandler("profile", _testKey, _testKey, stg, secret.WithLoadCollection())
+	if err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+
+	// Each change is made after the event of the previous change is
+	// received, since changes between two checks of the storage are
+	// sent as the difference between them.
+	changes := []func() error{
+		func() error {
+			if _, err := client.AddSecret(ctx, &secmanpb.AddSecretRequest{Name: "other", Value: []byte("value")}); err != nil {
+				return err
+			}
+			_, err := client.AddSecret(ctx, &secmanpb.AddSecretRequest{Name: "new", Value: []byte("value")})
+			return err
+		},
+		func() error {
+			if _, err := other.Reload(time.Time{}); err != nil {
+				return err
+			}
+			_, err := other.UpdateSecretByName("secret", secret.WithValue([]byte("rotated")))
+			return err
+		},
+		func() error {
+			_, err := client.DeleteSecret(ctx, &secmanpb.DeleteSecretRequest{
+				Identifier: &secmanpb.DeleteSecretRequest_Name{Name: "new"},
+			})
+			return err
+		},
+	}
+
+	want := []string{"TYPE_ADDED new", "TYPE_UPDATED secret", "TYPE_DELETED new"}
+	var got []string
+	for _, change := range changes {
+		if err := change(); err != nil {
+			t.Fatalf("unexpected error in test: %v\n", err)
+		}
+		event, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("unexpected error in test: %v\n", err)
+		}
+		got = append(got, event.GetType().String()+" "+event.GetName())
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Watch() = unexpected result (-want +got)\n%s\n", diff)
+	}
+}
+
+func TestServer_Reload(t *testing.T) {
+	stg := storage.NewMemory(nil)
+	client := setupServer(t, stg, "token", false)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := client.AddSecret(ctx, &secmanpb.AddSecretRequest{Name: "server", Value: []byte("value")}); err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+	// Change the storage underneath the server, like the CLI does.
+	time.Sleep(time.Millisecond)
+	other, err := secret.NewHandler("profile", _testKey, _testKey, stg, secret.WithLoadCollection())
+	if err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+	if _, err := other.AddSecret("cli", "value"); err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+	time.Sleep(time.Millisecond)
+	if _, err := client.AddSecret(ctx, &secmanpb.AddSecretRequest{Name: "server-2", Value: []byte("value")}); err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+
+	res, err := client.ListSecrets(ctx, &secmanpb.ListSecretsRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+	var got []string
+	for _, sec := range res.GetSecrets() {
+		got = append(got, sec.GetName())
+	}
+
+	if diff := cmp.Diff([]string{"secret", "server", "cli", "server-2"}, got); diff != "" {
+		t.Errorf("ListSecrets() = unexpected result (-want +got)\n%s\n", diff)
+	}
+}
+
+func TestServer_PostHook(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			call func(ctx context.Context, client *Client, opts ...grpc.CallOption) error
+		}
+		want []string
+	}{
+		{
+			name: "add secret",
+			input: struct {
+				call func(ctx context.Context, client *Client, opts ...grpc.CallOption) error
+			}{
+				call: func(ctx context.Context, client *Client, opts ...grpc.CallOption) error {
+					_, err := client.AddSecret(ctx, &secmanpb.AddSecretRequest{Name: "new", Value: []byte("value")}, opts...)
+					return err
+				},
+			},
+			want: []string{"secret", "new"},
+		},
+		{
+			name: "update secret",
+			input: struct {
+				call func(ctx context.Context, client *Client, opts ...grpc.CallOption) error
+			}{
+				call: func(ctx context.Context, client *Client, opts ...grpc.CallOption) error {
+					_, err := client.UpdateSecret(ctx, &secmanpb.UpdateSecretRequest{
+						Identifier: &secmanpb.UpdateSecretRequest_Name{Name: "secret"},
+						Secret:     &secmanpb.Secret{Value: []byte("updated")},
+					}, opts...)
+					return err
+				},
+			},
+			want: []string{"secret"},
+		},
+		{
+			name: "delete secret",
+			input: struct {
+				call func(ctx context.Context, client *Client, opts ...grpc.CallOption) error
+			}{
+				call: func(ctx context.Context, client *Client, opts ...grpc.CallOption) error {
+					_, err := client.DeleteSecret(ctx, &secmanpb.DeleteSecretRequest{
+						Identifier: &secmanpb.DeleteSecretRequest_Name{Name: "secret"},
+					}, opts...)
+					return err
+				},
+			},
+			want: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := setupServer(t, storage.NewMemory(nil), "token", false, secret.WithHooks(failingHooks{}))
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			var trailer metadata.MD
+			if err := test.input.call(ctx, client, grpc.Trailer(&trailer)); err != nil {
+				t.Fatalf("call = unexpected error: %v\n", err)
+			}
+			if len(trailer.Get(WarningKey)) == 0 {
+				t.Errorf("call = expected a warning in the trailer\n")
+			}
+
+			res, err := client.ListSecrets(ctx, &secmanpb.ListSecretsRequest{})
+			if err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+			var got []string
+			for _, sec := range res.GetSecrets() {
+				got = append(got, sec.GetName())
+			}
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("ListSecrets() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func setupServer(t *testing.T, stg secret.Storage, token string, readOnly bool, options ...secret.HandlerOption) *Client {
+	t.Helper()
+	serverOptions := []Option{
+		WithWatchInterval(time.Millisecond),
+		WithTokens([]server.Token{
+			{Name: "client", Hash: server.HashToken("token")},
+			{Name: "reader", Hash: server.HashToken("read-only-token"), ReadOnly: true},
+		}),
+	}
+	if readOnly {
+		serverOptions = append(serverOptions, WithReadOnly())
+	}
+
+	listener := bufconn.Listen(1024 * 1024)
+	ctx, cancel := context.WithCancel(context.Background())
+	srv := New(setupHandler(t, stg, options...), serverOptions...)
+	go srv.Serve(ctx, listener)
+
+	client, err := Dial("passthrough:///bufconn", WithToken(token), WithDialOptions(
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+	))
+	if err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+	t.Cleanup(func() {
+		client.Close()
+		cancel()
+	})
+	return client
+}
+
+var _testKey = security.Key{Value: bytes.Repeat([]byte{1}, secret.KeyLength)}
+
+func setupHandler(t *testing.T, stg secret.Storage, options ...secret.HandlerOption) *secret.Handler {
+	t.Helper()
+	handler, err := secret.NewHandler("profile", _testKey, _testKey, stg)
+	if err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+	if _, err := handler.AddSecret("secret", "value"); err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+	if len(options) == 0 {
+		return handler
+	}
+	// Set the options after the secret is added, to not run hooks for it.
+	handler, err = secret.NewHandler("profile", _testKey, _testKey, stg, append(options, secret.WithLoadCollection())...)
+	if err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+	return handler
+}
+
+// failingHooks are hooks where all post-hooks fail.
+type failingHooks struct{}
+
+func (failingHooks) Pre(op secret.Operation, s secret.Secret) error {
+	return nil
+}
+
+func (failingHooks) Post(op secret.Operation, s secret.Secret) error {
+	return errors.New("post-hook")
+}
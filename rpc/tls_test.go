@@ -0,0 +1,146 @@
+package rpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/KarlGW/secman/rpc/secmanpb"
+	"github.com/KarlGW/secman/storage"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestServer_TLS(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			clientCert bool
+		}
+		want codes.Code
+	}{
+		{
+			name: "with client certificate",
+			input: struct {
+				clientCert bool
+			}{
+				clientCert: true,
+			},
+			want: codes.OK,
+		},
+		{
+			name: "without client certificate",
+			input: struct {
+				clientCert bool
+			}{
+				clientCert: false,
+			},
+			want: codes.Unavailable,
+		},
+	}
+
+	dir := t.TempDir()
+	writeCertificates(t, dir)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			serverTLS, err := ServerTLSConfig(filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"), filepath.Join(dir, "ca.crt"))
+			if err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+			clientTLS, err := ClientTLSConfig(filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key"), filepath.Join(dir, "ca.crt"))
+			if err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+			if !test.input.clientCert {
+				clientTLS.Certificates = nil
+			}
+
+			listener := bufconn.Listen(1024 * 1024)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			go New(setupHandler(t, storage.NewMemory(nil)), WithTLSConfig(serverTLS)).Serve(ctx, listener)
+
+			client, err := Dial("passthrough:///localhost", WithClientTLSConfig(clientTLS), WithDialOptions(
+				grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+					return listener.DialContext(ctx)
+				}),
+			))
+			if err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+			defer client.Close()
+
+			_, err = client.ListSecrets(ctx, &secmanpb.ListSecretsRequest{})
+			got := status.Code(err)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("ListSecrets() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+// writeCertificates writes a certificate authority, and server and
+// client certificates signed by it to the directory.
+func writeCertificates(t *testing.T, dir string) {
+	t.Helper()
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, ca, ca, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+	writePEM(t, filepath.Join(dir, "ca.crt"), "CERTIFICATE", caDER)
+
+	for i, name := range []string{"server", "client"} {
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		cert := &x509.Certificate{
+			SerialNumber: big.NewInt(int64(i + 2)),
+			Subject:      pkix.Name{CommonName: name},
+			DNSNames:     []string{"localhost"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, cert, ca, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatalf("unexpected error in test: %v\n", err)
+		}
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatalf("unexpected error in test: %v\n", err)
+		}
+		writePEM(t, filepath.Join(dir, name+".crt"), "CERTIFICATE", der)
+		writePEM(t, filepath.Join(dir, name+".key"), "EC PRIVATE KEY", keyDER)
+	}
+}
+
+func writePEM(t *testing.T, path, typ string, b []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: b}), 0600); err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+}
@@ -0,0 +1,85 @@
+package secman_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/KarlGW/secman/secman"
+	"github.com/KarlGW/secman/storage"
+)
+
+func ExampleOpen() {
+	ctx := context.Background()
+	// The keys and storage are set to run the example without a
+	// configuration. Normally only the profile is needed.
+	key := bytes.Repeat([]byte{1}, 32)
+	client, err := secman.Open(ctx, "example", secman.WithKeys(key, key), secman.WithStorage(storage.NewMemory(nil)))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	if _, err := client.Create(ctx, "db", "password", secman.WithLabels("prod")); err != nil {
+		fmt.Println(err)
+		return
+	}
+	value, err := client.Value(ctx, "db")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(value)
+	// Output: password
+}
+
+func ExampleClient_Update() {
+	ctx := context.Background()
+	key := bytes.Repeat([]byte{1}, 32)
+	client, err := secman.Open(ctx, "example", secman.WithKeys(key, key), secman.WithStorage(storage.NewMemory(nil)))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	if _, err := client.Create(ctx, "db", "password"); err != nil {
+		fmt.Println(err)
+		return
+	}
+	s, err := client.Update(ctx, "db", secman.WithValue("rotated"), secman.WithType(secman.TypeCredential))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	value, err := client.Value(ctx, "db")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(s.Name, s.Type, value)
+	// Output: db credential rotated
+}
+
+func ExampleClient_Search() {
+	ctx := context.Background()
+	key := bytes.Repeat([]byte{1}, 32)
+	client, err := secman.Open(ctx, "example", secman.WithKeys(key, key), secman.WithStorage(storage.NewMemory(nil)))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for _, name := range []string{"db-prod", "db-test", "api"} {
+		if _, err := client.Create(ctx, name, "value"); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+	secrets, err := client.Search(ctx, "db")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(len(secrets))
+	// Output: 2
+}
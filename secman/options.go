@@ -0,0 +1,92 @@
+package secman
+
+import (
+	"github.com/KarlGW/secman/secret"
+)
+
+// Types of secrets.
+const (
+	TypeGeneric    = "generic"
+	TypeCredential = "credential"
+	TypeNote       = "note"
+	TypeFile       = "file"
+	TypeSSHKey     = "ssh-key"
+)
+
+// SecretOptions contains options for creating and updating secrets.
+// Fields that are not set are not changed on update.
+type SecretOptions struct {
+	Value       *string
+	DisplayName *string
+	Type        *string
+	Labels      []string
+	Tags        map[string]string
+}
+
+// SecretOption is a function that sets SecretOptions.
+type SecretOption func(o *SecretOptions)
+
+// newSecretOptions returns the secret.SecretOption of the options.
+func newSecretOptions(options ...SecretOption) ([]secret.SecretOption, error) {
+	opts := SecretOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	var secretOpts []secret.SecretOption
+	if opts.Value != nil {
+		secretOpts = append(secretOpts, secret.WithValue([]byte(*opts.Value)))
+	}
+	if opts.DisplayName != nil {
+		secretOpts = append(secretOpts, secret.WithDisplayName(*opts.DisplayName))
+	}
+	if opts.Type != nil {
+		t, err := secret.ParseType(*opts.Type)
+		if err != nil {
+			return nil, err
+		}
+		secretOpts = append(secretOpts, secret.WithType(t))
+	}
+	if opts.Labels != nil {
+		secretOpts = append(secretOpts, secret.WithLabels(opts.Labels...))
+	}
+	if opts.Tags != nil {
+		secretOpts = append(secretOpts, secret.WithTags(opts.Tags))
+	}
+	return secretOpts, nil
+}
+
+// WithValue sets the value of a secret on update.
+func WithValue(value string) SecretOption {
+	return func(o *SecretOptions) {
+		o.Value = &value
+	}
+}
+
+// WithDisplayName sets the display name of a secret.
+func WithDisplayName(displayName string) SecretOption {
+	return func(o *SecretOptions) {
+		o.DisplayName = &displayName
+	}
+}
+
+// WithType sets the type of a secret.
+func WithType(t string) SecretOption {
+	return func(o *SecretOptions) {
+		o.Type = &t
+	}
+}
+
+// WithLabels sets the labels of a secret.
+func WithLabels(labels ...string) SecretOption {
+	return func(o *SecretOptions) {
+		o.Labels = labels
+	}
+}
+
+// WithTags sets the tags of a secret.
+func WithTags(tags map[string]string) SecretOption {
+	return func(o *SecretOptions) {
+		o.Tags = tags
+	}
+}
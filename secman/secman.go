@@ -0,0 +1,388 @@
+// Package secman is a client for the secrets of a secman profile, for
+// Go programs that read and manage secrets without running the binary.
+//
+// A Client opens a profile by name or ID, resolves the keys of the
+// profile from the keyring, and reloads the secrets when the storage
+// is changed by other processes, like the secman CLI.
+package secman
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/KarlGW/secman/config"
+	"github.com/KarlGW/secman/hook"
+	"github.com/KarlGW/secman/internal/security"
+	"github.com/KarlGW/secman/secret"
+	"github.com/KarlGW/secman/storage"
+)
+
+var (
+	// ErrNoProfile is returned when no profile is provided and no
+	// profile is set as current.
+	ErrNoProfile = errors.New("no profile provided and no current profile set")
+	// ErrNoKeys is returned when the keys of the profile are not
+	// in the keyring.
+	ErrNoKeys = errors.New("the keys of the profile are not in the keyring")
+	// ErrSecretNotFound is returned when a secret cannot be found.
+	ErrSecretNotFound = secret.ErrSecretNotFound
+	// ErrSecretAlreadyExists is returned when a secret with the
+	// name already exists.
+	ErrSecretAlreadyExists = secret.ErrSecretAlreadyExists
+	// ErrPostHook is returned together with the result when a post-hook
+	// of the profile fails. The change has been saved.
+	ErrPostHook = secret.ErrPostHook
+)
+
+// Storage is the interface that wraps around the methods of the
+// storage of a collection of secrets.
+type Storage = secret.Storage
+
+// Secret is a secret and its metadata. Value is only set by Get.
+type Secret struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	DisplayName string            `json:"displayName,omitempty"`
+	Type        string            `json:"type"`
+	Labels      []string          `json:"labels,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Created     time.Time         `json:"created"`
+	Updated     time.Time         `json:"updated"`
+	Value       []byte            `json:"-"`
+}
+
+// Client is a client for the secrets of a profile. It is safe for
+// concurrent use.
+type Client struct {
+	profileID string
+	handler   *secret.Handler
+	storage   Storage
+	// loaded is the time the storage was updated when it was
+	// last loaded.
+	loaded time.Time
+	mu     sync.Mutex
+}
+
+// Options contains options for a Client.
+type Options struct {
+	Username         string
+	Storage          Storage
+	SecondaryStorage Storage
+	StorageKey       []byte
+	Key              []byte
+}
+
+// Option is a function that sets Options.
+type Option func(o *Options)
+
+// Open the profile with the provided name or ID. If profile is empty,
+// the current profile is opened. The keys of the profile are resolved
+// from the keyring, unless set with WithKeys. The hooks of the profile
+// are run on changes.
+func Open(ctx context.Context, profile string, options ...Option) (*Client, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	opts := Options{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	profileID := profile
+	var hooks []config.Hook
+	storageKey, key := security.Key{Value: opts.StorageKey}, security.Key{Value: opts.Key}
+	stg := opts.Storage
+	if len(opts.StorageKey) == 0 || len(opts.Key) == 0 || stg == nil {
+		var cfgOpts []config.Option
+		if len(opts.Username) > 0 {
+			cfgOpts = append(cfgOpts, config.WithUser(opts.Username))
+		}
+		cfg, err := config.Configure(cfgOpts...)
+		if err != nil {
+			return nil, err
+		}
+		if len(profile) == 0 {
+			profile = cfg.ProfileID
+		}
+		if len(profile) == 0 {
+			return nil, ErrNoProfile
+		}
+		p, err := cfg.FindProfile(profile)
+		if err != nil {
+			return nil, err
+		}
+		profileID, hooks = p.ID, p.Hooks
+
+		if len(opts.StorageKey) == 0 || len(opts.Key) == 0 {
+			if storageKey, key, err = cfg.ProfileKeys(p.ID); err != nil {
+				return nil, err
+			}
+			if !storageKey.Valid() || !key.Valid() {
+				return nil, fmt.Errorf("%w: %s", ErrNoKeys, p.Name)
+			}
+		}
+		if stg == nil {
+			stg = storage.NewFileSystem(cfg.CollectionPath(p.ID))
+		}
+	}
+
+	handlerOpts := []secret.HandlerOption{secret.WithLoadCollection()}
+	if opts.SecondaryStorage != nil {
+		handlerOpts = append(handlerOpts, secret.WithSecondaryStorage(opts.SecondaryStorage))
+	}
+	if len(hooks) > 0 {
+		handlerOpts = append(handlerOpts, secret.WithHooks(hook.NewRunner(profileID, hooks)))
+	}
+	handler, err := secret.NewHandler(profileID, storageKey, key, stg, handlerOpts...)
+	if err != nil {
+		return nil, err
+	}
+	loaded, err := stg.Updated()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		profileID: profileID,
+		handler:   handler,
+		storage:   stg,
+		loaded:    loaded,
+	}, nil
+}
+
+// ProfileID returns the ID of the profile of the client.
+func (c *Client) ProfileID() string {
+	return c.profileID
+}
+
+// List lists the secrets, without their values.
+func (c *Client) List(ctx context.Context) ([]Secret, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	secrets, err := c.handler.ListSecrets()
+	if err != nil {
+		return nil, err
+	}
+	return fromSecrets(secrets), nil
+}
+
+// Search searches the secrets with the query, with the same syntax
+// as the search command. The secrets are returned without their values,
+// best match first.
+func (c *Client) Search(ctx context.Context, query string) ([]Secret, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	secrets, err := c.handler.SearchSecrets(query)
+	if err != nil {
+		return nil, err
+	}
+	return fromSecrets(secrets), nil
+}
+
+// Get gets the secret with the provided name, with its decrypted value.
+func (c *Client) Get(ctx context.Context, name string) (Secret, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.refresh(ctx); err != nil {
+		return Secret{}, err
+	}
+
+	s, err := c.handler.GetSecretByName(name)
+	if err != nil {
+		return Secret{}, err
+	}
+	value, err := s.Decrypt()
+	if err != nil {
+		return Secret{}, err
+	}
+	sec := fromSecret(s)
+	sec.Value = value
+	return sec, nil
+}
+
+// Value returns the decrypted value of the secret with the
+// provided name.
+func (c *Client) Value(ctx context.Context, name string) (string, error) {
+	s, err := c.Get(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return string(s.Value), nil
+}
+
+// Create creates a secret with the provided name and value. If a
+// post-hook fails, the created secret is returned together with an
+// error wrapping ErrPostHook.
+func (c *Client) Create(ctx context.Context, name, value string, options ...SecretOption) (Secret, error) {
+	secretOpts, err := newSecretOptions(options...)
+	if err != nil {
+		return Secret{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.refresh(ctx); err != nil {
+		return Secret{}, err
+	}
+
+	s, err := c.handler.AddSecret(name, value, secretOpts...)
+	if err != nil && !errors.Is(err, ErrPostHook) {
+		return Secret{}, err
+	}
+	return fromSecret(s), errors.Join(err, c.loadedNow())
+}
+
+// Update updates the secret with the provided name with the
+// provided options. If a post-hook fails, the updated secret is
+// returned together with an error wrapping ErrPostHook.
+func (c *Client) Update(ctx context.Context, name string, options ...SecretOption) (Secret, error) {
+	secretOpts, err := newSecretOptions(options...)
+	if err != nil {
+		return Secret{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.refresh(ctx); err != nil {
+		return Secret{}, err
+	}
+
+	s, err := c.handler.UpdateSecretByName(name, secretOpts...)
+	if err != nil && !errors.Is(err, ErrPostHook) {
+		return Secret{}, err
+	}
+	return fromSecret(s), errors.Join(err, c.loadedNow())
+}
+
+// Delete deletes the secret with the provided name. If a post-hook
+// fails, the secret is deleted and an error wrapping ErrPostHook is
+// returned.
+func (c *Client) Delete(ctx context.Context, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.refresh(ctx); err != nil {
+		return err
+	}
+
+	err := c.handler.DeleteSecretByName(name)
+	if err != nil && !errors.Is(err, ErrPostHook) {
+		return err
+	}
+	return errors.Join(err, c.loadedNow())
+}
+
+// Sync syncs the secrets with the secondary storage set with
+// WithSecondaryStorage. Without a secondary storage it does nothing.
+// If a post-hook fails, the secrets are synced and an error wrapping
+// ErrPostHook is returned.
+func (c *Client) Sync(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err := c.handler.Sync()
+	if err != nil && !errors.Is(err, ErrPostHook) {
+		return err
+	}
+	return errors.Join(err, c.loadedNow())
+}
+
+// refresh reloads the secrets if the storage has been updated since
+// they were loaded.
+func (c *Client) refresh(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	updated, err := c.storage.Updated()
+	if err != nil {
+		return err
+	}
+	if !updated.After(c.loaded) {
+		return nil
+	}
+	if err := c.handler.Load(); err != nil {
+		return err
+	}
+	c.loaded = updated
+	return nil
+}
+
+// loadedNow sets the time of the last load to the time the storage
+// was updated, after a write by the client.
+func (c *Client) loadedNow() error {
+	updated, err := c.storage.Updated()
+	if err != nil {
+		return err
+	}
+	c.loaded = updated
+	return nil
+}
+
+// fromSecret converts a secret.Secret to a Secret.
+func fromSecret(s secret.Secret) Secret {
+	return Secret{
+		ID:          s.ID,
+		Name:        s.Name,
+		DisplayName: s.DisplayName,
+		Type:        s.Type.String(),
+		Labels:      s.Labels,
+		Tags:        s.Tags,
+		Created:     s.Created,
+		Updated:     s.Updated,
+	}
+}
+
+// fromSecrets converts secret.Secrets to Secrets.
+func fromSecrets(secrets secret.Secrets) []Secret {
+	s := make([]Secret, len(secrets))
+	for i := range secrets {
+		s[i] = fromSecret(secrets[i])
+	}
+	return s
+}
+
+// WithUser sets the user of the configuration to open the profile of.
+// Defaults to the current user.
+func WithUser(name string) Option {
+	return func(o *Options) {
+		o.Username = name
+	}
+}
+
+// WithStorage sets the storage of the secrets, instead of the
+// collection file of the profile.
+func WithStorage(storage Storage) Option {
+	return func(o *Options) {
+		o.Storage = storage
+	}
+}
+
+// WithSecondaryStorage sets the secondary storage to sync with.
+func WithSecondaryStorage(storage Storage) Option {
+	return func(o *Options) {
+		o.SecondaryStorage = storage
+	}
+}
+
+// WithKeys sets the storage key and key of the profile, instead of
+// resolving them from the keyring. Together with WithStorage no
+// configuration is needed.
+func WithKeys(storageKey, key []byte) Option {
+	return func(o *Options) {
+		o.StorageKey = storageKey
+		o.Key = key
+	}
+}
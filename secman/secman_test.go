@@ -0,0 +1,134 @@
+package secman
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/KarlGW/secman/internal/security"
+	"github.com/KarlGW/secman/secret"
+	"github.com/KarlGW/secman/storage"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClient(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			call func(ctx context.Context, client *Client, handler *secret.Handler) (string, error)
+		}
+		want    string
+		wantErr error
+	}{
+		{
+			name: "get secret",
+			input: struct {
+				call func(ctx context.Context, client *Client, handler *secret.Handler) (string, error)
+			}{
+				call: func(ctx context.Context, client *Client, _ *secret.Handler) (string, error) {
+					return client.Value(ctx, "secret")
+				},
+			},
+			want: "value",
+		},
+		{
+			name: "get secret - not found",
+			input: struct {
+				call func(ctx context.Context, client *Client, handler *secret.Handler) (string, error)
+			}{
+				call: func(ctx context.Context, client *Client, _ *secret.Handler) (string, error) {
+					return client.Value(ctx, "missing")
+				},
+			},
+			wantErr: ErrSecretNotFound,
+		},
+		{
+			name: "create secret - already exists",
+			input: struct {
+				call func(ctx context.Context, client *Client, handler *secret.Handler) (string, error)
+			}{
+				call: func(ctx context.Context, client *Client, _ *secret.Handler) (string, error) {
+					_, err := client.Create(ctx, "secret", "value")
+					return "", err
+				},
+			},
+			wantErr: ErrSecretAlreadyExists,
+		},
+		{
+			name: "get secret - updated by other process",
+			input: struct {
+				call func(ctx context.Context, client *Client, handler *secret.Handler) (string, error)
+			}{
+				call: func(ctx context.Context, client *Client, handler *secret.Handler) (string, error) {
+					// Make sure the storage is updated after the client loaded.
+					time.Sleep(time.Millisecond)
+					if _, err := handler.UpdateSecretByName("secret", secret.WithValue([]byte("rotated"))); err != nil {
+						return "", err
+					}
+					return client.Value(ctx, "secret")
+				},
+			},
+			want: "rotated",
+		},
+		{
+			name: "delete secret - deleted by other process",
+			input: struct {
+				call func(ctx context.Context, client *Client, handler *secret.Handler) (string, error)
+			}{
+				call: func(ctx context.Context, client *Client, handler *secret.Handler) (string, error) {
+					time.Sleep(time.Millisecond)
+					if err := handler.DeleteSecretByName("secret"); err != nil {
+						return "", err
+					}
+					return "", client.Delete(ctx, "secret")
+				},
+			},
+			wantErr: ErrSecretNotFound,
+		},
+		{
+			name: "canceled context",
+			input: struct {
+				call func(ctx context.Context, client *Client, handler *secret.Handler) (string, error)
+			}{
+				call: func(ctx context.Context, client *Client, _ *secret.Handler) (string, error) {
+					ctx, cancel := context.WithCancel(ctx)
+					cancel()
+					_, err := client.List(ctx)
+					return "", err
+				},
+			},
+			wantErr: context.Canceled,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			key := bytes.Repeat([]byte{1}, secret.KeyLength)
+			stg := storage.NewMemory(nil)
+			handler, err := secret.NewHandler("profile", security.Key{Value: key}, security.Key{Value: key}, stg)
+			if err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+			if _, err := handler.AddSecret("secret", "value"); err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+
+			ctx := context.Background()
+			client, err := Open(ctx, "profile", WithKeys(key, key), WithStorage(stg))
+			if err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+
+			got, gotErr := test.input.call(ctx, client, handler)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("call() = unexpected result (-want +got)\n%s\n", diff)
+			}
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Errorf("call() = unexpected error, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+		})
+	}
+}
@@ -80,6 +80,17 @@ func (c Collection) GetByName(name string) Secret {
 	return c.secrets[i]
 }
 
+// GetByLabel gets all secrets with the provided label.
+func (c Collection) GetByLabel(label string) []Secret {
+	var secrets []Secret
+	for _, secret := range c.secrets {
+		if slices.Contains(secret.Labels, label) {
+			secrets = append(secrets, secret)
+		}
+	}
+	return secrets
+}
+
 // Add a secret to the collection. Returns true if secret was added,
 // false if not (secret already exists).
 func (c *Collection) Add(secret Secret) error {
@@ -178,6 +189,45 @@ func (c *Collection) remove(i int) {
 	}
 }
 
+// Merge the secrets of the provided collection into the collection.
+// Secrets that do not exist are added, and existing secrets (by ID) are
+// replaced if the provided secret was updated more recently. Secrets with
+// a name that already exists on another secret are skipped, and their
+// names are returned.
+func (c *Collection) Merge(other Collection) []string {
+	var skipped []string
+	for _, secret := range other.secrets {
+		existing := c.GetByID(secret.ID)
+		if !existing.Valid() {
+			if err := c.Add(secret); err != nil {
+				skipped = append(skipped, secret.Name)
+			}
+			continue
+		}
+		if !lastModified(secret).After(lastModified(existing)) {
+			continue
+		}
+		if i, ok := c.names[secret.Name]; ok && c.secrets[i].ID != secret.ID {
+			skipped = append(skipped, secret.Name)
+			continue
+		}
+		i := c.ids[existing.ID]
+		delete(c.names, existing.Name)
+		c.names[secret.Name] = i
+		c.secrets[i] = secret
+		c.updated = now()
+	}
+	return skipped
+}
+
+// lastModified returns when the secret was last modified.
+func lastModified(secret Secret) time.Time {
+	if secret.Updated.After(secret.Created) {
+		return secret.Updated
+	}
+	return secret.Created
+}
+
 // Updated returns when the collection was last modified.
 func (c Collection) Updated() time.Time {
 	return c.updated
@@ -1121,3 +1121,70 @@ func TestCollection_RemoveByName(t *testing.T) {
 		})
 	}
 }
+
+func TestCollection_Merge(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			collection Collection
+			other      Collection
+		}
+		want        Collection
+		wantSkipped []string
+	}{
+		{
+			name: "merge collections",
+			input: struct {
+				collection Collection
+				other      Collection
+			}{
+				collection: Collection{
+					secrets: []Secret{
+						{ID: "1", Name: "secret-1", Created: _testCreated},
+						{ID: "2", Name: "secret-2", Created: _testCreated, Updated: _testUpdated},
+					},
+					ids:   map[string]int{"1": 0, "2": 1},
+					names: map[string]int{"secret-1": 0, "secret-2": 1},
+				},
+				other: Collection{
+					secrets: []Secret{
+						{ID: "1", Name: "secret-1-renamed", Created: _testCreated, Updated: _testUpdated},
+						{ID: "2", Name: "secret-2-old", Created: _testCreated},
+						{ID: "3", Name: "secret-3", Created: _testCreated},
+						{ID: "4", Name: "secret-2", Created: _testCreated},
+					},
+				},
+			},
+			want: Collection{
+				secrets: []Secret{
+					{ID: "1", Name: "secret-1-renamed", Created: _testCreated, Updated: _testUpdated},
+					{ID: "2", Name: "secret-2", Created: _testCreated, Updated: _testUpdated},
+					{ID: "3", Name: "secret-3", Created: _testCreated},
+				},
+				ids:     map[string]int{"1": 0, "2": 1, "3": 2},
+				names:   map[string]int{"secret-1-renamed": 0, "secret-2": 1, "secret-3": 2},
+				updated: _testUpdated,
+			},
+			wantSkipped: []string{"secret-2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			now = func() time.Time {
+				return _testUpdated
+			}
+
+			gotSkipped := test.input.collection.Merge(test.input.other)
+			got := test.input.collection
+
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(Collection{}), cmpopts.IgnoreUnexported(Secret{})); diff != "" {
+				t.Errorf("Merge() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantSkipped, gotSkipped); diff != "" {
+				t.Errorf("Merge() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
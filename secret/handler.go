@@ -3,6 +3,7 @@ package secret
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/KarlGW/secman/internal/gob"
@@ -21,8 +22,43 @@ var (
 	ErrSaveCollection = errors.New("save collection failed")
 	// ErrSecretNotFound is returned when a secret cannot be found.
 	ErrSecretNotFound = errors.New("a secret with that identifier cannot be found")
+	// ErrAmbiguousReference is returned when a reference matches several secrets.
+	ErrAmbiguousReference = errors.New("reference matches several secrets")
+	// ErrPreHook is returned when a pre-hook fails. The operation
+	// is aborted.
+	ErrPreHook = errors.New("pre-hook failed")
+	// ErrPostHook is returned when a post-hook fails. The operation
+	// has been saved.
+	ErrPostHook = errors.New("post-hook failed")
 )
 
+// Operation is an operation that changes secrets.
+type Operation string
+
+const (
+	// OperationCreate is the operation of creating a secret.
+	OperationCreate Operation = "create"
+	// OperationUpdate is the operation of updating a secret, without
+	// changing its value.
+	OperationUpdate Operation = "update"
+	// OperationDelete is the operation of deleting a secret.
+	OperationDelete Operation = "delete"
+	// OperationRotate is the operation of updating the value
+	// of a secret.
+	OperationRotate Operation = "rotate"
+	// OperationSync is the operation of syncing the secrets with
+	// the secondary storage.
+	OperationSync Operation = "sync"
+)
+
+// Hooks is the interface that wraps around methods Pre and Post, that
+// are called before and after an operation. The secret is empty for
+// OperationSync.
+type Hooks interface {
+	Pre(op Operation, secret Secret) error
+	Post(op Operation, secret Secret) error
+}
+
 // Storage is the interface that wraps around methods Save, Load and Updated.
 type Storage interface {
 	Save(data []byte) error
@@ -39,12 +75,20 @@ type Handler struct {
 	secondaryStorage Storage
 	storageKey       security.Key
 	key              security.Key
+	// storageCipher and cipher replaces storageKey and key
+	// when set.
+	storageCipher Cipher
+	cipher        Cipher
+	hooks         Hooks
 }
 
 // HandlerOptions contains options for a Handler.
 type HandlerOptions struct {
 	SecondaryStorage Storage
 	LoadCollection   bool
+	StorageCipher    Cipher
+	Cipher           Cipher
+	Hooks            Hooks
 }
 
 // HandlerOption is a function that sets HandlerOptions.
@@ -55,26 +99,30 @@ func NewHandler(profileID string, storageKey, key security.Key, storage Storage,
 	if len(profileID) == 0 {
 		return nil, ErrProfileID
 	}
-	if len(storageKey.Value) != KeyLength {
+
+	opts := HandlerOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	if opts.StorageCipher == nil && len(storageKey.Value) != KeyLength {
 		return nil, ErrInvalidKeyLength
 	}
-	if len(key.Value) != KeyLength {
+	if opts.Cipher == nil && len(key.Value) != KeyLength {
 		return nil, ErrInvalidKeyLength
 	}
 	if storage == nil {
 		return nil, ErrStorage
 	}
 
-	opts := HandlerOptions{}
-	for _, option := range options {
-		option(&opts)
-	}
-
 	handler := &Handler{
 		storage:          storage,
 		secondaryStorage: opts.SecondaryStorage,
 		storageKey:       storageKey,
 		key:              key,
+		storageCipher:    opts.StorageCipher,
+		cipher:           opts.Cipher,
+		hooks:            opts.Hooks,
 	}
 
 	if opts.LoadCollection {
@@ -103,7 +151,7 @@ func (h Handler) Collection() *Collection {
 
 // Load collection into Handler.
 func (h *Handler) Load() error {
-	collection, err := loadDecryptDecode(h.storage, h.storageKey.Value)
+	collection, err := loadDecryptDecode(h.storage, h.currentStorageCipher())
 	if err != nil {
 		return err
 	}
@@ -111,9 +159,31 @@ func (h *Handler) Load() error {
 	return nil
 }
 
+// Reload loads the collection into Handler if the storage has been
+// updated after the provided time, like by another process. It returns
+// the time the storage was updated, to pass to the next call.
+func (h *Handler) Reload(loaded time.Time) (time.Time, error) {
+	updated, err := h.storage.Updated()
+	if err != nil {
+		return loaded, err
+	}
+	if !updated.After(loaded) {
+		return loaded, nil
+	}
+	if err := h.Load(); err != nil {
+		return loaded, err
+	}
+	return updated, nil
+}
+
+// Updated returns the time the storage was updated.
+func (h Handler) Updated() (time.Time, error) {
+	return h.storage.Updated()
+}
+
 // Save collection.
 func (h *Handler) Save() error {
-	return encodeEncryptSave(h.storage, h.collection, h.storageKey.Value)
+	return encodeEncryptSave(h.storage, h.collection, h.currentStorageCipher())
 }
 
 // Sync current collection with collection from secondary storage (if any).
@@ -132,6 +202,9 @@ func (h *Handler) Sync() error {
 	if err != nil {
 		return err
 	}
+	if err := h.pre(OperationSync, Secret{}); err != nil {
+		return err
+	}
 
 	// Check if the remote storage is more recent. This is a shallow check on the state of the
 	// secrets. In further updates a deeper check should be made available.
@@ -144,13 +217,16 @@ func (h *Handler) Sync() error {
 		dstStg = h.secondaryStorage
 	}
 
-	collection, err := loadDecryptDecode(srcStg, h.storageKey.Value)
+	collection, err := loadDecryptDecode(srcStg, h.currentStorageCipher())
 	if err != nil {
 		return err
 	}
 
 	h.collection = &collection
-	return encodeEncryptSave(dstStg, h.collection, h.storageKey.Value)
+	if err := encodeEncryptSave(dstStg, h.collection, h.currentStorageCipher()); err != nil {
+		return err
+	}
+	return h.post(OperationSync, Secret{})
 }
 
 // GetSecretByID retrieves a secret by ID.
@@ -159,10 +235,7 @@ func (h Handler) GetSecretByID(id string) (Secret, error) {
 	if !secret.Valid() {
 		return secret, ErrSecretNotFound
 	}
-	if secret.key == nil {
-		secret.key = h.key.Value
-	}
-	return secret, nil
+	return h.withKey(secret), nil
 }
 
 // GetSecretByName retrieves a secret by Name.
@@ -171,23 +244,87 @@ func (h Handler) GetSecretByName(name string) (Secret, error) {
 	if !secret.Valid() {
 		return secret, ErrSecretNotFound
 	}
-	if secret.key == nil {
-		secret.key = h.key.Value
+	return h.withKey(secret), nil
+}
+
+// GetSecretsByLabel retrieves all secrets with the provided label.
+func (h Handler) GetSecretsByLabel(label string) (Secrets, error) {
+	secrets := h.collection.GetByLabel(label)
+	if len(secrets) == 0 {
+		return nil, ErrSecretNotFound
 	}
-	return secret, nil
+	for i := range secrets {
+		secrets[i] = h.withKey(secrets[i])
+	}
+	return secrets, nil
+}
+
+// GetSecretByReference retrieves a secret by a reference. A reference
+// is either a name or ID, or one of:
+//
+//	@id:<id>
+//	@name:<name>
+//	@label:<label>
+//
+// A label reference must match exactly one secret.
+func (h Handler) GetSecretByReference(ref string) (Secret, error) {
+	kind, value, ok := strings.Cut(ref, ":")
+	if !ok || !strings.HasPrefix(kind, "@") {
+		secret, err := h.GetSecretByName(ref)
+		if errors.Is(err, ErrSecretNotFound) {
+			return h.GetSecretByID(ref)
+		}
+		return secret, err
+	}
+
+	switch kind {
+	case "@id":
+		return h.GetSecretByID(value)
+	case "@name":
+		return h.GetSecretByName(value)
+	case "@label":
+		secrets, err := h.GetSecretsByLabel(value)
+		if err != nil {
+			return Secret{}, err
+		}
+		if len(secrets) > 1 {
+			return Secret{}, fmt.Errorf("%w: %s", ErrAmbiguousReference, ref)
+		}
+		return secrets[0], nil
+	}
+	return Secret{}, fmt.Errorf("invalid reference: %s", ref)
 }
 
 // ListSecrets lists all secrets.
 func (h Handler) ListSecrets() (Secrets, error) {
-	return Secrets(h.collection.secrets), nil
+	return h.withKeys(h.collection.secrets), nil
+}
+
+// SearchSecrets searches the secrets with the provided query.
+// See ParseQuery for the format of the query.
+func (h Handler) SearchSecrets(query string) (Secrets, error) {
+	q, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return h.withKeys(h.collection.Search(q)), nil
 }
 
 // AddSecret adds a new secret to the collection.
 func (h Handler) AddSecret(name, value string, options ...SecretOption) (Secret, error) {
+	if h.cipher != nil {
+		options = append(options, WithCipher(h.cipher))
+	}
 	secret, err := NewSecret(name, value, h.key.Value, options...)
 	if err != nil {
 		return Secret{}, err
 	}
+	if h.collection.GetByName(secret.Name).Valid() {
+		return secret, ErrSecretAlreadyExists
+	}
+	if err := h.pre(OperationCreate, secret); err != nil {
+		return Secret{}, err
+	}
 	if err := h.collection.Add(secret); err != nil {
 		return secret, err
 	}
@@ -196,7 +333,10 @@ func (h Handler) AddSecret(name, value string, options ...SecretOption) (Secret,
 	if err != nil {
 		return secret, err
 	}
-	return secret, h.Save()
+	if err := h.Save(); err != nil {
+		return secret, err
+	}
+	return secret, h.post(OperationCreate, secret)
 }
 
 // UpdateSecretByUD updates a secret in the collection by ID.
@@ -206,12 +346,7 @@ func (h Handler) UpdateSecretByID(id string, options ...SecretOption) (Secret, e
 		return secret, ErrSecretNotFound
 	}
 
-	secret, err := h.updateSecret(secret, options...)
-	if err != nil {
-		return Secret{}, err
-	}
-
-	return secret, h.Save()
+	return h.update(secret, options...)
 }
 
 // UpdateSecretByName updates a secret in the collection by name.
@@ -221,22 +356,44 @@ func (h Handler) UpdateSecretByName(name string, options ...SecretOption) (Secre
 		return Secret{}, ErrSecretNotFound
 	}
 
-	secret, err := h.updateSecret(secret, options...)
-	if err != nil {
-		return Secret{}, err
+	return h.update(secret, options...)
+}
+
+// update updates the secret with the provided options and saves the
+// collection, with the hooks of the handler. The operation is
+// OperationRotate if a value is set, otherwise OperationUpdate.
+func (h Handler) update(secret Secret, options ...SecretOption) (Secret, error) {
+	opts := SecretOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+	op := OperationUpdate
+	if len(opts.Value) > 0 {
+		op = OperationRotate
 	}
 
-	return secret, h.Save()
+	secret = h.withKey(secret)
+	if err := secret.Set(options...); err != nil {
+		return Secret{}, err
+	}
+	if err := h.pre(op, secret); err != nil {
+		return Secret{}, err
+	}
+	if err := h.collection.Update(secret); err != nil {
+		return Secret{}, err
+	}
+	if err := h.Save(); err != nil {
+		return secret, err
+	}
+	return secret, h.post(op, secret)
 }
 
 // updateSecret updates the secret with the provided options.
 func (h Handler) updateSecret(secret Secret, options ...SecretOption) (Secret, error) {
-	if secret.key == nil {
-		// If the collection and secret is newly loaded, the
-		// key will not be set. Set the one configured on the
-		// handler.
-		secret.key = h.key.Value
-	}
+	// If the collection and secret is newly loaded, the
+	// key will not be set. Set the one configured on the
+	// handler.
+	secret = h.withKey(secret)
 	if err := secret.Set(options...); err != nil {
 		return Secret{}, err
 	}
@@ -250,18 +407,39 @@ func (h Handler) updateSecret(secret Secret, options ...SecretOption) (Secret, e
 
 // DeleteSecretByID deletes a secret by ID.
 func (h Handler) DeleteSecretByID(id string) error {
-	if err := h.collection.RemoveByID(id); err != nil {
-		return err
-	}
-	return h.Save()
+	return h.delete(h.collection.GetByID(id))
 }
 
 // DeleteSecretByName deletes a secret by name.
 func (h Handler) DeleteSecretByName(name string) error {
-	if err := h.collection.RemoveByName(name); err != nil {
+	return h.delete(h.collection.GetByName(name))
+}
+
+// delete deletes the secret and saves the collection, with the hooks
+// of the handler.
+func (h Handler) delete(secret Secret) error {
+	if !secret.Valid() {
+		return ErrSecretNotFound
+	}
+	if err := h.pre(OperationDelete, secret); err != nil {
 		return err
 	}
-	return h.Save()
+	if err := h.collection.RemoveByID(secret.ID); err != nil {
+		return err
+	}
+	if err := h.Save(); err != nil {
+		return err
+	}
+	return h.post(OperationDelete, secret)
+}
+
+// Merge the provided collection into the collection of the handler,
+// and save it. The secrets of the provided collection must be encrypted
+// with the key of the handler. The names of secrets that could not be
+// merged because of conflicting names are returned.
+func (h *Handler) Merge(collection *Collection) ([]string, error) {
+	skipped := h.collection.Merge(*collection)
+	return skipped, h.Save()
 }
 
 // UpdateKey updates the key on the handler and all all secrets.
@@ -273,18 +451,83 @@ func (h *Handler) UpdateKey(key security.Key) error {
 		}
 	}
 	h.key = key
+	h.cipher = nil
 	return h.Save()
 }
 
+// UpdateStorageKey updates the storage key on the handler and
+// saves the collection with it.
+func (h *Handler) UpdateStorageKey(key security.Key) error {
+	if len(key.Value) != KeyLength {
+		return ErrInvalidKeyLength
+	}
+	h.storageKey = key
+	h.storageCipher = nil
+	return h.Save()
+}
+
+// pre calls the pre-hook of the handler, if any.
+func (h Handler) pre(op Operation, secret Secret) error {
+	if h.hooks == nil {
+		return nil
+	}
+	if err := h.hooks.Pre(op, secret); err != nil {
+		return fmt.Errorf("%w: %w", ErrPreHook, err)
+	}
+	return nil
+}
+
+// post calls the post-hook of the handler, if any.
+func (h Handler) post(op Operation, secret Secret) error {
+	if h.hooks == nil {
+		return nil
+	}
+	if err := h.hooks.Post(op, secret); err != nil {
+		return fmt.Errorf("%w: %w", ErrPostHook, err)
+	}
+	return nil
+}
+
+// withKey sets the key and cipher configured on the handler
+// to the secret, if they are not already set.
+func (h Handler) withKey(secret Secret) Secret {
+	if secret.key == nil {
+		secret.key = h.key.Value
+	}
+	if secret.cipher == nil {
+		secret.cipher = h.cipher
+	}
+	return secret
+}
+
+// withKeys returns a copy of the secrets with the key and cipher
+// configured on the handler set.
+func (h Handler) withKeys(secrets []Secret) Secrets {
+	s := make(Secrets, len(secrets))
+	for i := range secrets {
+		s[i] = h.withKey(secrets[i])
+	}
+	return s
+}
+
+// currentStorageCipher returns the cipher used for the storage. If no
+// cipher is set, one is created from the storage key.
+func (h Handler) currentStorageCipher() Cipher {
+	if h.storageCipher != nil {
+		return h.storageCipher
+	}
+	return keyCipher(h.storageKey.Value)
+}
+
 // loadDecryptDecode loads data from storage, decrypts it and finally
 // decodes it.
-func loadDecryptDecode(storage Storage, key []byte) (Collection, error) {
+func loadDecryptDecode(storage Storage, c Cipher) (Collection, error) {
 	b, err := storage.Load()
 	if err != nil {
 		return Collection{}, fmt.Errorf("%w: %w", ErrLoadCollection, err)
 	}
 
-	decrypted, err := security.Decrypt(b, key)
+	decrypted, err := c.Decrypt(b)
 	if err != nil {
 		return Collection{}, fmt.Errorf("%w: %w", ErrLoadCollection, err)
 	}
@@ -297,13 +540,13 @@ func loadDecryptDecode(storage Storage, key []byte) (Collection, error) {
 }
 
 // encodeEncryptSave encrypt, encodes and finally saves data to storage.
-func encodeEncryptSave(storage Storage, collection *Collection, key []byte) error {
+func encodeEncryptSave(storage Storage, collection *Collection, c Cipher) error {
 	encoded, err := gob.Encode(collection)
 	if err != nil {
 		return fmt.Errorf("%w: %w", ErrSaveCollection, err)
 	}
 
-	encrypted, err := security.Encrypt(encoded, key)
+	encrypted, err := c.Encrypt(encoded)
 	if err != nil {
 		return fmt.Errorf("%w: %w", ErrSaveCollection, err)
 	}
@@ -317,6 +560,24 @@ func WithSecondaryStorage(storage Storage) HandlerOption {
 	}
 }
 
+// WithCiphers sets ciphers for the storage and the secrets to
+// the Handler. They replace the storage key and key, which
+// then are not required.
+func WithCiphers(storageCipher, cipher Cipher) HandlerOption {
+	return func(o *HandlerOptions) {
+		o.StorageCipher = storageCipher
+		o.Cipher = cipher
+	}
+}
+
+// WithHooks sets hooks that are called before and after operations
+// that change secrets.
+func WithHooks(hooks Hooks) HandlerOption {
+	return func(o *HandlerOptions) {
+		o.Hooks = hooks
+	}
+}
+
 // WithLoadCollection() sets that collections should be loaded
 // when creating a new handler.
 func WithLoadCollection() HandlerOption {
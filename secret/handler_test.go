@@ -1,6 +1,8 @@
 package secret
 
 import (
+	"bytes"
+	"errors"
 	"testing"
 	"time"
 
@@ -134,10 +136,291 @@ func TestHandler_Sync(t *testing.T) {
 	}
 }
 
+func TestHandler_GetSecretByReference(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   string
+		want    Secret
+		wantErr error
+	}{
+		{
+			name:  "by name",
+			input: "secret-1",
+			want:  Secret{ID: "1", Name: "secret-1", Labels: []string{"prod"}},
+		},
+		{
+			name:  "by ID",
+			input: "2",
+			want:  Secret{ID: "2", Name: "secret-2", Labels: []string{"dev", "shared"}},
+		},
+		{
+			name:  "by @name",
+			input: "@name:secret-2",
+			want:  Secret{ID: "2", Name: "secret-2", Labels: []string{"dev", "shared"}},
+		},
+		{
+			name:  "by @id",
+			input: "@id:1",
+			want:  Secret{ID: "1", Name: "secret-1", Labels: []string{"prod"}},
+		},
+		{
+			name:  "by @label",
+			input: "@label:dev",
+			want:  Secret{ID: "2", Name: "secret-2", Labels: []string{"dev", "shared"}},
+		},
+		{
+			name:    "by @label - ambiguous",
+			input:   "@label:shared",
+			wantErr: ErrAmbiguousReference,
+		},
+		{
+			name:    "not found",
+			input:   "secret-4",
+			wantErr: ErrSecretNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := Handler{
+				collection: &Collection{
+					secrets: []Secret{
+						{ID: "1", Name: "secret-1", Labels: []string{"prod"}},
+						{ID: "2", Name: "secret-2", Labels: []string{"dev", "shared"}},
+						{ID: "3", Name: "secret-3", Labels: []string{"shared"}},
+					},
+					ids:   map[string]int{"1": 0, "2": 1, "3": 2},
+					names: map[string]int{"secret-1": 0, "secret-2": 1, "secret-3": 2},
+				},
+				key: _testKey,
+			}
+
+			got, gotErr := handler.GetSecretByReference(test.input)
+
+			if diff := cmp.Diff(test.want, got, cmpopts.IgnoreUnexported(Secret{})); diff != "" {
+				t.Errorf("GetSecretByReference() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("GetSecretByReference() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestHandler_ListSecrets(t *testing.T) {
+	value1, _ := security.Encrypt([]byte("value-1"), _testKey.Value)
+	value2, _ := security.Encrypt([]byte("value-2"), _testKey.Value)
+	otherKey := security.Key{Value: bytes.Repeat([]byte{2}, KeyLength)}
+
+	var tests = []struct {
+		name  string
+		input struct {
+			secrets []Secret
+			key     security.Key
+		}
+		want    [][]byte
+		wantErr error
+	}{
+		{
+			name: "list secrets",
+			input: struct {
+				secrets []Secret
+				key     security.Key
+			}{
+				secrets: []Secret{
+					{ID: "1", Name: "secret-1", Value: value1},
+					{ID: "2", Name: "secret-2", Value: value2},
+				},
+				key: _testKey,
+			},
+			want: [][]byte{[]byte("value-1"), []byte("value-2")},
+		},
+		{
+			name: "list secrets - empty collection",
+			input: struct {
+				secrets []Secret
+				key     security.Key
+			}{
+				key: _testKey,
+			},
+			want: nil,
+		},
+		{
+			name: "list secrets - error, other key",
+			input: struct {
+				secrets []Secret
+				key     security.Key
+			}{
+				secrets: []Secret{
+					{ID: "1", Name: "secret-1", Value: value1},
+				},
+				key: otherKey,
+			},
+			want:    nil,
+			wantErr: ErrSecretDecrypt,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := Handler{
+				collection: &Collection{
+					secrets: test.input.secrets,
+				},
+				key: test.input.key,
+			}
+
+			secrets, gotErr := handler.ListSecrets()
+			var got [][]byte
+			for _, secret := range secrets {
+				decrypted, err := secret.Decrypt()
+				if err != nil {
+					gotErr = err
+					break
+				}
+				got = append(got, decrypted)
+			}
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("ListSecrets() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("ListSecrets() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestHandler_Hooks(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			preErr error
+			call   func(handler *Handler) error
+		}
+		want      []string
+		wantSaved bool
+		wantErr   error
+	}{
+		{
+			name: "create",
+			input: struct {
+				preErr error
+				call   func(handler *Handler) error
+			}{
+				call: func(handler *Handler) error {
+					_, err := handler.AddSecret("new", "value")
+					return err
+				},
+			},
+			want:      []string{"pre create new", "post create new"},
+			wantSaved: true,
+		},
+		{
+			name: "update",
+			input: struct {
+				preErr error
+				call   func(handler *Handler) error
+			}{
+				call: func(handler *Handler) error {
+					_, err := handler.UpdateSecretByName("secret", WithDisplayName("Secret"))
+					return err
+				},
+			},
+			want:      []string{"pre update secret", "post update secret"},
+			wantSaved: true,
+		},
+		{
+			name: "rotate",
+			input: struct {
+				preErr error
+				call   func(handler *Handler) error
+			}{
+				call: func(handler *Handler) error {
+					_, err := handler.UpdateSecretByID("1", WithValue([]byte("rotated")))
+					return err
+				},
+			},
+			want:      []string{"pre rotate secret", "post rotate secret"},
+			wantSaved: true,
+		},
+		{
+			name: "delete",
+			input: struct {
+				preErr error
+				call   func(handler *Handler) error
+			}{
+				call: func(handler *Handler) error {
+					return handler.DeleteSecretByName("secret")
+				},
+			},
+			want:      []string{"pre delete secret", "post delete secret"},
+			wantSaved: true,
+		},
+		{
+			name: "delete - failing pre-hook",
+			input: struct {
+				preErr error
+				call   func(handler *Handler) error
+			}{
+				preErr: errors.New("hook error"),
+				call: func(handler *Handler) error {
+					return handler.DeleteSecretByName("secret")
+				},
+			},
+			want:    []string{"pre delete secret"},
+			wantErr: ErrPreHook,
+		},
+		{
+			name: "delete - not found",
+			input: struct {
+				preErr error
+				call   func(handler *Handler) error
+			}{
+				call: func(handler *Handler) error {
+					return handler.DeleteSecretByName("missing")
+				},
+			},
+			wantErr: ErrSecretNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hooks := &mockHooks{preErr: test.input.preErr}
+			storage := &mockStorage{}
+			collection := Collection{}
+			collection.Add(Secret{ID: "1", Name: "secret"})
+			handler := &Handler{
+				collection: &collection,
+				storage:    storage,
+				storageKey: _testKey,
+				key:        _testKey,
+				hooks:      hooks,
+			}
+
+			gotErr := test.input.call(handler)
+
+			if diff := cmp.Diff(test.want, hooks.calls); diff != "" {
+				t.Errorf("call() = unexpected result (-want +got)\n%s\n", diff)
+			}
+			if diff := cmp.Diff(test.wantSaved, storage.saved); diff != "" {
+				t.Errorf("call() = unexpected save (-want +got)\n%s\n", diff)
+			}
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("call() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
 type mockStorage struct {
 	collection Collection
 	err        error
 	updated    time.Time
+	saved      bool
 }
 
 func (stg *mockStorage) Save(data []byte) error {
@@ -153,6 +436,7 @@ func (stg *mockStorage) Save(data []byte) error {
 	}
 
 	stg.collection = c
+	stg.saved = true
 	return nil
 }
 
@@ -175,3 +459,18 @@ func (stg mockStorage) Updated() (time.Time, error) {
 
 	return stg.updated, nil
 }
+
+type mockHooks struct {
+	calls  []string
+	preErr error
+}
+
+func (h *mockHooks) Pre(op Operation, secret Secret) error {
+	h.calls = append(h.calls, "pre "+string(op)+" "+secret.Name)
+	return h.preErr
+}
+
+func (h *mockHooks) Post(op Operation, secret Secret) error {
+	h.calls = append(h.calls, "post "+string(op)+" "+secret.Name)
+	return nil
+}
@@ -0,0 +1,258 @@
+package secret
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	// ErrInvalidQuery is returned when a query cannot be parsed.
+	ErrInvalidQuery = errors.New("invalid query")
+)
+
+// Weights of the fields of a secret when ranking search results.
+const (
+	weightName        = 4
+	weightDisplayName = 3
+	weightLabel       = 2
+	weightTag         = 1
+)
+
+// Query contains the terms and filters of a search.
+type Query struct {
+	// Terms are matched fuzzily against the name, display name, labels
+	// and tag values of secrets. All terms must match.
+	Terms []string
+	// Filters must all be satisfied by a secret.
+	Filters []Filter
+}
+
+// Filter is a function that reports whether a secret satisfies
+// a filter expression.
+type Filter func(secret Secret) bool
+
+// ParseQuery parses a query. A query consists of terms separated by
+// whitespace and the filter expressions:
+//
+//   - type:<type>
+//   - label:<label>
+//   - tag:<key> and tag:<key>=<value>
+//   - updated<age, updated>age, created<age and created>age where age
+//     is a duration (30d, 2w, 12h) or a date (2006-01-02)
+func ParseQuery(s string) (Query, error) {
+	var query Query
+	for _, token := range strings.Fields(s) {
+		filter, ok, err := parseFilter(token)
+		if err != nil {
+			return Query{}, fmt.Errorf("%w: %w", ErrInvalidQuery, err)
+		}
+		if ok {
+			query.Filters = append(query.Filters, filter)
+			continue
+		}
+		query.Terms = append(query.Terms, token)
+	}
+	return query, nil
+}
+
+// parseFilter parses a filter expression. Returns false if the token
+// is not a filter expression.
+func parseFilter(token string) (Filter, bool, error) {
+	for _, field := range []string{"updated", "created"} {
+		rest, ok := strings.CutPrefix(token, field)
+		if !ok || len(rest) == 0 || (rest[0] != '<' && rest[0] != '>') {
+			continue
+		}
+		filter, err := timeFilter(field, rest[0], rest[1:])
+		return filter, true, err
+	}
+
+	key, value, ok := strings.Cut(token, ":")
+	if !ok {
+		return nil, false, nil
+	}
+	switch key {
+	case "type":
+		t, err := ParseType(value)
+		if err != nil {
+			return nil, true, err
+		}
+		return func(secret Secret) bool {
+			return secret.Type == t
+		}, true, nil
+	case "label":
+		return func(secret Secret) bool {
+			return slices.Contains(secret.Labels, value)
+		}, true, nil
+	case "tag":
+		k, v, hasValue := strings.Cut(value, "=")
+		return func(secret Secret) bool {
+			tv, ok := secret.Tags[k]
+			return ok && (!hasValue || tv == v)
+		}, true, nil
+	}
+	return nil, false, nil
+}
+
+// timeFilter creates a filter on the updated or created time of
+// secrets, where updated is the last time the secret was modified.
+// For durations < means newer than and > means older than.
+// For dates < means before and > means after.
+func timeFilter(field string, op byte, value string) (Filter, error) {
+	var t time.Time
+	if d, err := parseAge(value); err == nil {
+		t = now().Add(-d)
+		// An age less than the duration is a time after the point in time.
+		if op == '<' {
+			op = '>'
+		} else {
+			op = '<'
+		}
+	} else if t, err = time.ParseInLocation(time.DateOnly, value, time.Local); err != nil {
+		return nil, fmt.Errorf("invalid age or date %q in %s filter", value, field)
+	}
+
+	return func(secret Secret) bool {
+		st := lastModified(secret)
+		if field == "created" {
+			st = secret.Created
+		}
+		if op == '<' {
+			return st.Before(t)
+		}
+		return st.After(t)
+	}, nil
+}
+
+// parseAge parses a duration with the additional units d (days)
+// and w (weeks).
+func parseAge(s string) (time.Duration, error) {
+	if len(s) > 1 {
+		var unit time.Duration
+		switch s[len(s)-1] {
+		case 'd':
+			unit = 24 * time.Hour
+		case 'w':
+			unit = 7 * 24 * time.Hour
+		}
+		if unit > 0 {
+			n, err := strconv.Atoi(s[:len(s)-1])
+			if err != nil {
+				return 0, err
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	return time.ParseDuration(s)
+}
+
+// Search the collection with the provided query. The secrets are
+// ranked by how well they match the terms of the query. Secrets
+// with equal rank are sorted by name.
+func (c Collection) Search(query Query) []Secret {
+	type result struct {
+		secret Secret
+		score  int
+	}
+
+	var results []result
+	for _, secret := range c.secrets {
+		if !matchFilters(secret, query.Filters) {
+			continue
+		}
+		score, ok := matchTerms(secret, query.Terms)
+		if !ok {
+			continue
+		}
+		results = append(results, result{secret: secret, score: score})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return results[i].secret.Name < results[j].secret.Name
+	})
+
+	secrets := make([]Secret, len(results))
+	for i := range results {
+		secrets[i] = results[i].secret
+	}
+	return secrets
+}
+
+// matchFilters reports whether the secret satisfies all filters.
+func matchFilters(secret Secret, filters []Filter) bool {
+	for _, filter := range filters {
+		if !filter(secret) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchTerms returns the score of the secret for the terms. Returns
+// false if any of the terms does not match.
+func matchTerms(secret Secret, terms []string) (int, bool) {
+	var total int
+	for _, term := range terms {
+		best := fuzzyScore(term, secret.Name) * weightName
+		best = max(best, fuzzyScore(term, secret.DisplayName)*weightDisplayName)
+		for _, label := range secret.Labels {
+			best = max(best, fuzzyScore(term, label)*weightLabel)
+		}
+		for _, value := range secret.Tags {
+			best = max(best, fuzzyScore(term, value)*weightTag)
+		}
+		if best == 0 {
+			return 0, false
+		}
+		total += best
+	}
+	return total, true
+}
+
+// fuzzyScore returns the score of how well the pattern matches s. Exact
+// matches score highest, followed by prefix and substring matches. Other
+// matches must contain the characters of the pattern in order, and are
+// scored on consecutive characters and characters at word boundaries.
+// Returns 0 if the pattern does not match. Matching is case-insensitive.
+func fuzzyScore(pattern, s string) int {
+	if len(pattern) == 0 || len(s) == 0 {
+		return 0
+	}
+	pattern, s = strings.ToLower(pattern), strings.ToLower(s)
+	switch {
+	case pattern == s:
+		return 100
+	case strings.HasPrefix(s, pattern):
+		return 80
+	case strings.Contains(s, pattern):
+		return 60
+	}
+
+	p, r := []rune(pattern), []rune(s)
+	var score, consecutive, pi int
+	for i := 0; i < len(r) && pi < len(p); i++ {
+		if r[i] != p[pi] {
+			consecutive = 0
+			continue
+		}
+		pi++
+		consecutive++
+		score += consecutive
+		if i == 0 || !unicode.IsLetter(r[i-1]) && !unicode.IsDigit(r[i-1]) {
+			score += 2
+		}
+	}
+	if pi < len(p) {
+		return 0
+	}
+	return min(10+score, 50)
+}
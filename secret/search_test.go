@@ -0,0 +1,131 @@
+package secret
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestCollection_Search(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr error
+	}{
+		{
+			name:  "exact match ranks first",
+			input: "db",
+			want:  []string{"db", "db-password", "api-key"},
+		},
+		{
+			name:  "fuzzy match",
+			input: "dbpwd",
+			want:  []string{"db-password"},
+		},
+		{
+			name:  "match display name",
+			input: "stripe",
+			want:  []string{"api-key"},
+		},
+		{
+			name:  "filter on type and label",
+			input: "type:credential label:prod",
+			want:  []string{"db-password"},
+		},
+		{
+			name:  "filter on tag",
+			input: "tag:env=API_KEY",
+			want:  []string{"api-key"},
+		},
+		{
+			name:  "filter on updated age",
+			input: "updated<30d",
+			want:  []string{"api-key", "db-password", "token"},
+		},
+		{
+			name:  "filter on updated date",
+			input: "updated<2023-07-01",
+			want:  []string{"db"},
+		},
+		{
+			name:  "filter on updated age of secret never updated",
+			input: "updated<3d",
+			want:  []string{"token"},
+		},
+		{
+			name:  "filter on old updated age excludes new secret",
+			input: "updated>30d",
+			want:  []string{"db"},
+		},
+		{
+			name:  "terms and filters",
+			input: "db updated>30d",
+			want:  []string{"db"},
+		},
+		{
+			name:  "no match",
+			input: "xyz",
+			want:  []string{},
+		},
+		{
+			name:    "invalid type",
+			input:   "type:unknown",
+			want:    []string{},
+			wantErr: ErrInvalidQuery,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			now = func() time.Time {
+				return time.Date(2023, time.August, 1, 0, 0, 0, 0, time.Local)
+			}
+			c := Collection{
+				secrets: []Secret{
+					{
+						Name:        "api-key",
+						Labels:      []string{"dev", "db"},
+						Tags:        map[string]string{"env": "API_KEY"},
+						Updated:     time.Date(2023, time.July, 20, 0, 0, 0, 0, time.Local),
+						DisplayName: "Stripe API key",
+					},
+					{
+						Name:    "db-password",
+						Type:    TypeCredential,
+						Labels:  []string{"prod"},
+						Updated: time.Date(2023, time.July, 25, 0, 0, 0, 0, time.Local),
+					},
+					{
+						Name:    "db",
+						Type:    TypeNote,
+						Labels:  []string{"prod"},
+						Updated: time.Date(2023, time.June, 1, 0, 0, 0, 0, time.Local),
+					},
+					{
+						Name:    "token",
+						Created: time.Date(2023, time.July, 30, 0, 0, 0, 0, time.Local),
+					},
+				},
+			}
+
+			var got []string
+			query, gotErr := ParseQuery(test.input)
+			if gotErr == nil {
+				for _, s := range c.Search(query) {
+					got = append(got, s.Name)
+				}
+			}
+
+			if diff := cmp.Diff(test.want, got, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("Search() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Search() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
@@ -25,6 +25,10 @@ var (
 	ErrSecretEncrypt = errors.New("encrypting secret")
 	// ErrSecretDecrypt is returned when an error is encountered when decrypting a secret.
 	ErrSecretDecrypt = errors.New("decrypting secret")
+	// ErrNotStructured is returned when the value of a secret does not contain fields.
+	ErrNotStructured = errors.New("secret value does not contain fields")
+	// ErrFieldNotFound is returned when a field cannot be found in the value of a secret.
+	ErrFieldNotFound = errors.New("field not found")
 )
 
 const (
@@ -43,6 +47,8 @@ const (
 	TypeNote
 	// TypeFile represents a secret file.
 	TypeFile
+	// TypeSSHKey represents an SSH private key.
+	TypeSSHKey
 )
 
 // String returns the string representation of a secret type.
@@ -56,10 +62,22 @@ func (t Type) String() string {
 		return "note"
 	case TypeFile:
 		return "file"
+	case TypeSSHKey:
+		return "ssh-key"
 	}
 	return ""
 }
 
+// ParseType parses the string representation of a secret type.
+func ParseType(s string) (Type, error) {
+	for _, t := range []Type{TypeGeneric, TypeCredential, TypeNote, TypeFile, TypeSSHKey} {
+		if t.String() == s {
+			return t, nil
+		}
+	}
+	return TypeGeneric, fmt.Errorf("invalid secret type: %s", s)
+}
+
 // MarshalJSON marshals the Type to its string representation
 // for JSON.
 func (t Type) MarshalJSON() ([]byte, error) {
@@ -92,6 +110,10 @@ type Secret struct {
 	// Key for encrypting the secret. The key is not persisted
 	// or transmitted.
 	key []byte `json:"-"`
+	// cipher for encrypting the secret. If set it takes
+	// precedence over key. The cipher is not persisted
+	// or transmitted.
+	cipher Cipher `json:"-"`
 }
 
 // SecretOptions contains options for a secret.
@@ -103,6 +125,13 @@ type SecretOptions struct {
 	Tags        map[string]string
 	Updated     time.Time
 	key         []byte
+	cipher      Cipher
+	// typeSet, displayNameSet, labelsSet and tagsSet are true if
+	// the field is set with its option, so that it can be cleared.
+	typeSet        bool
+	displayNameSet bool
+	labelsSet      bool
+	tagsSet        bool
 }
 
 // SecretOption is a function to set SecretOptions.
@@ -110,17 +139,19 @@ type SecretOption func(options *SecretOptions)
 
 // NewSecret creates a new secret.
 func NewSecret(name, value string, key []byte, options ...SecretOption) (Secret, error) {
-	if len(key) != KeyLength {
-		return Secret{}, ErrInvalidKeyLength
-	}
 	opts := SecretOptions{}
 	for _, option := range options {
 		option(&opts)
 	}
+	// A cipher provided by options replaces the key.
+	if opts.cipher == nil && len(key) != KeyLength {
+		return Secret{}, ErrInvalidKeyLength
+	}
 
+	s := Secret{key: key, cipher: opts.cipher}
 	// Always make use of the provided value and key when creating
 	// a secret and ignore one provided by options.
-	encrypted, err := security.Encrypt([]byte(value), key)
+	encrypted, err := s.encrypt([]byte(value))
 	if err != nil {
 		return Secret{}, fmt.Errorf("%w: %w", ErrSecretEncrypt, err)
 	}
@@ -135,6 +166,7 @@ func NewSecret(name, value string, key []byte, options ...SecretOption) (Secret,
 		Tags:        opts.Tags,
 		Created:     now(),
 		key:         key,
+		cipher:      opts.cipher,
 	}, nil
 }
 
@@ -151,15 +183,45 @@ func (s *Secret) Decrypt(options ...SecretOption) ([]byte, error) {
 	}
 	if opts.key != nil && len(opts.key) == KeyLength {
 		s.key = opts.key
+		s.cipher = nil
 	}
 
-	decrypted, err := security.Decrypt(s.Value, s.key)
+	decrypted, err := s.decrypt(s.Value)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", ErrSecretDecrypt, err)
 	}
 	return decrypted, nil
 }
 
+// Fields decrypts the value of the secret and returns its fields.
+// The value of a structured secret (like a credential) is a JSON
+// object with string values.
+func (s *Secret) Fields(options ...SecretOption) (map[string]string, error) {
+	decrypted, err := s.Decrypt(options...)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]string)
+	if err := json.Unmarshal(decrypted, &fields); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotStructured, s.Name)
+	}
+	return fields, nil
+}
+
+// Field decrypts the value of the secret and returns the
+// value of the provided field.
+func (s *Secret) Field(name string, options ...SecretOption) (string, error) {
+	fields, err := s.Fields(options...)
+	if err != nil {
+		return "", err
+	}
+	value, ok := fields[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s in %s", ErrFieldNotFound, name, s.Name)
+	}
+	return value, nil
+}
+
 // Set options to a secret.
 func (s *Secret) Set(options ...SecretOption) error {
 	opts := SecretOptions{}
@@ -169,50 +231,69 @@ func (s *Secret) Set(options ...SecretOption) error {
 
 	// Check if a key is provided in options, and set it
 	// to key.
-	var key, previousKey []byte
+	var c, previous Cipher
 	if len(opts.key) > 0 {
-		previousKey = s.key
-		key = opts.key
+		previous = s.currentCipher()
+		c = keyCipher(opts.key)
 	} else {
-		key = s.key
+		c = s.currentCipher()
 	}
 
 	if len(opts.Value) > 0 {
-		encrypted, err := security.Encrypt(opts.Value, key)
+		encrypted, err := c.Encrypt(opts.Value)
 		if err != nil {
 			return fmt.Errorf("%w: %w", ErrSecretEncrypt, err)
 		}
 		s.Value = encrypted
 	}
 
-	if previousKey != nil {
-		decrypted, err := security.Decrypt(s.Value, previousKey)
+	if previous != nil {
+		decrypted, err := previous.Decrypt(s.Value)
 		if err != nil {
 			return fmt.Errorf("%w: %w", ErrSecretDecrypt, err)
 		}
-		s.key = key
-		encrypted, err := security.Encrypt(decrypted, s.key)
+		s.key, s.cipher = opts.key, nil
+		encrypted, err := s.encrypt(decrypted)
 		if err != nil {
 			return fmt.Errorf("%w: %w", ErrSecretEncrypt, err)
 		}
 		s.Value = encrypted
 	}
 
-	if len(opts.DisplayName) > 0 {
+	if opts.displayNameSet || len(opts.DisplayName) > 0 {
 		s.DisplayName = opts.DisplayName
 	}
-	if opts.Type != s.Type {
+	if (opts.typeSet || opts.Type != TypeGeneric) && opts.Type != s.Type {
 		s.Type = opts.Type
 	}
-	if len(opts.Labels) > 0 {
+	if opts.labelsSet || len(opts.Labels) > 0 {
 		s.Labels = opts.Labels
 	}
-	if len(opts.Tags) > 0 {
+	if opts.tagsSet || len(opts.Tags) > 0 {
 		s.Tags = opts.Tags
 	}
 	return nil
 }
 
+// currentCipher returns the cipher of the secret. If no cipher
+// is set, one is created from the key.
+func (s Secret) currentCipher() Cipher {
+	if s.cipher != nil {
+		return s.cipher
+	}
+	return keyCipher(s.key)
+}
+
+// encrypt data with the cipher of the secret.
+func (s Secret) encrypt(b []byte) ([]byte, error) {
+	return s.currentCipher().Encrypt(b)
+}
+
+// decrypt data with the cipher of the secret.
+func (s Secret) decrypt(b []byte) ([]byte, error) {
+	return s.currentCipher().Decrypt(b)
+}
+
 // JSON returns the JSON encoding of Secret.
 func (s Secret) JSON() []byte {
 	b, _ := json.MarshalIndent(s, "", "  ")
@@ -235,6 +316,38 @@ func WithValue(value []byte) SecretOption {
 	}
 }
 
+// WithDisplayName sets display name to SecretOptions.
+func WithDisplayName(displayName string) SecretOption {
+	return func(o *SecretOptions) {
+		o.DisplayName = displayName
+		o.displayNameSet = true
+	}
+}
+
+// WithType sets type to SecretOptions.
+func WithType(t Type) SecretOption {
+	return func(o *SecretOptions) {
+		o.Type = t
+		o.typeSet = true
+	}
+}
+
+// WithLabels sets labels to SecretOptions.
+func WithLabels(labels ...string) SecretOption {
+	return func(o *SecretOptions) {
+		o.Labels = labels
+		o.labelsSet = true
+	}
+}
+
+// WithTags sets tags to SecretOptions.
+func WithTags(tags map[string]string) SecretOption {
+	return func(o *SecretOptions) {
+		o.Tags = tags
+		o.tagsSet = true
+	}
+}
+
 // WithKey sets key to SecretOptions.
 func WithKey(key []byte) SecretOption {
 	return func(o *SecretOptions) {
@@ -242,6 +355,36 @@ func WithKey(key []byte) SecretOption {
 	}
 }
 
+// WithCipher sets cipher to SecretOptions. The cipher is used
+// instead of a key to encrypt and decrypt the secret.
+func WithCipher(c Cipher) SecretOption {
+	return func(o *SecretOptions) {
+		o.cipher = c
+	}
+}
+
+// Cipher is the interface that wraps around methods Encrypt and Decrypt.
+// It makes it possible to have the encryption and decryption of secrets
+// and collections performed elsewhere, like by an agent holding the keys.
+type Cipher interface {
+	Encrypt(b []byte) ([]byte, error)
+	Decrypt(b []byte) ([]byte, error)
+}
+
+// keyCipher satisfies Cipher with AES-256-GCM and the
+// key it holds.
+type keyCipher []byte
+
+// Encrypt data with the key.
+func (k keyCipher) Encrypt(b []byte) ([]byte, error) {
+	return security.Encrypt(b, k)
+}
+
+// Decrypt data with the key.
+func (k keyCipher) Decrypt(b []byte) ([]byte, error) {
+	return security.Decrypt(b, k)
+}
+
 // now returns the current time.
 var now = func() time.Time {
 	return time.Now()
@@ -41,6 +41,40 @@ func TestNewSecret(t *testing.T) {
 			},
 			wantValue: _testValue,
 		},
+		{
+			name: "New Secret - with cipher",
+			input: struct {
+				name    string
+				value   string
+				key     []byte
+				options []SecretOption
+			}{
+				name:    "secret",
+				value:   _testValue,
+				options: []SecretOption{WithCipher(keyCipher(_testKey.Value))},
+			},
+			want: Secret{
+				ID:      "aaaa",
+				Name:    "secret",
+				Type:    TypeGeneric,
+				Created: _testCreated,
+			},
+			wantValue: _testValue,
+		},
+		{
+			name: "New Secret - invalid key length",
+			input: struct {
+				name    string
+				value   string
+				key     []byte
+				options []SecretOption
+			}{
+				name:  "secret",
+				value: _testValue,
+				key:   []byte(`key`),
+			},
+			wantErr: ErrInvalidKeyLength,
+		},
 	}
 
 	for _, test := range tests {
@@ -64,13 +98,94 @@ func TestNewSecret(t *testing.T) {
 				t.Errorf("NewSecret() = unexpected value, want: %s, got: %s\n", test.wantValue, string(gotValue))
 			}
 
-			if diff := cmp.Diff(test.wantErr, gotErr); diff != "" {
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
 				t.Errorf("NewSecret() = unexpected error (-want +got)\n%s\n", diff)
 			}
 		})
 	}
 }
 
+func TestSecret_Set(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			secret  Secret
+			options []SecretOption
+		}
+		want Secret
+	}{
+		{
+			name: "set metadata",
+			input: struct {
+				secret  Secret
+				options []SecretOption
+			}{
+				secret: Secret{ID: "aaaa", Name: "secret", Type: TypeCredential},
+				options: []SecretOption{
+					WithDisplayName("Secret"),
+					WithLabels("prod"),
+					WithTags(map[string]string{"env": "SECRET"}),
+				},
+			},
+			want: Secret{
+				ID:          "aaaa",
+				Name:        "secret",
+				DisplayName: "Secret",
+				Type:        TypeCredential,
+				Labels:      []string{"prod"},
+				Tags:        map[string]string{"env": "SECRET"},
+			},
+		},
+		{
+			name: "set type",
+			input: struct {
+				secret  Secret
+				options []SecretOption
+			}{
+				secret:  Secret{ID: "aaaa", Name: "secret", Type: TypeCredential},
+				options: []SecretOption{WithType(TypeGeneric)},
+			},
+			want: Secret{ID: "aaaa", Name: "secret", Type: TypeGeneric},
+		},
+		{
+			name: "clear metadata",
+			input: struct {
+				secret  Secret
+				options []SecretOption
+			}{
+				secret: Secret{
+					ID:          "aaaa",
+					Name:        "secret",
+					DisplayName: "Secret",
+					Labels:      []string{"prod"},
+					Tags:        map[string]string{"env": "SECRET"},
+				},
+				options: []SecretOption{
+					WithDisplayName(""),
+					WithLabels(),
+					WithTags(nil),
+				},
+			},
+			want: Secret{ID: "aaaa", Name: "secret"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.input.secret
+			gotErr := got.Set(test.input.options...)
+
+			if diff := cmp.Diff(test.want, got, cmpopts.IgnoreUnexported(Secret{})); diff != "" {
+				t.Errorf("Set() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if gotErr != nil {
+				t.Errorf("Set() = unexpected error: %v\n", gotErr)
+			}
+		})
+	}
+}
+
 var (
 	_testValue   = "value"
 	_testKey, _  = security.NewKeyFromPassword([]byte("test"))
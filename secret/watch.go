@@ -0,0 +1,186 @@
+package secret
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	stg "github.com/KarlGW/secman/storage"
+)
+
+const (
+	// defaultWatchInterval is the default interval between checks
+	// of the storage when watching.
+	defaultWatchInterval = time.Second
+)
+
+var (
+	// ErrInvalidInterval is returned when the interval of a watch
+	// is not positive.
+	ErrInvalidInterval = errors.New("interval must be greater than 0")
+)
+
+// EventType is the type of a change of a secret.
+type EventType int
+
+const (
+	// EventAdded is the type of an event for an added secret.
+	EventAdded EventType = iota
+	// EventUpdated is the type of an event for an updated secret.
+	EventUpdated
+	// EventDeleted is the type of an event for a deleted secret.
+	EventDeleted
+)
+
+// String returns the string representation of an event type.
+func (t EventType) String() string {
+	switch t {
+	case EventAdded:
+		return "added"
+	case EventUpdated:
+		return "updated"
+	case EventDeleted:
+		return "deleted"
+	}
+	return ""
+}
+
+// MarshalText returns the string representation of an event type
+// as text.
+func (t EventType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// Event is a change of a secret. It never contains the value
+// of the secret.
+type Event struct {
+	Type EventType `json:"type"`
+	ID   string    `json:"id"`
+	Name string    `json:"name"`
+	Time time.Time `json:"time"`
+}
+
+// WatchOptions contains options for watching.
+type WatchOptions struct {
+	Interval time.Duration
+}
+
+// WatchOption is a function that sets WatchOptions.
+type WatchOption func(o *WatchOptions)
+
+// Watch the storage of the handler for changes of secrets made by
+// other handlers and processes. The storage is polled with the interval
+// set with WithInterval (defaults to 1 second), and when it has been
+// updated the secrets are compared with the previous state. Failed
+// loads, like of a partly written file, are retried on the next poll.
+// The returned channel is closed when the context is done.
+//
+// The collection of the handler is not changed, Load must be called
+// to get the changes.
+func (h Handler) Watch(ctx context.Context, options ...WatchOption) (<-chan Event, error) {
+	opts := WatchOptions{
+		Interval: defaultWatchInterval,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+	if opts.Interval <= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidInterval, opts.Interval)
+	}
+
+	storage, cipher := h.storage, h.currentStorageCipher()
+	updated, err := storage.Updated()
+	if err != nil {
+		return nil, err
+	}
+	previous, err := loadSecrets(storage, cipher)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		ticker := time.NewTicker(opts.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			u, err := storage.Updated()
+			if err != nil || !u.After(updated) {
+				continue
+			}
+			current, err := loadSecrets(storage, cipher)
+			if err != nil {
+				continue
+			}
+			updated = u
+
+			for _, event := range Changes(previous, current) {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+			previous = current
+		}
+	}()
+	return events, nil
+}
+
+// Changes returns the events for the changes between the secrets
+// before and after. Secrets are matched by ID, and a secret is
+// updated if the time it was updated differs.
+func Changes(before, after []Secret) []Event {
+	now := time.Now()
+	previous := make(map[string]Secret, len(before))
+	for _, secret := range before {
+		previous[secret.ID] = secret
+	}
+
+	var events []Event
+	for _, secret := range after {
+		prev, ok := previous[secret.ID]
+		if !ok {
+			events = append(events, Event{Type: EventAdded, ID: secret.ID, Name: secret.Name, Time: now})
+		} else if !prev.Updated.Equal(secret.Updated) {
+			events = append(events, Event{Type: EventUpdated, ID: secret.ID, Name: secret.Name, Time: now})
+		}
+		delete(previous, secret.ID)
+	}
+	// Keep the order of the deleted secrets as they were.
+	for _, secret := range before {
+		if _, ok := previous[secret.ID]; ok {
+			events = append(events, Event{Type: EventDeleted, ID: secret.ID, Name: secret.Name, Time: now})
+		}
+	}
+	return events
+}
+
+// loadSecrets loads the secrets from the storage. If the storage
+// source cannot be found, no secrets are returned.
+func loadSecrets(storage Storage, c Cipher) ([]Secret, error) {
+	collection, err := loadDecryptDecode(storage, c)
+	if err != nil {
+		if errors.Is(err, stg.ErrStorageSourceNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return collection.secrets, nil
+}
+
+// WithInterval sets the interval between checks of the storage
+// when watching.
+func WithInterval(d time.Duration) WatchOption {
+	return func(o *WatchOptions) {
+		o.Interval = d
+	}
+}
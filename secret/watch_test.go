@@ -0,0 +1,148 @@
+package secret
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stg "github.com/KarlGW/secman/storage"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestChanges(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			before []Secret
+			after  []Secret
+		}
+		want []Event
+	}{
+		{
+			name: "no changes",
+			input: struct {
+				before []Secret
+				after  []Secret
+			}{
+				before: []Secret{{ID: "1", Name: "secret", Updated: _testTime1}},
+				after:  []Secret{{ID: "1", Name: "secret", Updated: _testTime1}},
+			},
+		},
+		{
+			name: "added, updated and deleted",
+			input: struct {
+				before []Secret
+				after  []Secret
+			}{
+				before: []Secret{
+					{ID: "1", Name: "secret", Updated: _testTime1},
+					{ID: "2", Name: "deleted", Updated: _testTime1},
+				},
+				after: []Secret{
+					{ID: "1", Name: "renamed", Updated: _testTime2},
+					{ID: "3", Name: "added", Updated: _testTime2},
+				},
+			},
+			want: []Event{
+				{Type: EventUpdated, ID: "1", Name: "renamed"},
+				{Type: EventAdded, ID: "3", Name: "added"},
+				{Type: EventDeleted, ID: "2", Name: "deleted"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Changes(test.input.before, test.input.after)
+
+			if diff := cmp.Diff(test.want, got, cmpopts.IgnoreFields(Event{}, "Time")); diff != "" {
+				t.Errorf("Changes() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestHandler_Watch(t *testing.T) {
+	storage := stg.NewMemory(nil)
+	handler, err := NewHandler("profile", _testKey, _testKey, storage)
+	if err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+	if _, err := handler.AddSecret("secret", "value"); err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events, err := handler.Watch(ctx, WithInterval(time.Millisecond))
+	if err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+
+	// Changes are made by another handler with the same storage.
+	other, err := NewHandler("profile", _testKey, _testKey, storage, WithLoadCollection())
+	if err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+	time.Sleep(time.Millisecond)
+	if _, err := other.UpdateSecretByName("secret", WithValue([]byte("rotated"))); err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+
+	var got Event
+	select {
+	case got = <-events:
+	case <-ctx.Done():
+		t.Fatalf("unexpected error in test: %v\n", ctx.Err())
+	}
+	cancel()
+
+	want := Event{Type: EventUpdated, Name: "secret"}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(Event{}, "ID", "Time")); diff != "" {
+		t.Errorf("Watch() = unexpected result (-want +got)\n%s\n", diff)
+	}
+	if _, ok := <-events; ok {
+		t.Errorf("Watch() = channel not closed after context is done\n")
+	}
+}
+
+func TestHandler_Watch_Interval(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   time.Duration
+		wantErr error
+	}{
+		{
+			name:  "positive interval",
+			input: time.Millisecond,
+		},
+		{
+			name:    "zero interval",
+			input:   0,
+			wantErr: ErrInvalidInterval,
+		},
+		{
+			name:    "negative interval",
+			input:   -time.Second,
+			wantErr: ErrInvalidInterval,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler, err := NewHandler("profile", _testKey, _testKey, stg.NewMemory(nil))
+			if err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			_, gotErr := handler.Watch(ctx, WithInterval(test.input))
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Watch() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/KarlGW/secman/secret"
+)
+
+var (
+	// errNotFound is returned for unknown paths.
+	errNotFound = errors.New("not found")
+	// errMethodNotAllowed is returned for unsupported methods.
+	errMethodNotAllowed = errors.New("method not allowed")
+	// errBadRequest is returned for invalid requests.
+	errBadRequest = errors.New("bad request")
+)
+
+// secretsPath is the path of the secrets resource.
+const secretsPath = "/v1/secrets"
+
+// secretRequest is the body of requests for creating and updating
+// secrets. Fields that are not set are not updated.
+type secretRequest struct {
+	Name        string            `json:"name"`
+	Value       *string           `json:"value"`
+	DisplayName *string           `json:"displayName"`
+	Type        *string           `json:"type"`
+	Labels      []string          `json:"labels"`
+	Tags        map[string]string `json:"tags"`
+}
+
+// secretResponse is a secret with its value, if requested.
+type secretResponse struct {
+	secret.Secret
+	Value string `json:"value,omitempty"`
+}
+
+// errorResponse is the body of error responses.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// route routes the request to the handler of the resource:
+//
+//	GET    /v1/secrets[?query=<query>]  list or search secrets
+//	POST   /v1/secrets                  create a secret
+//	GET    /v1/secrets/<name>           get a secret with its value
+//	PATCH  /v1/secrets/<name>           update a secret
+//	DELETE /v1/secrets/<name>           delete a secret
+func (s *Server) route(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.EscapedPath()
+	if path == secretsPath {
+		switch r.Method {
+		case http.MethodGet:
+			s.listSecrets(w, r)
+		case http.MethodPost:
+			s.createSecret(w, r)
+		default:
+			writeError(w, errMethodNotAllowed)
+		}
+		return
+	}
+
+	escaped, ok := strings.CutPrefix(path, secretsPath+"/")
+	if !ok || len(escaped) == 0 || strings.Contains(escaped, "/") {
+		writeError(w, errNotFound)
+		return
+	}
+	name, err := url.PathUnescape(escaped)
+	if err != nil {
+		writeError(w, fmt.Errorf("%w: %w", errBadRequest, err))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.getSecret(w, name)
+	case http.MethodPatch:
+		s.updateSecret(w, r, name)
+	case http.MethodDelete:
+		s.deleteSecret(w, name)
+	default:
+		writeError(w, errMethodNotAllowed)
+	}
+}
+
+// listSecrets lists the secrets, or searches them if a query is provided.
+func (s *Server) listSecrets(w http.ResponseWriter, r *http.Request) {
+	var secrets secret.Secrets
+	var err error
+	if query := r.URL.Query().Get("query"); len(query) > 0 {
+		secrets, err = s.handler.SearchSecrets(query)
+	} else {
+		secrets, err = s.handler.ListSecrets()
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if secrets == nil {
+		secrets = secret.Secrets{}
+	}
+	writeJSON(w, http.StatusOK, secrets)
+}
+
+// getSecret gets a secret with its decrypted value.
+func (s *Server) getSecret(w http.ResponseWriter, name string) {
+	sec, err := s.handler.GetSecretByName(name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	decrypted, err := sec.Decrypt()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, secretResponse{Secret: sec, Value: string(decrypted)})
+}
+
+// createSecret creates a secret.
+func (s *Server) createSecret(w http.ResponseWriter, r *http.Request) {
+	req, options, err := decodeSecretRequest(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if len(req.Name) == 0 || req.Value == nil || len(*req.Value) == 0 {
+		writeError(w, fmt.Errorf("%w: a name and a value must be provided", errBadRequest))
+		return
+	}
+
+	sec, err := s.handler.AddSecret(req.Name, *req.Value, options...)
+	if err := s.warnPostHook(w, err); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, sec)
+}
+
+// updateSecret updates a secret.
+func (s *Server) updateSecret(w http.ResponseWriter, r *http.Request, name string) {
+	req, options, err := decodeSecretRequest(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if req.Value != nil && len(*req.Value) > 0 {
+		options = append(options, secret.WithValue([]byte(*req.Value)))
+	}
+
+	sec, err := s.handler.GetSecretByName(name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	sec, err = s.handler.UpdateSecretByID(sec.ID, options...)
+	if err := s.warnPostHook(w, err); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sec)
+}
+
+// deleteSecret deletes a secret.
+func (s *Server) deleteSecret(w http.ResponseWriter, name string) {
+	sec, err := s.handler.GetSecretByName(name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.warnPostHook(w, s.handler.DeleteSecretByID(sec.ID)); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// warnPostHook reports a failed post-hook as a warning header on the
+// response, since the change has been saved. Other errors are returned.
+func (s *Server) warnPostHook(w http.ResponseWriter, err error) error {
+	if !errors.Is(err, secret.ErrPostHook) {
+		return err
+	}
+	s.logger.Warn("post-hook failed", "error", err)
+	w.Header().Set("Warning", "199 secman "+strconv.Quote(err.Error()))
+	return nil
+}
+
+// decodeSecretRequest decodes the body of the request and returns
+// the options for the metadata of the secret.
+func decodeSecretRequest(r *http.Request) (secretRequest, []secret.SecretOption, error) {
+	var req secretRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		return secretRequest{}, nil, fmt.Errorf("%w: %w", errBadRequest, err)
+	}
+
+	var options []secret.SecretOption
+	if req.DisplayName != nil {
+		options = append(options, secret.WithDisplayName(*req.DisplayName))
+	}
+	if req.Type != nil {
+		t, err := secret.ParseType(*req.Type)
+		if err != nil {
+			return secretRequest{}, nil, fmt.Errorf("%w: %w", errBadRequest, err)
+		}
+		options = append(options, secret.WithType(t))
+	}
+	if req.Labels != nil {
+		options = append(options, secret.WithLabels(req.Labels...))
+	}
+	if req.Tags != nil {
+		options = append(options, secret.WithTags(req.Tags))
+	}
+	return req, options, nil
+}
+
+// writeJSON writes the value as JSON with the status code.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes the error with the status code of the error.
+func writeError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrUnauthorized):
+		status = http.StatusUnauthorized
+	case errors.Is(err, ErrReadOnly):
+		status = http.StatusForbidden
+	case errors.Is(err, errNotFound), errors.Is(err, secret.ErrSecretNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, errMethodNotAllowed):
+		status = http.StatusMethodNotAllowed
+	case errors.Is(err, secret.ErrSecretAlreadyExists):
+		status = http.StatusConflict
+	case errors.As(err, &maxBytesErr):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, errBadRequest), errors.Is(err, secret.ErrInvalidQuery):
+		status = http.StatusBadRequest
+	}
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,270 @@
+// Package server contains a REST/JSON API server for the secrets of
+// a profile, served over a Unix socket with token authentication.
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KarlGW/secman/secret"
+)
+
+var (
+	// ErrUnauthorized is returned when a request has no valid token.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrReadOnly is returned when a write is requested from a read-only
+	// server or with a read-only token.
+	ErrReadOnly = errors.New("read-only access")
+)
+
+const (
+	// tokenPrefix is the prefix of tokens.
+	tokenPrefix = "secman_"
+	// tokenLength is the number of random bytes of tokens.
+	tokenLength = 32
+	// maxBodySize is the maximum size of request bodies.
+	maxBodySize = 1 << 20
+)
+
+// Handler is the interface that wraps around the methods of
+// secret.Handler used by the server.
+type Handler interface {
+	ListSecrets() (secret.Secrets, error)
+	SearchSecrets(query string) (secret.Secrets, error)
+	GetSecretByName(name string) (secret.Secret, error)
+	AddSecret(name, value string, options ...secret.SecretOption) (secret.Secret, error)
+	UpdateSecretByID(id string, options ...secret.SecretOption) (secret.Secret, error)
+	DeleteSecretByID(id string) error
+	Reload(loaded time.Time) (time.Time, error)
+	Updated() (time.Time, error)
+}
+
+// Token is the token of a client. Only the hash of the token is kept.
+type Token struct {
+	Name     string
+	Hash     string
+	ReadOnly bool
+}
+
+// NewToken generates a new token.
+func NewToken() (string, error) {
+	b := make([]byte, tokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return tokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the hash of the token.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Server serves the API for the secrets of a handler. All requests
+// are served by the single handler, one at a time. The secrets are
+// reloaded before a request if they have been changed by another
+// process, like the CLI.
+type Server struct {
+	handler  Handler
+	tokens   []Token
+	readOnly bool
+	logger   *slog.Logger
+	// loaded is the time the storage was updated when the secrets
+	// were last loaded.
+	loaded time.Time
+	mu     sync.Mutex
+}
+
+// Options contains options for a Server.
+type Options struct {
+	ReadOnly bool
+	Logger   *slog.Logger
+}
+
+// Option is a function that sets Options.
+type Option func(o *Options)
+
+// New creates and returns a new Server. Only requests with one of the
+// tokens are served.
+func New(handler Handler, tokens []Token, options ...Option) *Server {
+	opts := Options{
+		Logger: slog.New(slog.NewTextHandler(os.Stderr, nil)),
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	return &Server{
+		handler:  handler,
+		tokens:   tokens,
+		readOnly: opts.ReadOnly,
+		logger:   opts.Logger,
+	}
+}
+
+// Listen creates the socket at the provided path, with access only
+// for the current user.
+func Listen(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// Serve the API on the listener until the context is cancelled.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	srv := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(listener)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	}
+}
+
+// ServeHTTP authenticates, serves and logs a request. Values of
+// secrets are never logged.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+
+	token, err := s.authenticate(r)
+	if err != nil {
+		writeError(rw, err)
+	} else if r.Method != http.MethodGet && (s.readOnly || token.ReadOnly) {
+		writeError(rw, ErrReadOnly)
+	} else {
+		r.Body = http.MaxBytesReader(rw, r.Body, maxBodySize)
+		s.serve(rw, r)
+	}
+
+	s.logger.Info("request",
+		"method", r.Method,
+		"path", r.URL.EscapedPath(),
+		"client", token.Name,
+		"status", rw.status,
+		"duration", time.Since(start),
+	)
+}
+
+// serve reloads the secrets if they have been changed by another
+// process, and routes the request.
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	loaded, err := s.handler.Reload(s.loaded)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.loaded = loaded
+	s.route(w, r)
+	if r.Method != http.MethodGet {
+		// Changes saved by the server should not cause a reload.
+		if updated, err := s.handler.Updated(); err == nil {
+			s.loaded = updated
+		}
+	}
+}
+
+// authenticate returns the token of the bearer token of the request.
+func (s *Server) authenticate(r *http.Request) (Token, error) {
+	bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || len(bearer) == 0 {
+		return Token{}, ErrUnauthorized
+	}
+	hash := HashToken(bearer)
+	for _, token := range s.tokens {
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(token.Hash)) == 1 {
+			return token, nil
+		}
+	}
+	return Token{}, ErrUnauthorized
+}
+
+// DefaultSocketPath returns the default path of the socket.
+func DefaultSocketPath() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if len(dir) == 0 {
+		dir = filepath.Join(os.TempDir(), "secman-"+strconv.Itoa(os.Getuid()))
+	} else {
+		dir = filepath.Join(dir, "secman")
+	}
+	return filepath.Join(dir, "api.sock")
+}
+
+// WithReadOnly makes the server serve only read requests.
+func WithReadOnly() Option {
+	return func(o *Options) {
+		o.ReadOnly = true
+	}
+}
+
+// WithLogger sets the logger for requests.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+// removeStaleSocket removes the socket at path if no server
+// is listening on it.
+func removeStaleSocket(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return errors.New("a server is already listening on " + path)
+	}
+	return os.Remove(path)
+}
+
+// statusWriter records the status code of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status code and writes it.
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,537 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KarlGW/secman/internal/security"
+	"github.com/KarlGW/secman/secret"
+	"github.com/KarlGW/secman/storage"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestServer_ServeHTTP(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			method   string
+			path     string
+			token    string
+			body     string
+			readOnly bool
+		}
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "list secrets",
+			input: struct {
+				method   string
+				path     string
+				token    string
+				body     string
+				readOnly bool
+			}{
+				method: http.MethodGet,
+				path:   "/v1/secrets",
+				token:  "token",
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"name":"secret"`,
+		},
+		{
+			name: "get secret",
+			input: struct {
+				method   string
+				path     string
+				token    string
+				body     string
+				readOnly bool
+			}{
+				method: http.MethodGet,
+				path:   "/v1/secrets/secret",
+				token:  "read-only-token",
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"value":"value"`,
+		},
+		{
+			name: "get secret - not found",
+			input: struct {
+				method   string
+				path     string
+				token    string
+				body     string
+				readOnly bool
+			}{
+				method: http.MethodGet,
+				path:   "/v1/secrets/missing",
+				token:  "token",
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody:   `"error":"a secret with that identifier cannot be found"`,
+		},
+		{
+			name: "create secret",
+			input: struct {
+				method   string
+				path     string
+				token    string
+				body     string
+				readOnly bool
+			}{
+				method: http.MethodPost,
+				path:   "/v1/secrets",
+				token:  "token",
+				body:   `{"name":"new","value":"new-value","labels":["label"]}`,
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `"name":"new"`,
+		},
+		{
+			name: "create secret - already exists",
+			input: struct {
+				method   string
+				path     string
+				token    string
+				body     string
+				readOnly bool
+			}{
+				method: http.MethodPost,
+				path:   "/v1/secrets",
+				token:  "token",
+				body:   `{"name":"secret","value":"value"}`,
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name: "create secret - invalid body",
+			input: struct {
+				method   string
+				path     string
+				token    string
+				body     string
+				readOnly bool
+			}{
+				method: http.MethodPost,
+				path:   "/v1/secrets",
+				token:  "token",
+				body:   `{"name":"new","unknown":true}`,
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "update secret",
+			input: struct {
+				method   string
+				path     string
+				token    string
+				body     string
+				readOnly bool
+			}{
+				method: http.MethodPatch,
+				path:   "/v1/secrets/secret",
+				token:  "token",
+				body:   `{"displayName":"Secret"}`,
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"displayName":"Secret"`,
+		},
+		{
+			name: "delete secret",
+			input: struct {
+				method   string
+				path     string
+				token    string
+				body     string
+				readOnly bool
+			}{
+				method: http.MethodDelete,
+				path:   "/v1/secrets/secret",
+				token:  "token",
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name: "unauthorized",
+			input: struct {
+				method   string
+				path     string
+				token    string
+				body     string
+				readOnly bool
+			}{
+				method: http.MethodGet,
+				path:   "/v1/secrets",
+				token:  "invalid",
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "write with read-only token",
+			input: struct {
+				method   string
+				path     string
+				token    string
+				body     string
+				readOnly bool
+			}{
+				method: http.MethodDelete,
+				path:   "/v1/secrets/secret",
+				token:  "read-only-token",
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "write to read-only server",
+			input: struct {
+				method   string
+				path     string
+				token    string
+				body     string
+				readOnly bool
+			}{
+				method:   http.MethodDelete,
+				path:     "/v1/secrets/secret",
+				token:    "token",
+				readOnly: true,
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "unknown path",
+			input: struct {
+				method   string
+				path     string
+				token    string
+				body     string
+				readOnly bool
+			}{
+				method: http.MethodGet,
+				path:   "/v1/unknown",
+				token:  "token",
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "method not allowed",
+			input: struct {
+				method   string
+				path     string
+				token    string
+				body     string
+				readOnly bool
+			}{
+				method: http.MethodPut,
+				path:   "/v1/secrets/secret",
+				token:  "token",
+			},
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			srv := setupServer(t, &logs, test.input.readOnly)
+
+			req := httptest.NewRequest(test.input.method, test.input.path, strings.NewReader(test.input.body))
+			req.Header.Set("Authorization", "Bearer "+test.input.token)
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+
+			if diff := cmp.Diff(test.wantStatus, rec.Code); diff != "" {
+				t.Errorf("ServeHTTP() = unexpected result (-want +got)\n%s\n", diff)
+			}
+			body, _ := io.ReadAll(rec.Body)
+			if !strings.Contains(string(body), test.wantBody) {
+				t.Errorf("ServeHTTP() = unexpected body, want it to contain %q, got %q\n", test.wantBody, body)
+			}
+			if strings.Contains(logs.String(), "value") {
+				t.Errorf("ServeHTTP() = values must not be logged, got %q\n", logs.String())
+			}
+		})
+	}
+}
+
+func TestServer_ServeHTTP_List(t *testing.T) {
+	srv := setupServer(t, io.Discard, false)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/secrets", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+
+	var got []map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+	for _, s := range got {
+		if _, ok := s["value"]; ok {
+			t.Errorf("ServeHTTP() = values must not be listed, got %v\n", s)
+		}
+	}
+}
+
+func TestServer_ServeHTTP_Update(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input string
+		want  map[string]any
+	}{
+		{
+			name:  "keep fields not in body",
+			input: `{"value":"new-value"}`,
+			want: map[string]any{
+				"displayName": "Secret",
+				"labels":      []any{"prod"},
+				"tags":        map[string]any{"env": "SECRET"},
+			},
+		},
+		{
+			name:  "clear fields in body",
+			input: `{"displayName":"","labels":[],"tags":{}}`,
+			want:  map[string]any{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := setupServer(t, io.Discard, false)
+
+			serve := func(method, body string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(method, "/v1/secrets/secret", strings.NewReader(body))
+				req.Header.Set("Authorization", "Bearer token")
+				rec := httptest.NewRecorder()
+				srv.ServeHTTP(rec, req)
+				return rec
+			}
+			if rec := serve(http.MethodPatch, `{"displayName":"Secret","labels":["prod"],"tags":{"env":"SECRET"}}`); rec.Code != http.StatusOK {
+				t.Fatalf("unexpected error in test: status %d\n", rec.Code)
+			}
+			rec := serve(http.MethodPatch, test.input)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("unexpected error in test: status %d\n", rec.Code)
+			}
+
+			var res map[string]any
+			if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+			got := make(map[string]any)
+			for _, field := range []string{"displayName", "labels", "tags"} {
+				if v, ok := res[field]; ok {
+					got[field] = v
+				}
+			}
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("ServeHTTP() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestServer_ServeHTTP_Reload(t *testing.T) {
+	key := security.Key{Value: bytes.Repeat([]byte{1}, secret.KeyLength)}
+	stg := storage.NewMemory(nil)
+	handler, err := secret.NewHandler("profile", key, key, stg)
+	if err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+	if _, err := handler.AddSecret("secret", "value"); err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+	srv := New(handler, []Token{{Name: "client", Hash: HashToken("token")}}, WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer token")
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve(http.MethodPost, "/v1/secrets", `{"name":"server","value":"value"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("unexpected status in test: %d\n", rec.Code)
+	}
+
+	// Change the storage underneath the server, like the CLI does.
+	time.Sleep(time.Millisecond)
+	other, err := secret.NewHandler("profile", key, key, stg, secret.WithLoadCollection())
+	if err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+	if _, err := other.UpdateSecretByName("secret", secret.WithValue([]byte("rotated"))); err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+	if _, err := other.AddSecret("cli", "value"); err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+
+	rec := serve(http.MethodGet, "/v1/secrets/secret", "")
+	var got map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+	if diff := cmp.Diff("rotated", got["value"]); diff != "" {
+		t.Errorf("ServeHTTP() = unexpected result (-want +got)\n%s\n", diff)
+	}
+
+	time.Sleep(time.Millisecond)
+	if rec := serve(http.MethodPost, "/v1/secrets", `{"name":"server-2","value":"value"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("unexpected status in test: %d\n", rec.Code)
+	}
+
+	// All changes must be saved, none overwritten by the server.
+	saved, err := secret.NewHandler("profile", key, key, stg, secret.WithLoadCollection())
+	if err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+	secrets, _ := saved.ListSecrets()
+	var names []string
+	for _, s := range secrets {
+		names = append(names, s.Name)
+	}
+	if diff := cmp.Diff([]string{"secret", "server", "cli", "server-2"}, names); diff != "" {
+		t.Errorf("ServeHTTP() = unexpected result (-want +got)\n%s\n", diff)
+	}
+}
+
+func TestServer_ServeHTTP_PostHook(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			method string
+			path   string
+			body   string
+		}
+		want     int
+		wantGone bool
+	}{
+		{
+			name: "create secret",
+			input: struct {
+				method string
+				path   string
+				body   string
+			}{
+				method: http.MethodPost,
+				path:   "/v1/secrets",
+				body:   `{"name":"new","value":"value"}`,
+			},
+			want: http.StatusCreated,
+		},
+		{
+			name: "update secret",
+			input: struct {
+				method string
+				path   string
+				body   string
+			}{
+				method: http.MethodPatch,
+				path:   "/v1/secrets/secret",
+				body:   `{"value":"updated"}`,
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "delete secret",
+			input: struct {
+				method string
+				path   string
+				body   string
+			}{
+				method: http.MethodDelete,
+				path:   "/v1/secrets/secret",
+			},
+			want:     http.StatusNoContent,
+			wantGone: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			key := security.Key{Value: bytes.Repeat([]byte{1}, secret.KeyLength)}
+			stg := storage.NewMemory(nil)
+			seed, err := secret.NewHandler("profile", key, key, stg)
+			if err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+			if _, err := seed.AddSecret("secret", "value"); err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+			handler, err := secret.NewHandler("profile", key, key, stg, secret.WithLoadCollection(), secret.WithHooks(failingHooks{}))
+			if err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+			srv := New(handler, []Token{{Name: "client", Hash: HashToken("token")}}, WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+
+			req := httptest.NewRequest(test.input.method, test.input.path, strings.NewReader(test.input.body))
+			req.Header.Set("Authorization", "Bearer token")
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+
+			if diff := cmp.Diff(test.want, rec.Code); diff != "" {
+				t.Errorf("ServeHTTP() = unexpected status (-want +got)\n%s\n", diff)
+			}
+			if len(rec.Header().Get("Warning")) == 0 {
+				t.Errorf("ServeHTTP() = expected a warning header\n")
+			}
+
+			name := "new"
+			if test.input.method != http.MethodPost {
+				name = "secret"
+			}
+			saved, err := secret.NewHandler("profile", key, key, stg, secret.WithLoadCollection())
+			if err != nil {
+				t.Fatalf("unexpected error in test: %v\n", err)
+			}
+			_, err = saved.GetSecretByName(name)
+			if test.wantGone != errors.Is(err, secret.ErrSecretNotFound) {
+				t.Errorf("ServeHTTP() = unexpected saved secret: %v\n", err)
+			}
+		})
+	}
+}
+
+func setupServer(t *testing.T, logs io.Writer, readOnly bool) *Server {
+	t.Helper()
+	key := security.Key{Value: bytes.Repeat([]byte{1}, secret.KeyLength)}
+	handler, err := secret.NewHandler("profile", key, key, storage.NewMemory(nil))
+	if err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+	if _, err := handler.AddSecret("secret", "value"); err != nil {
+		t.Fatalf("unexpected error in test: %v\n", err)
+	}
+
+	tokens := []Token{
+		{Name: "client", Hash: HashToken("token")},
+		{Name: "reader", Hash: HashToken("read-only-token"), ReadOnly: true},
+	}
+	options := []Option{WithLogger(slog.New(slog.NewTextHandler(logs, nil)))}
+	if readOnly {
+		options = append(options, WithReadOnly())
+	}
+	return New(handler, tokens, options...)
+}
+
+// failingHooks are hooks where all post-hooks fail.
+type failingHooks struct{}
+
+func (failingHooks) Pre(op secret.Operation, s secret.Secret) error {
+	return nil
+}
+
+func (failingHooks) Post(op secret.Operation, s secret.Secret) error {
+	return errors.New("post-hook")
+}
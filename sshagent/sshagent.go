@@ -0,0 +1,264 @@
+// Package sshagent provides an SSH agent that serves keys stored
+// as secrets over the OpenSSH agent protocol.
+package sshagent
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/KarlGW/secman/secret"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+const (
+	// SocketEnv is the environment variable with the path to the socket
+	// of an SSH agent.
+	SocketEnv = "SSH_AUTH_SOCK"
+)
+
+var (
+	// ErrNotConfirmed is returned when the use of a key that requires
+	// confirmation is not confirmed.
+	ErrNotConfirmed = errors.New("use of key was not confirmed")
+	// ErrPassphraseProtected is returned when a private key is protected
+	// by a passphrase.
+	ErrPassphraseProtected = errors.New("private key is protected by a passphrase")
+)
+
+// Agent is an SSH agent holding keys in memory. Keys added with
+// the confirmation constraint must be confirmed before each use.
+type Agent struct {
+	keyring agent.ExtendedAgent
+	confirm func(message string) bool
+	// confirmKeys contains the keys that require confirmation, keyed
+	// by their marshaled public key.
+	confirmKeys map[string]struct{}
+	mu          sync.Mutex
+}
+
+// Options contains options for an Agent.
+type Options struct {
+	// Confirm is called to confirm the use of a key. Returns true
+	// if confirmed.
+	Confirm func(message string) bool
+}
+
+// Option is a function that sets options to Options.
+type Option func(o *Options)
+
+// New creates a new Agent. By default the use of keys is confirmed
+// with the program set in SSH_ASKPASS.
+func New(options ...Option) *Agent {
+	opts := Options{
+		Confirm: Askpass,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	return &Agent{
+		keyring:     agent.NewKeyring().(agent.ExtendedAgent),
+		confirm:     opts.Confirm,
+		confirmKeys: make(map[string]struct{}),
+	}
+}
+
+// List returns the identities known to the agent.
+func (a *Agent) List() ([]*agent.Key, error) {
+	return a.keyring.List()
+}
+
+// Sign has the agent sign the data using a protocol 2 key as defined
+// in [PROTOCOL.agent] section 2.6.2.
+func (a *Agent) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	return a.SignWithFlags(key, data, 0)
+}
+
+// SignWithFlags signs like Sign, but allows for additional flags to be
+// sent/received. If the key requires confirmation it is confirmed first.
+func (a *Agent) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	a.mu.Lock()
+	_, ok := a.confirmKeys[string(key.Marshal())]
+	a.mu.Unlock()
+	if ok && !a.confirm(a.confirmMessage(key)) {
+		return nil, ErrNotConfirmed
+	}
+	return a.keyring.SignWithFlags(key, data, flags)
+}
+
+// Add adds a private key to the agent.
+func (a *Agent) Add(key agent.AddedKey) error {
+	signer, err := ssh.NewSignerFromKey(key.PrivateKey)
+	if err != nil {
+		return err
+	}
+	confirm := key.ConfirmBeforeUse
+	key.ConfirmBeforeUse = false
+	if err := a.keyring.Add(key); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if confirm {
+		a.confirmKeys[string(signer.PublicKey().Marshal())] = struct{}{}
+	}
+	return nil
+}
+
+// Remove removes all identities with the given public key.
+func (a *Agent) Remove(key ssh.PublicKey) error {
+	a.mu.Lock()
+	delete(a.confirmKeys, string(key.Marshal()))
+	a.mu.Unlock()
+	return a.keyring.Remove(key)
+}
+
+// RemoveAll removes all identities.
+func (a *Agent) RemoveAll() error {
+	a.mu.Lock()
+	clear(a.confirmKeys)
+	a.mu.Unlock()
+	return a.keyring.RemoveAll()
+}
+
+// Lock locks the agent. Sign and Remove will fail, and List will
+// return an empty list.
+func (a *Agent) Lock(passphrase []byte) error {
+	return a.keyring.Lock(passphrase)
+}
+
+// Unlock undoes the effect of Lock.
+func (a *Agent) Unlock(passphrase []byte) error {
+	return a.keyring.Unlock(passphrase)
+}
+
+// Signers returns signers for all the known keys.
+func (a *Agent) Signers() ([]ssh.Signer, error) {
+	return a.keyring.Signers()
+}
+
+// Extension processes a custom extension request.
+func (a *Agent) Extension(extensionType string, contents []byte) ([]byte, error) {
+	return a.keyring.Extension(extensionType, contents)
+}
+
+// confirmMessage returns the confirmation message for the key.
+func (a *Agent) confirmMessage(key ssh.PublicKey) string {
+	comment := ssh.FingerprintSHA256(key)
+	if keys, err := a.keyring.List(); err == nil {
+		for _, k := range keys {
+			if string(k.Marshal()) == string(key.Marshal()) && len(k.Comment) > 0 {
+				comment = k.Comment + " " + comment
+			}
+		}
+	}
+	return "Allow use of key " + comment + "?"
+}
+
+// Listen on a Unix socket at the provided path and serve the agent.
+// The socket is only accessible by the current user.
+func (a *Agent) Listen(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// Serve the agent on the listener until it is closed.
+func (a *Agent) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			_ = agent.ServeAgent(a, conn)
+		}()
+	}
+}
+
+// ParseKey decrypts the secret and parses its value as a private key.
+// The decrypted value is wiped after parsing.
+func ParseKey(s secret.Secret) (any, error) {
+	decrypted, err := s.Decrypt()
+	if err != nil {
+		return nil, err
+	}
+	defer clear(decrypted)
+
+	key, err := ssh.ParseRawPrivateKey(decrypted)
+	if err != nil {
+		var passphraseErr *ssh.PassphraseMissingError
+		if errors.As(err, &passphraseErr) {
+			return nil, fmt.Errorf("%w: %s", ErrPassphraseProtected, s.Name)
+		}
+		return nil, fmt.Errorf("%s: %w", s.Name, err)
+	}
+	return key, nil
+}
+
+// Askpass confirms with the program set in SSH_ASKPASS, the same way
+// as OpenSSH. Returns false if no program is set.
+func Askpass(message string) bool {
+	program := os.Getenv("SSH_ASKPASS")
+	if len(program) == 0 {
+		return false
+	}
+	cmd := exec.Command(program, message)
+	cmd.Env = append(os.Environ(), "SSH_ASKPASS_PROMPT=confirm")
+	return cmd.Run() == nil
+}
+
+// DefaultSocketPath returns the default path of the agent socket.
+func DefaultSocketPath() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if len(dir) == 0 {
+		dir = filepath.Join(os.TempDir(), "secman-"+strconv.Itoa(os.Getuid()))
+	} else {
+		dir = filepath.Join(dir, "secman")
+	}
+	return filepath.Join(dir, "ssh-agent.sock")
+}
+
+// WithConfirm sets the function for confirming the use of keys.
+func WithConfirm(fn func(message string) bool) Option {
+	return func(o *Options) {
+		o.Confirm = fn
+	}
+}
+
+// removeStaleSocket removes the socket at path if no agent
+// is listening on it.
+func removeStaleSocket(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return errors.New("an agent is already listening on " + path)
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,100 @@
+package sshagent
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/KarlGW/secman/secret"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestAgent_Sign(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			confirmBeforeUse bool
+			confirmed        bool
+		}
+		wantConfirm bool
+		wantErr     error
+	}{
+		{
+			name: "no confirmation",
+		},
+		{
+			name: "confirmed",
+			input: struct {
+				confirmBeforeUse bool
+				confirmed        bool
+			}{
+				confirmBeforeUse: true,
+				confirmed:        true,
+			},
+			wantConfirm: true,
+		},
+		{
+			name: "not confirmed",
+			input: struct {
+				confirmBeforeUse bool
+				confirmed        bool
+			}{
+				confirmBeforeUse: true,
+			},
+			wantConfirm: true,
+			wantErr:     ErrNotConfirmed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var gotConfirm bool
+			a := New(WithConfirm(func(message string) bool {
+				gotConfirm = true
+				return test.input.confirmed
+			}))
+
+			key := setupSecret(t)
+			privateKey, err := ParseKey(key)
+			if err != nil {
+				t.Fatalf("ParseKey() = unexpected error: %v\n", err)
+			}
+			if err := a.Add(agent.AddedKey{PrivateKey: privateKey, Comment: key.Name, ConfirmBeforeUse: test.input.confirmBeforeUse}); err != nil {
+				t.Fatalf("Add() = unexpected error: %v\n", err)
+			}
+			keys, _ := a.List()
+			publicKey, _ := ssh.ParsePublicKey(keys[0].Blob)
+
+			_, gotErr := a.Sign(publicKey, []byte("data"))
+
+			if diff := cmp.Diff(test.wantConfirm, gotConfirm); diff != "" {
+				t.Errorf("Sign() = unexpected confirmation (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Sign() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func setupSecret(t *testing.T) secret.Secret {
+	t.Helper()
+	_, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	b, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() = unexpected error: %v\n", err)
+	}
+	value := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: b})
+	s, err := secret.NewSecret("id_ed25519", string(value), bytes.Repeat([]byte{1}, secret.KeyLength), secret.WithType(secret.TypeSSHKey))
+	if err != nil {
+		t.Fatalf("NewSecret() = unexpected error: %v\n", err)
+	}
+	return s
+}
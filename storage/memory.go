@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Memory represents a storage in memory.
+type Memory struct {
+	data    []byte
+	updated time.Time
+	mu      sync.RWMutex
+}
+
+// NewMemory creates a new Memory storage with the provided data.
+func NewMemory(data []byte) *Memory {
+	m := &Memory{data: data}
+	if data != nil {
+		m.updated = time.Now()
+	}
+	return m
+}
+
+// Save data to memory.
+func (m *Memory) Save(data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data = data
+	m.updated = time.Now()
+	return nil
+}
+
+// Load data from memory.
+func (m *Memory) Load() ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.data == nil {
+		return nil, fmt.Errorf("%w: no data in memory", ErrStorageSourceNotFound)
+	}
+	return m.data, nil
+}
+
+// Updated returns the time the data was last saved.
+func (m *Memory) Updated() (time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.updated, nil
+}
@@ -0,0 +1,110 @@
+package ui
+
+import "unicode/utf8"
+
+// keyType is the type of a key press.
+type keyType int
+
+const (
+	keyUnknown keyType = iota
+	keyRune
+	keyEnter
+	keyEsc
+	keyBackspace
+	keyTab
+	keyBacktab
+	keyUp
+	keyDown
+	keyPgUp
+	keyPgDown
+	keyHome
+	keyEnd
+	keyCtrlC
+	keyCtrlS
+	keyCtrlU
+)
+
+// key is a key press.
+type key struct {
+	typ keyType
+	r   rune
+}
+
+// parseKeys parses bytes read from a terminal in raw mode into key presses.
+// A single escape byte is an escape key press, since escape sequences
+// are read as a whole.
+func parseKeys(b []byte) []key {
+	var keys []key
+	for len(b) > 0 {
+		k, n := parseKey(b)
+		keys = append(keys, k)
+		b = b[n:]
+	}
+	return keys
+}
+
+// parseKey parses the first key press of b and returns it together
+// with the number of bytes it occupies.
+func parseKey(b []byte) (key, int) {
+	switch b[0] {
+	case 0x1b:
+		if len(b) == 1 {
+			return key{typ: keyEsc}, 1
+		}
+		if b[1] == '[' || b[1] == 'O' {
+			return parseEscapeSequence(b)
+		}
+		return key{typ: keyEsc}, 1
+	case '\r', '\n':
+		return key{typ: keyEnter}, 1
+	case 0x7f, 0x08:
+		return key{typ: keyBackspace}, 1
+	case '\t':
+		return key{typ: keyTab}, 1
+	case 0x03:
+		return key{typ: keyCtrlC}, 1
+	case 0x13:
+		return key{typ: keyCtrlS}, 1
+	case 0x15:
+		return key{typ: keyCtrlU}, 1
+	}
+	if b[0] < 0x20 {
+		return key{typ: keyUnknown}, 1
+	}
+	r, n := utf8.DecodeRune(b)
+	if r == utf8.RuneError {
+		return key{typ: keyUnknown}, n
+	}
+	return key{typ: keyRune, r: r}, n
+}
+
+// parseEscapeSequence parses an escape sequence starting with ESC [
+// or ESC O. The sequence ends with a byte in the range 0x40-0x7e.
+func parseEscapeSequence(b []byte) (key, int) {
+	end := 2
+	for end < len(b) && (b[end] < 0x40 || b[end] > 0x7e) {
+		end++
+	}
+	if end == len(b) {
+		return key{typ: keyUnknown}, len(b)
+	}
+
+	var typ keyType
+	switch string(b[2 : end+1]) {
+	case "A":
+		typ = keyUp
+	case "B":
+		typ = keyDown
+	case "Z":
+		typ = keyBacktab
+	case "H", "1~", "7~":
+		typ = keyHome
+	case "F", "4~", "8~":
+		typ = keyEnd
+	case "5~":
+		typ = keyPgUp
+	case "6~":
+		typ = keyPgDown
+	}
+	return key{typ: typ}, end + 1
+}
@@ -0,0 +1,454 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/KarlGW/secman/secret"
+)
+
+// mode is the mode of the interface.
+type mode int
+
+const (
+	// modeList is for browsing the secrets.
+	modeList mode = iota
+	// modeFilter is for editing the filter.
+	modeFilter
+	// modeForm is for creating or editing a secret.
+	modeForm
+	// modeConfirm is for confirming deletion of a secret.
+	modeConfirm
+)
+
+// model contains the state of the interface.
+type model struct {
+	handler   Handler
+	clipboard func(value string) error
+	secrets   secret.Secrets
+	filter    string
+	selected  int
+	offset    int
+	// revealedID is the ID of the secret with its value revealed.
+	revealedID string
+	revealed   string
+	mode       mode
+	form       *form
+	status     string
+	quit       bool
+}
+
+// fieldID identifies a field of a form.
+type fieldID int
+
+const (
+	fieldName fieldID = iota
+	fieldValue
+	fieldDisplayName
+	fieldType
+	fieldLabels
+)
+
+// field is an input field of a form.
+type field struct {
+	id     fieldID
+	label  string
+	value  string
+	masked bool
+}
+
+// form is a form for creating or editing a secret.
+type form struct {
+	// id is the ID of the secret being edited. Empty when creating.
+	id     string
+	name   string
+	fields []field
+	focus  int
+}
+
+// newModel creates a new model and loads the secrets.
+func newModel(handler Handler, clipboard func(value string) error) (*model, error) {
+	m := &model{handler: handler, clipboard: clipboard}
+	if err := m.refresh(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// refresh loads the secrets matching the filter. Without a filter
+// all secrets are loaded and sorted by name.
+func (m *model) refresh() error {
+	var secrets secret.Secrets
+	var err error
+	if len(strings.TrimSpace(m.filter)) == 0 {
+		secrets, err = m.handler.ListSecrets()
+		sort.SliceStable(secrets, func(i, j int) bool {
+			return secrets[i].Name < secrets[j].Name
+		})
+	} else {
+		secrets, err = m.handler.SearchSecrets(m.filter)
+	}
+	if err != nil {
+		return err
+	}
+	m.secrets = secrets
+	m.selected = max(0, min(m.selected, len(m.secrets)-1))
+	return nil
+}
+
+// current returns the selected secret.
+func (m *model) current() (secret.Secret, bool) {
+	if m.selected < 0 || m.selected >= len(m.secrets) {
+		return secret.Secret{}, false
+	}
+	return m.secrets[m.selected], true
+}
+
+// update updates the model from a key press.
+func (m *model) update(k key) {
+	if k.typ == keyCtrlC {
+		m.quit = true
+		return
+	}
+	switch m.mode {
+	case modeList:
+		m.updateList(k)
+	case modeFilter:
+		m.updateFilter(k)
+	case modeForm:
+		m.updateForm(k)
+	case modeConfirm:
+		m.updateConfirm(k)
+	}
+}
+
+// updateList handles key presses when browsing.
+func (m *model) updateList(k key) {
+	m.status = ""
+	if m.move(k) {
+		return
+	}
+	switch k.typ {
+	case keyEnter:
+		m.toggleReveal()
+	case keyEsc:
+		if len(m.filter) > 0 {
+			m.setFilter("")
+		}
+	case keyRune:
+		switch k.r {
+		case 'q':
+			m.quit = true
+		case '/':
+			m.mode = modeFilter
+		case 'r', ' ':
+			m.toggleReveal()
+		case 'c':
+			m.copy()
+		case 'n':
+			m.newForm()
+		case 'e':
+			m.editForm()
+		case 'd':
+			if s, ok := m.current(); ok {
+				m.mode = modeConfirm
+				m.status = fmt.Sprintf("Delete secret %s? [y/N]", s.Name)
+			}
+		}
+	}
+}
+
+// updateFilter handles key presses when editing the filter.
+func (m *model) updateFilter(k key) {
+	if m.move(k) {
+		return
+	}
+	switch k.typ {
+	case keyEnter:
+		m.mode = modeList
+	case keyEsc:
+		m.mode = modeList
+		m.setFilter("")
+	case keyBackspace:
+		if r := []rune(m.filter); len(r) > 0 {
+			m.setFilter(string(r[:len(r)-1]))
+		}
+	case keyCtrlU:
+		m.setFilter("")
+	case keyRune:
+		m.setFilter(m.filter + string(k.r))
+	}
+}
+
+// updateForm handles key presses in a form.
+func (m *model) updateForm(k key) {
+	f := m.form
+	switch k.typ {
+	case keyEsc:
+		m.closeForm("")
+	case keyTab, keyDown:
+		f.focus = (f.focus + 1) % len(f.fields)
+	case keyBacktab, keyUp:
+		f.focus = (f.focus + len(f.fields) - 1) % len(f.fields)
+	case keyEnter:
+		if f.focus < len(f.fields)-1 {
+			f.focus++
+			return
+		}
+		m.submitForm()
+	case keyCtrlS:
+		m.submitForm()
+	case keyBackspace:
+		if r := []rune(f.fields[f.focus].value); len(r) > 0 {
+			f.fields[f.focus].value = string(r[:len(r)-1])
+		}
+	case keyCtrlU:
+		f.fields[f.focus].value = ""
+	case keyRune:
+		f.fields[f.focus].value += string(k.r)
+	}
+}
+
+// updateConfirm handles key presses when confirming deletion.
+func (m *model) updateConfirm(k key) {
+	m.mode = modeList
+	s, ok := m.current()
+	if !ok || k.typ != keyRune || (k.r != 'y' && k.r != 'Y') {
+		m.status = "Cancelled"
+		return
+	}
+	err := m.handler.DeleteSecretByID(s.ID)
+	if err != nil && !errors.Is(err, secret.ErrPostHook) {
+		m.status = err.Error()
+		return
+	}
+	m.status = withWarning(fmt.Sprintf("Deleted secret %s", s.Name), err)
+	if err := m.refresh(); err != nil {
+		m.status = err.Error()
+	}
+}
+
+// move moves the selection. Returns true if the key press was
+// a movement.
+func (m *model) move(k key) bool {
+	switch k.typ {
+	case keyUp:
+		m.selected--
+	case keyDown:
+		m.selected++
+	case keyPgUp:
+		m.selected -= 10
+	case keyPgDown:
+		m.selected += 10
+	case keyHome:
+		m.selected = 0
+	case keyEnd:
+		m.selected = len(m.secrets) - 1
+	case keyRune:
+		if m.mode != modeList {
+			return false
+		}
+		switch k.r {
+		case 'k':
+			m.selected--
+		case 'j':
+			m.selected++
+		case 'g':
+			m.selected = 0
+		case 'G':
+			m.selected = len(m.secrets) - 1
+		default:
+			return false
+		}
+	default:
+		return false
+	}
+	m.selected = max(0, min(m.selected, len(m.secrets)-1))
+	return true
+}
+
+// setFilter sets the filter and refreshes the secrets. If the filter
+// is an invalid query the previous secrets are kept.
+func (m *model) setFilter(filter string) {
+	m.filter = filter
+	m.selected = 0
+	if err := m.refresh(); err != nil {
+		m.status = err.Error()
+		return
+	}
+	m.status = ""
+}
+
+// toggleReveal reveals or hides the value of the selected secret.
+func (m *model) toggleReveal() {
+	s, ok := m.current()
+	if !ok {
+		return
+	}
+	if m.revealedID == s.ID {
+		m.revealedID, m.revealed = "", ""
+		return
+	}
+	decrypted, err := s.Decrypt()
+	if err != nil {
+		m.status = err.Error()
+		return
+	}
+	m.revealedID, m.revealed = s.ID, string(decrypted)
+}
+
+// copy copies the value of the selected secret to the clipboard.
+func (m *model) copy() {
+	s, ok := m.current()
+	if !ok {
+		return
+	}
+	if m.clipboard == nil {
+		m.status = "Clipboard is not available"
+		return
+	}
+	decrypted, err := s.Decrypt()
+	if err != nil {
+		m.status = err.Error()
+		return
+	}
+	if err := m.clipboard(string(decrypted)); err != nil {
+		m.status = err.Error()
+		return
+	}
+	m.status = fmt.Sprintf("Copied value of %s to the clipboard", s.Name)
+}
+
+// newForm opens a form for creating a secret.
+func (m *model) newForm() {
+	m.form = &form{
+		fields: append([]field{{id: fieldName, label: "Name"}}, formFields(secret.Secret{}, "Value")...),
+	}
+	m.mode = modeForm
+}
+
+// editForm opens a form for editing the selected secret.
+func (m *model) editForm() {
+	s, ok := m.current()
+	if !ok {
+		return
+	}
+	m.form = &form{
+		id:     s.ID,
+		name:   s.Name,
+		fields: formFields(s, "Value (empty keeps the current)"),
+	}
+	m.mode = modeForm
+}
+
+// formFields returns the fields of a form for the secret.
+func formFields(s secret.Secret, valueLabel string) []field {
+	var t string
+	if len(s.ID) > 0 {
+		t = s.Type.String()
+	}
+	return []field{
+		{id: fieldValue, label: valueLabel, masked: true},
+		{id: fieldDisplayName, label: "Display name", value: s.DisplayName},
+		{id: fieldType, label: "Type", value: t},
+		{id: fieldLabels, label: "Labels (comma separated)", value: strings.Join(s.Labels, ",")},
+	}
+}
+
+// submitForm creates or updates the secret from the form.
+func (m *model) submitForm() {
+	f := m.form
+	options, err := f.options()
+	if err != nil {
+		m.status = err.Error()
+		return
+	}
+
+	value := f.value(fieldValue)
+	if len(f.id) == 0 {
+		name := strings.TrimSpace(f.value(fieldName))
+		if len(name) == 0 || len(value) == 0 {
+			m.status = "A name and a value must be provided"
+			return
+		}
+		_, err := m.handler.AddSecret(name, value, options...)
+		if err != nil && !errors.Is(err, secret.ErrPostHook) {
+			m.status = err.Error()
+			return
+		}
+		m.closeForm(withWarning(fmt.Sprintf("Created secret %s", name), err))
+		return
+	}
+
+	if len(value) > 0 {
+		options = append(options, secret.WithValue([]byte(value)))
+	}
+	_, err = m.handler.UpdateSecretByID(f.id, options...)
+	if err != nil && !errors.Is(err, secret.ErrPostHook) {
+		m.status = err.Error()
+		return
+	}
+	if m.revealedID == f.id {
+		m.revealedID, m.revealed = "", ""
+	}
+	m.closeForm(withWarning(fmt.Sprintf("Updated secret %s", f.name), err))
+}
+
+// withWarning returns the status with the error as a warning, if any.
+// It is used for failed post-hooks, since the change has been saved.
+func withWarning(status string, err error) string {
+	if err == nil {
+		return status
+	}
+	return status + " (warning: " + err.Error() + ")"
+}
+
+// closeForm closes the form, refreshes the secrets and sets the status.
+func (m *model) closeForm(status string) {
+	m.form = nil
+	m.mode = modeList
+	m.status = status
+	if err := m.refresh(); err != nil {
+		m.status = err.Error()
+	}
+}
+
+// options returns the secret options of the metadata fields
+// of the form. When editing, the display name and labels are
+// always set, so that they can be cleared.
+func (f *form) options() ([]secret.SecretOption, error) {
+	var options []secret.SecretOption
+	editing := len(f.id) > 0
+	if displayName := strings.TrimSpace(f.value(fieldDisplayName)); editing || len(displayName) > 0 {
+		options = append(options, secret.WithDisplayName(displayName))
+	}
+	if t := strings.TrimSpace(f.value(fieldType)); len(t) > 0 {
+		typ, err := secret.ParseType(t)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, secret.WithType(typ))
+	}
+	var labels []string
+	for _, label := range strings.Split(f.value(fieldLabels), ",") {
+		if label = strings.TrimSpace(label); len(label) > 0 {
+			labels = append(labels, label)
+		}
+	}
+	if editing || len(labels) > 0 {
+		options = append(options, secret.WithLabels(labels...))
+	}
+	return options, nil
+}
+
+// value returns the value of the field with the provided ID.
+func (f *form) value(id fieldID) string {
+	for _, field := range f.fields {
+		if field.id == id {
+			return field.value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,161 @@
+package ui
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/KarlGW/secman/internal/security"
+	"github.com/KarlGW/secman/secret"
+	"github.com/KarlGW/secman/storage"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseKeys(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input []byte
+		want  []key
+	}{
+		{
+			name:  "runes",
+			input: []byte("aö"),
+			want:  []key{{typ: keyRune, r: 'a'}, {typ: keyRune, r: 'ö'}},
+		},
+		{
+			name:  "escape",
+			input: []byte{0x1b},
+			want:  []key{{typ: keyEsc}},
+		},
+		{
+			name:  "escape sequences",
+			input: []byte("\x1b[A\x1b[B\x1b[5~\x1b[Z"),
+			want:  []key{{typ: keyUp}, {typ: keyDown}, {typ: keyPgUp}, {typ: keyBacktab}},
+		},
+		{
+			name:  "control keys",
+			input: []byte{'\r', 0x7f, '\t', 0x03},
+			want:  []key{{typ: keyEnter}, {typ: keyBackspace}, {typ: keyTab}, {typ: keyCtrlC}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := parseKeys(test.input)
+
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(key{})); diff != "" {
+				t.Errorf("parseKeys() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestModel_Update(t *testing.T) {
+	var tests = []struct {
+		name       string
+		input      []byte
+		want       []string
+		wantStatus string
+	}{
+		{
+			name:  "filter",
+			input: []byte("/db\r"),
+			want:  []string{"db-password"},
+		},
+		{
+			name:       "delete",
+			input:      []byte("jdy"),
+			want:       []string{"api-key"},
+			wantStatus: "Deleted secret db-password",
+		},
+		{
+			name:       "delete - cancelled",
+			input:      []byte("dn"),
+			want:       []string{"api-key", "db-password"},
+			wantStatus: "Cancelled",
+		},
+		{
+			name:       "create",
+			input:      []byte("nnew\tvalue\r\r\r\r"),
+			want:       []string{"api-key", "db-password", "new"},
+			wantStatus: "Created secret new",
+		},
+		{
+			name:       "create - no value",
+			input:      []byte("nnew\x13"),
+			want:       []string{"api-key", "db-password"},
+			wantStatus: "A name and a value must be provided",
+		},
+		{
+			name:       "edit",
+			input:      []byte("e\t\t\x15credential\x13/type:credential\r"),
+			want:       []string{"api-key"},
+			wantStatus: "",
+		},
+		{
+			name:       "edit - clear labels",
+			input:      []byte("je\t\t\t\x15\x13/label:prod\r"),
+			want:       nil,
+			wantStatus: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := setupHandler(t)
+			m, err := newModel(handler, nil)
+			if err != nil {
+				t.Fatalf("newModel() = unexpected error: %v\n", err)
+			}
+
+			for _, k := range parseKeys(test.input) {
+				m.update(k)
+			}
+
+			var got []string
+			for _, s := range m.secrets {
+				got = append(got, s.Name)
+			}
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("update() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantStatus, m.status); diff != "" {
+				t.Errorf("update() = unexpected status (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestModel_Reveal(t *testing.T) {
+	m, err := newModel(setupHandler(t), nil)
+	if err != nil {
+		t.Fatalf("newModel() = unexpected error: %v\n", err)
+	}
+
+	m.update(key{typ: keyEnter})
+	if diff := cmp.Diff("key", m.revealed); diff != "" {
+		t.Errorf("update() = unexpected result (-want +got)\n%s\n", diff)
+	}
+
+	m.update(key{typ: keyEnter})
+	if diff := cmp.Diff("", m.revealed); diff != "" {
+		t.Errorf("update() = unexpected result (-want +got)\n%s\n", diff)
+	}
+}
+
+func setupHandler(t *testing.T) *secret.Handler {
+	t.Helper()
+	key := security.Key{Value: bytes.Repeat([]byte{1}, secret.KeyLength)}
+	handler, err := secret.NewHandler("profile", key, key, storage.NewMemory(nil))
+	if err != nil {
+		t.Fatalf("NewHandler() = unexpected error: %v\n", err)
+	}
+	if _, err := handler.AddSecret("db-password", "password", secret.WithLabels("prod")); err != nil {
+		t.Fatalf("AddSecret() = unexpected error: %v\n", err)
+	}
+	if _, err := handler.AddSecret("api-key", "key"); err != nil {
+		t.Fatalf("AddSecret() = unexpected error: %v\n", err)
+	}
+	return handler
+}
@@ -0,0 +1,166 @@
+// Package ui provides a full-screen terminal interface for browsing
+// and editing secrets.
+package ui
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/KarlGW/secman/secret"
+	"golang.org/x/term"
+)
+
+const (
+	enterAltScreen = "\033[?1049h"
+	exitAltScreen  = "\033[?1049l"
+	hideCursor     = "\033[?25l"
+	showCursor     = "\033[?25h"
+	cursorHome     = "\033[H"
+	clearLine      = "\033[K"
+)
+
+var (
+	// ErrNotTerminal is returned when the interface is not run
+	// in a terminal.
+	ErrNotTerminal = errors.New("the interface must be run in a terminal")
+)
+
+// Handler is the interface that wraps around the methods of
+// secret.Handler used by the interface.
+type Handler interface {
+	ListSecrets() (secret.Secrets, error)
+	SearchSecrets(query string) (secret.Secrets, error)
+	AddSecret(name, value string, options ...secret.SecretOption) (secret.Secret, error)
+	UpdateSecretByID(id string, options ...secret.SecretOption) (secret.Secret, error)
+	DeleteSecretByID(id string) error
+}
+
+// Options contains options for the interface.
+type Options struct {
+	// Clipboard is called with the value to copy to the clipboard.
+	Clipboard func(value string) error
+}
+
+// Option is a function that sets options to Options.
+type Option func(o *Options)
+
+// Run the interface until it is quit. The terminal is restored
+// to its previous state when returning.
+func Run(handler Handler, options ...Option) error {
+	opts := Options{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(in) || !term.IsTerminal(out) {
+		return ErrNotTerminal
+	}
+
+	m, err := newModel(handler, opts.Clipboard)
+	if err != nil {
+		return err
+	}
+
+	state, err := term.MakeRaw(in)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(in, state)
+	io.WriteString(os.Stdout, enterAltScreen+hideCursor)
+	defer io.WriteString(os.Stdout, showCursor+exitAltScreen)
+
+	input, errs := readInput(os.Stdin)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	// The size of the terminal is checked periodically to handle resizes.
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	var width, height int
+	redraw := true
+	for !m.quit {
+		// Render on input and when the terminal has been resized.
+		if w, h := size(out); redraw || w != width || h != height {
+			width, height = w, h
+			if err := render(os.Stdout, m.view(width, height)); err != nil {
+				return err
+			}
+		}
+
+		redraw = true
+		select {
+		case b := <-input:
+			for _, k := range parseKeys(b) {
+				m.update(k)
+			}
+		case err := <-errs:
+			return err
+		case <-signals:
+			return nil
+		case <-ticker.C:
+			redraw = false
+		}
+	}
+	return nil
+}
+
+// WithClipboard sets the function for copying values to the clipboard.
+func WithClipboard(fn func(value string) error) Option {
+	return func(o *Options) {
+		o.Clipboard = fn
+	}
+}
+
+// readInput reads from r until an error occurs. Each read is sent on
+// the returned channel, and the error on the error channel.
+func readInput(r io.Reader) (<-chan []byte, <-chan error) {
+	input, errs := make(chan []byte), make(chan error, 1)
+	go func() {
+		buf := make([]byte, 256)
+		for {
+			n, err := r.Read(buf)
+			if n > 0 {
+				b := make([]byte, n)
+				copy(b, buf[:n])
+				input <- b
+			}
+			if err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+	return input, errs
+}
+
+// size returns the size of the terminal. Defaults to 80x24
+// if the size cannot be retrieved.
+func size(fd int) (int, int) {
+	width, height, err := term.GetSize(fd)
+	if err != nil {
+		return 80, 24
+	}
+	return width, height
+}
+
+// render writes the lines to the terminal in a single write.
+func render(w io.Writer, lines []string) error {
+	var b strings.Builder
+	b.WriteString(cursorHome)
+	for i, line := range lines {
+		b.WriteString(line)
+		b.WriteString(clearLine)
+		if i < len(lines)-1 {
+			b.WriteString("\r\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
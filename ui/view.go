@@ -0,0 +1,183 @@
+package ui
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	reverse = "\033[7m"
+	bold    = "\033[1m"
+	reset   = "\033[0m"
+	// maskedValue is shown in place of values that are not revealed.
+	maskedValue = "********"
+)
+
+// Help texts for the modes.
+var help = map[mode]string{
+	modeList:    "↑/↓ move  / filter  enter reveal  c copy  n new  e edit  d delete  q quit",
+	modeFilter:  "type to filter  ↑/↓ move  enter done  esc clear",
+	modeForm:    "tab/↑/↓ next field  enter next/save  ctrl+s save  esc cancel",
+	modeConfirm: "y delete  any other key cancels",
+}
+
+// view renders the model to lines of the provided width and height.
+func (m *model) view(width, height int) []string {
+	width, height = max(width, 20), max(height, 6)
+	lines := make([]string, 0, height)
+
+	lines = append(lines, bold+pad(" secman - "+countText(len(m.secrets)), width)+reset)
+	filter := " Filter: " + m.filter
+	if m.mode == modeFilter {
+		filter += "_"
+	}
+	lines = append(lines, pad(filter, width))
+	lines = append(lines, strings.Repeat("─", width))
+
+	bodyHeight := height - 5
+	listWidth := max(20, width*2/5)
+	detailWidth := max(0, width-listWidth-3)
+	list := m.listView(listWidth, bodyHeight)
+	var detail []string
+	switch m.mode {
+	case modeForm:
+		detail = m.formView()
+	default:
+		detail = m.detailView()
+	}
+
+	for i := 0; i < bodyHeight; i++ {
+		var l, d string
+		if i < len(list) {
+			l = list[i]
+		} else {
+			l = strings.Repeat(" ", listWidth)
+		}
+		if i < len(detail) {
+			d = detail[i]
+		}
+		lines = append(lines, l+" │ "+pad(d, detailWidth))
+	}
+
+	lines = append(lines, strings.Repeat("─", width))
+	status := m.status
+	if len(status) == 0 {
+		status = help[m.mode]
+	}
+	lines = append(lines, pad(" "+status, width))
+	return lines
+}
+
+// listView renders the list of secrets. The list is scrolled
+// to keep the selected secret visible.
+func (m *model) listView(width, height int) []string {
+	if m.selected < m.offset {
+		m.offset = m.selected
+	}
+	if m.selected >= m.offset+height {
+		m.offset = m.selected - height + 1
+	}
+	m.offset = max(0, min(m.offset, len(m.secrets)-height))
+
+	var lines []string
+	for i := m.offset; i < len(m.secrets) && len(lines) < height; i++ {
+		line := pad(" "+m.secrets[i].Name, width)
+		if i == m.selected {
+			line = reverse + line + reset
+		}
+		lines = append(lines, line)
+	}
+	if len(m.secrets) == 0 {
+		lines = append(lines, pad(" No secrets", width))
+	}
+	return lines
+}
+
+// detailView renders the metadata of the selected secret.
+func (m *model) detailView() []string {
+	s, ok := m.current()
+	if !ok {
+		return nil
+	}
+	tags := make([]string, 0, len(s.Tags))
+	for k, v := range s.Tags {
+		tags = append(tags, k+"="+v)
+	}
+	sort.Strings(tags)
+
+	lines := []string{
+		"ID:           " + s.ID,
+		"Name:         " + s.Name,
+		"Display name: " + s.DisplayName,
+		"Type:         " + s.Type.String(),
+		"Labels:       " + strings.Join(s.Labels, ", "),
+		"Tags:         " + strings.Join(tags, ", "),
+		"Created:      " + formatTime(s.Created),
+		"Updated:      " + formatTime(s.Updated),
+		"",
+	}
+	if m.revealedID != s.ID {
+		return append(lines, "Value:        "+maskedValue)
+	}
+	lines = append(lines, "Value:")
+	return append(lines, strings.Split(m.revealed, "\n")...)
+}
+
+// formView renders the form.
+func (m *model) formView() []string {
+	f := m.form
+	title := "New secret"
+	if len(f.id) > 0 {
+		title = "Edit secret " + f.name
+	}
+	lines := []string{title, ""}
+	for i, field := range f.fields {
+		value := field.value
+		if field.masked {
+			value = strings.Repeat("*", len([]rune(value)))
+		}
+		prefix := "  "
+		if i == f.focus {
+			prefix = "> "
+			value += "_"
+		}
+		lines = append(lines, prefix+field.label+":", "    "+value, "")
+	}
+	return lines
+}
+
+// pad truncates or pads the string with spaces to the provided width.
+// Control characters are replaced with spaces to not disturb
+// the terminal.
+func pad(s string, width int) string {
+	r := []rune(s)
+	for i := range r {
+		if unicode.IsControl(r[i]) {
+			r[i] = ' '
+		}
+	}
+	if len(r) > width {
+		return string(r[:width])
+	}
+	return string(r) + strings.Repeat(" ", width-len(r))
+}
+
+// countText returns the text for the number of secrets.
+func countText(n int) string {
+	if n == 1 {
+		return "1 secret"
+	}
+	return strconv.Itoa(n) + " secrets"
+}
+
+// formatTime formats the time. The zero time is formatted as
+// an empty string.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format(time.DateTime)
+}